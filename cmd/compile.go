@@ -20,13 +20,18 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/infobloxopen/seal/pkg/atomic"
 	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/testgen"
 
-	// register the rego backend compiler
-	_ "github.com/infobloxopen/seal/pkg/compiler/rego"
+	// register the ir, rego, rls and sql backend compilers
+	_ "github.com/infobloxopen/seal/pkg/compiler/ir"
+	_ "github.com/infobloxopen/seal/pkg/compiler/rls"
+	_ "github.com/infobloxopen/seal/pkg/compiler/sql"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -36,6 +41,8 @@ var compileSettings struct {
 	backend      string   // backend compiler
 	outputFile   string   // output filename
 	swaggerFiles []string // swagger file to read in types
+	emitTests    bool     // emit rego unit tests of the policies
+	decisions    bool     // emit the rego decisions set of the rules matching the request
 }
 
 // compileCmd represents the compile command
@@ -50,24 +57,22 @@ a custom backend to target your own runtime.`,
 }
 
 func compileFunc(cmd *cobra.Command, args []string) {
-	if len(compileSettings.swaggerFiles) == 0 {
-		logrus.Fatal("swagger file is required for inferring types")
+	if compileSettings.emitTests && compileSettings.backend != compiler_rego.Language {
+		logrus.WithField("backend", compileSettings.backend).Fatal("--emit-tests is only supported by the rego backend")
 	}
-
-	swaggerSpec := []string{}
-	for _, file := range compileSettings.swaggerFiles {
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			logrus.WithField("file", file).WithError(err).Fatal("could not read swagger file")
-		}
-		swaggerSpec = append(swaggerSpec, string(content))
-
+	if compileSettings.decisions && compileSettings.backend != compiler_rego.Language {
+		logrus.WithField("backend", compileSettings.backend).Fatal("--decisions is only supported by the rego backend")
 	}
 
+	swaggerSpec := readSwaggerFiles(compileSettings.swaggerFiles)
+
 	cplr, err := compiler.NewPolicyCompiler(compileSettings.backend, swaggerSpec...)
 	if err != nil {
 		logrus.WithError(err).Fatal("could not create policy compiler")
 	}
+	if compileSettings.decisions {
+		cplr.BackendCompiler().(*compiler_rego.CompilerRego).WithDecisions(true)
+	}
 
 	var output []string
 	for _, fil := range compileSettings.files {
@@ -80,12 +85,16 @@ func compileFunc(cmd *cobra.Command, args []string) {
 
 		// compile policies from policy rules
 		pkgname := strings.TrimSuffix(path.Base(fil), ".seal")
-		out, err := cplr.Compile(pkgname, string(input))
+		out, err := cplr.CompileFile(fil, pkgname, string(input))
 		if err != nil {
 			logrus.WithField("file", fil).WithError(err).Fatal("could not compile rules file")
 		}
 
 		output = append(output, out)
+
+		if compileSettings.emitTests {
+			emitTests(cplr, fil, pkgname, string(input))
+		}
 	}
 
 	// write to output
@@ -102,6 +111,33 @@ func compileFunc(cmd *cobra.Command, args []string) {
 	}
 }
 
+// emitTests writes the rego unit tests of the policies of the seal file to <pkgname>_test.rego,
+// next to the output file, or next to the seal file if the output is stdout.
+// An existing tests file is only overwritten if it was generated.
+func emitTests(cplr *compiler.PolicyCompiler, fil, pkgname, input string) {
+	pols, err := cplr.Parse(fil, input)
+	if err != nil {
+		logrus.WithField("file", fil).WithError(err).Fatal("could not parse rules file")
+	}
+
+	cases, err := testgen.Generate(pols, cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithField("file", fil).WithError(err).Fatal("could not generate tests")
+	}
+
+	dir := filepath.Dir(fil)
+	if out := compileSettings.outputFile; out != "" && out != "-" {
+		dir = filepath.Dir(out)
+	}
+	testFile := filepath.Join(dir, pkgname+"_test.rego")
+	if content, err := ioutil.ReadFile(testFile); err == nil && !testgen.IsGenerated(content) {
+		logrus.WithField("file", testFile).Fatal("refusing to overwrite tests file which was not generated by seal, it has no DO NOT EDIT header")
+	}
+	if err := atomic.WriteFile(testFile, []byte(testgen.Rego(pkgname, cases)), 0644); err != nil {
+		logrus.WithField("file", testFile).WithError(err).Fatal("could not write to tests file")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(compileCmd)
 
@@ -114,4 +150,8 @@ func init() {
 		"filenames to read types")
 	compileCmd.PersistentFlags().StringVarP(&compileSettings.outputFile, "output", "o", "",
 		"output file")
+	compileCmd.PersistentFlags().BoolVar(&compileSettings.emitTests, "emit-tests", false,
+		"write rego unit tests of each seal file to <package>_test.rego next to the output file")
+	compileCmd.PersistentFlags().BoolVar(&compileSettings.decisions, "decisions", false,
+		"emit the rego decisions set tracing the statement and line of the rules matching the request")
 }
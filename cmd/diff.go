@@ -0,0 +1,148 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/access"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+)
+
+var diffSettings struct {
+	swaggerFiles []string // swagger files to read in types
+	format       string   // output format: text or json
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <old seal file or directory> <new seal file or directory>",
+	Short: "Compares the effective access of two versions of policies",
+	Long: `diff compares the effective access of two versions of
+seal files, not their text, and lists the (subject, type,
+base verb) tuples newly granted (+), revoked (-), or with
+changed conditions (~). Rules of the new version with a
+broader type pattern than the same rule of the old version
+are flagged as widened (!).
+
+Reordered or reformatted rules show no change. diff exits
+with status 1 if the effective access changed.`,
+	Args: cobra.ExactArgs(2),
+	Run:  diffFunc,
+}
+
+// diffResult is the difference between the policies, as printed in JSON
+type diffResult struct {
+	Changes []*access.Change `json:"changes"`
+	Widened []*diffWidening  `json:"widened"`
+}
+
+// diffWidening is a widened rule, with the locations of the old and new rules
+type diffWidening struct {
+	OldLocation string `json:"old_location,omitempty"`
+	OldRule     string `json:"old_rule"`
+	NewLocation string `json:"new_location,omitempty"`
+	NewRule     string `json:"new_rule"`
+	*access.Widening
+}
+
+func diffFunc(cmd *cobra.Command, args []string) {
+	if diffSettings.format != "text" && diffSettings.format != "json" {
+		logrus.WithField("format", diffSettings.format).Fatal("invalid output format, expected text or json")
+	}
+
+	swaggerSpec := readSwaggerFiles(diffSettings.swaggerFiles)
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggerSpec...)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create policy compiler")
+	}
+
+	oldRules, err := access.Expand(parsePolicyFiles(cplr, args[:1]), cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithField("file", args[0]).WithError(err).Fatal("could not expand rules files")
+	}
+	newRules, err := access.Expand(parsePolicyFiles(cplr, args[1:]), cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithField("file", args[1]).WithError(err).Fatal("could not expand rules files")
+	}
+
+	res := &diffResult{
+		Changes: access.Diff(oldRules, newRules, cplr.SwaggerTypes()),
+		Widened: []*diffWidening{},
+	}
+	for _, w := range access.Widened(oldRules, newRules) {
+		res.Widened = append(res.Widened, &diffWidening{
+			OldLocation: w.Old.Pos.String(),
+			OldRule:     w.Old.Statement.String(),
+			NewLocation: w.New.Pos.String(),
+			NewRule:     w.New.Statement.String(),
+			Widening:    w,
+		})
+	}
+
+	if diffSettings.format == "json" {
+		js, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("could not encode differences")
+		}
+		fmt.Println(string(js))
+	} else {
+		for _, c := range res.Changes {
+			sign := map[string]string{access.ChangeGranted: "+", access.ChangeRevoked: "-", access.ChangeChanged: "~"}[c.Kind]
+			fmt.Printf("%s %s: %s %s: %s -> %s\n", sign, c.New.Subject, c.New.Type, c.New.Verb, describeEntry(c.Old), describeEntry(c.New))
+		}
+		for _, w := range res.Widened {
+			fmt.Printf("! widened %s: %s -> %s grants %s\n  %s\n", w.NewLocation,
+				w.Old.Statement.TypePattern.Value, w.New.Statement.TypePattern.Value,
+				access.FormatPermissions(w.Permissions), w.NewRule)
+		}
+	}
+
+	if len(res.Changes) > 0 || len(res.Widened) > 0 {
+		os.Exit(1)
+	}
+}
+
+// describeEntry returns the access with its conditions, e.g. `conditional (allow if (ctx.age > 2))`
+func describeEntry(e *access.Entry) string {
+	conditions := []string{}
+	if len(e.AllowIf) > 0 {
+		conditions = append(conditions, "allow if "+strings.Join(e.AllowIf, " or "))
+	}
+	if len(e.DenyIf) > 0 {
+		conditions = append(conditions, "deny if "+strings.Join(e.DenyIf, " or "))
+	}
+	if len(conditions) == 0 {
+		return e.Access
+	}
+	return fmt.Sprintf("%s (%s)", e.Access, strings.Join(conditions, "; "))
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringArrayVarP(&diffSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	diffCmd.Flags().StringVar(&diffSettings.format, "format", "text",
+		"output format: text or json")
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/eval"
+)
+
+var evalSettings struct {
+	files        []string // seal files or directories to evaluate
+	swaggerFiles []string // swagger files to read in types
+	dataFile     string   // YAML or JSON document resolving data references
+	format       string   // output format: text or json
+}
+
+// evalCmd represents the eval command
+var evalCmd = &cobra.Command{
+	Use:   "eval [request file]...",
+	Short: "Decides requests with the policies of seal files",
+	Long: `eval decides requests locally, without OPA, with the
+same semantics as the rego backend. Request files are
+JSON or YAML documents (or lists of documents) with
+the layout of the rego input document, e.g.
+
+  {"type": "petstore.pet", "verb": "buy",
+   "ctx": [{"status": "available"}], "jwt": "..."}
+
+The subject claims are decoded from the jwt without
+verifying its signature, or given as "subject": {...}.
+Requests are read from stdin without request file.`,
+	Run: evalFunc,
+}
+
+// evalResult is the decision for a request, as printed in JSON
+type evalResult struct {
+	Request     string       `json:"request"`
+	Action      string       `json:"action"`
+	Matches     []*evalMatch `json:"matches"`
+	Obligations []string     `json:"obligations,omitempty"`
+}
+
+// evalMatch is a matching rule, with its location in the seal files
+type evalMatch struct {
+	Location string `json:"location,omitempty"`
+	*eval.Match
+}
+
+func evalFunc(cmd *cobra.Command, args []string) {
+	if evalSettings.format != "text" && evalSettings.format != "json" {
+		logrus.WithField("format", evalSettings.format).Fatal("invalid output format, expected text or json")
+	}
+
+	swaggerSpec := readSwaggerFiles(evalSettings.swaggerFiles)
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggerSpec...)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create policy compiler")
+	}
+
+	pols := parsePolicyFiles(cplr, evalSettings.files)
+	evaluator, err := eval.New(pols, cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithError(err).Fatal("could not evaluate rules files")
+	}
+	if evalSettings.dataFile != "" {
+		evaluator.WithDataProvider(readDataFile(evalSettings.dataFile))
+	}
+
+	results := []*evalResult{}
+	for _, req := range readRequestFiles(args) {
+		dcsn, err := evaluator.Eval(req.Request)
+		if err != nil {
+			logrus.WithField("request", req.name).WithError(err).Fatal("could not evaluate request")
+		}
+		results = append(results, newEvalResult(req.name, dcsn))
+	}
+
+	if evalSettings.format == "json" {
+		js, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("could not encode decisions")
+		}
+		fmt.Println(string(js))
+		return
+	}
+
+	for _, res := range results {
+		action := res.Action
+		if action == "" {
+			action = "undefined (unknown type or no default action)"
+		}
+		fmt.Printf("%s: %s\n", res.Request, action)
+		for _, m := range res.Matches {
+			fmt.Printf("  %s %s: %s\n", m.Action, m.Location, m.Rule)
+		}
+		for _, oblige := range res.Obligations {
+			fmt.Printf("  obligation: %s\n", oblige)
+		}
+	}
+}
+
+func newEvalResult(name string, dcsn *eval.Decision) *evalResult {
+	res := &evalResult{
+		Request:     name,
+		Action:      dcsn.Action,
+		Matches:     []*evalMatch{},
+		Obligations: dcsn.Obligations,
+	}
+	for _, m := range dcsn.Matches {
+		loc := m.Pos.String()
+		if loc == "" {
+			loc = fmt.Sprintf("stmt%d", m.Stmt)
+		}
+		res.Matches = append(res.Matches, &evalMatch{Location: loc, Match: m})
+	}
+	return res
+}
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+
+	evalCmd.Flags().StringArrayVarP(&evalSettings.files, "file", "f", []string{},
+		"filename or directory to read seal files")
+	evalCmd.Flags().StringArrayVarP(&evalSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	evalCmd.Flags().StringVar(&evalSettings.dataFile, "data", "",
+		"YAML or JSON document resolving data references")
+	evalCmd.Flags().StringVar(&evalSettings.format, "format", "text",
+		"output format: text or json")
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/eval"
+)
+
+var explainSettings struct {
+	files        []string // seal files or directories to evaluate
+	swaggerFiles []string // swagger files to read in types
+	dataFile     string   // YAML or JSON document resolving data references
+	format       string   // output format: text or json
+}
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain [request file]...",
+	Short: "Explains the decisions for requests with the policies of seal files",
+	Long: `explain decides requests as eval does, and shows
+the rules matching each request and the near-miss rules:
+the rules which would have matched the request but for
+one part, its verb, type pattern, subject or where clause,
+e.g. an allow rule of another group when a request is denied.
+Requests are read from stdin without request file.`,
+	Run: explainFunc,
+}
+
+// explainResult is the decision for a request with the near-miss rules, as printed in JSON
+type explainResult struct {
+	*evalResult
+	NearMisses []*explainMiss `json:"near_misses"`
+}
+
+// explainMiss is a near-miss rule, with its location in the seal files
+type explainMiss struct {
+	Location string `json:"location,omitempty"`
+	*eval.Miss
+}
+
+func explainFunc(cmd *cobra.Command, args []string) {
+	if explainSettings.format != "text" && explainSettings.format != "json" {
+		logrus.WithField("format", explainSettings.format).Fatal("invalid output format, expected text or json")
+	}
+
+	swaggerSpec := readSwaggerFiles(explainSettings.swaggerFiles)
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggerSpec...)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create policy compiler")
+	}
+
+	pols := parsePolicyFiles(cplr, explainSettings.files)
+	evaluator, err := eval.New(pols, cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithError(err).Fatal("could not evaluate rules files")
+	}
+	if explainSettings.dataFile != "" {
+		evaluator.WithDataProvider(readDataFile(explainSettings.dataFile))
+	}
+
+	results := []*explainResult{}
+	for _, req := range readRequestFiles(args) {
+		expl, err := evaluator.Explain(req.Request)
+		if err != nil {
+			logrus.WithField("request", req.name).WithError(err).Fatal("could not explain request")
+		}
+
+		res := &explainResult{evalResult: newEvalResult(req.name, expl.Decision), NearMisses: []*explainMiss{}}
+		for _, m := range expl.NearMisses {
+			loc := m.Pos.String()
+			if loc == "" {
+				loc = fmt.Sprintf("stmt%d", m.Stmt)
+			}
+			res.NearMisses = append(res.NearMisses, &explainMiss{Location: loc, Miss: m})
+		}
+		results = append(results, res)
+	}
+
+	if explainSettings.format == "json" {
+		js, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("could not encode explanations")
+		}
+		fmt.Println(string(js))
+		return
+	}
+
+	for _, res := range results {
+		action := res.Action
+		if action == "" {
+			action = "undefined (unknown type or no default action)"
+		}
+		fmt.Printf("%s: %s\n", res.Request, action)
+		if len(res.Matches) == 0 {
+			fmt.Println("  no matching rule: decided by the default action of the type")
+		}
+		for _, m := range res.Matches {
+			fmt.Printf("  matched %s %s: %s\n", m.Action, m.Location, m.Rule)
+		}
+		for _, oblige := range res.Obligations {
+			fmt.Printf("  obligation: %s\n", oblige)
+		}
+		for _, m := range res.NearMisses {
+			fmt.Printf("  near miss %s %s: %s\n    %s\n", m.Action, m.Location, m.Rule, m.Reason)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().StringArrayVarP(&explainSettings.files, "file", "f", []string{},
+		"filename or directory to read seal files")
+	explainCmd.Flags().StringArrayVarP(&explainSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	explainCmd.Flags().StringVar(&explainSettings.dataFile, "data", "",
+		"YAML or JSON document resolving data references")
+	explainCmd.Flags().StringVar(&explainSettings.format, "format", "text",
+		"output format: text or json")
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/atomic"
+	"github.com/infobloxopen/seal/pkg/format"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var fmtSettings struct {
+	write bool // rewrite files in place
+	check bool // only report files that are not formatted
+}
+
+// fmtCmd represents the fmt command
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [file or directory]...",
+	Short: "Formats seal files in the canonical layout",
+	Long: `fmt rewrites seal files in the canonical layout,
+keeping comments. Directories are searched recursively
+for .seal files. By default the formatted files are
+printed to stdout.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  fmtFunc,
+}
+
+func fmtFunc(cmd *cobra.Command, args []string) {
+	files, err := sealFiles(args)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list seal files")
+	}
+
+	unformatted := 0
+	for _, fil := range files {
+		input, err := ioutil.ReadFile(fil)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not read rules file")
+		}
+
+		output, err := format.Source(fil, input)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not format rules file")
+		}
+
+		switch {
+		case fmtSettings.check:
+			if !bytes.Equal(input, output) {
+				fmt.Println(fil)
+				unformatted++
+			}
+		case fmtSettings.write:
+			if bytes.Equal(input, output) {
+				continue
+			}
+			if err := atomic.WriteFile(fil, output, 0644); err != nil {
+				logrus.WithField("file", fil).WithError(err).Fatal("could not write rules file")
+			}
+		default:
+			fmt.Print(string(output))
+		}
+	}
+
+	if unformatted > 0 {
+		os.Exit(1)
+	}
+}
+
+// sealFiles expands directories in paths to the .seal files they contain
+func sealFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, pth := range paths {
+		err := filepath.Walk(pth, func(fil string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fil == pth && !info.IsDir() || !info.IsDir() && strings.HasSuffix(fil, ".seal") {
+				files = append(files, fil)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+
+	fmtCmd.Flags().BoolVarP(&fmtSettings.write, "write", "w", false,
+		"write the result to the source file instead of stdout")
+	fmtCmd.Flags().BoolVar(&fmtSettings.check, "check", false,
+		"list files whose formatting differs and exit with status 1")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/lint"
+)
+
+var lintSettings struct {
+	files        []string // seal files or directories to lint
+	swaggerFiles []string // swagger files to read in types
+	format       string   // output format: text or json
+}
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Reports rules of seal files which are probably mistakes",
+	Long: `lint expands the rules of seal files through the
+base verbs of the types matching their type patterns
+and warns about:
+
+  - duplicate rules
+  - rules shadowed by a broader unconditional deny
+  - allow rules overlapping deny rules on the same
+    subject, type and base verb
+  - where clauses which can never be true,
+    e.g. ctx.age < 2 and ctx.age > 5
+
+lint exits with status 1 if it reports warnings.`,
+	Run: lintFunc,
+}
+
+// lintWarning is a warning, with the location of its rule in the seal files
+type lintWarning struct {
+	Location string `json:"location,omitempty"`
+	*lint.Warning
+}
+
+func lintFunc(cmd *cobra.Command, args []string) {
+	if lintSettings.format != "text" && lintSettings.format != "json" {
+		logrus.WithField("format", lintSettings.format).Fatal("invalid output format, expected text or json")
+	}
+
+	swaggerSpec := readSwaggerFiles(lintSettings.swaggerFiles)
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggerSpec...)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create policy compiler")
+	}
+
+	warnings, err := lint.Lint(parsePolicyFiles(cplr, lintSettings.files), cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithError(err).Fatal("could not lint rules files")
+	}
+
+	if lintSettings.format == "json" {
+		out := []*lintWarning{}
+		for _, w := range warnings {
+			out = append(out, &lintWarning{Location: w.Pos.String(), Warning: w})
+		}
+		js, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("could not encode warnings")
+		}
+		fmt.Println(string(js))
+	} else {
+		for _, w := range warnings {
+			fmt.Printf("%s\n  %s\n", w, w.Rule)
+		}
+	}
+
+	if len(warnings) > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringArrayVarP(&lintSettings.files, "file", "f", []string{},
+		"filename or directory to read seal files")
+	lintCmd.Flags().StringArrayVarP(&lintSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	lintCmd.Flags().StringVar(&lintSettings.format, "format", "text",
+		"output format: text or json")
+}
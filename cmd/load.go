@@ -0,0 +1,129 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/provider"
+)
+
+// readSwaggerFiles returns the content of the swagger files, at least one is required for inferring types
+func readSwaggerFiles(files []string) []string {
+	if len(files) == 0 {
+		logrus.Fatal("swagger file is required for inferring types")
+	}
+
+	swaggerSpec := []string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.WithField("file", file).WithError(err).Fatal("could not read swagger file")
+		}
+		swaggerSpec = append(swaggerSpec, string(content))
+	}
+	return swaggerSpec
+}
+
+// readDataFile returns the YAML or JSON document resolving data references
+func readDataFile(file string) provider.Document {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		logrus.WithField("file", file).WithError(err).Fatal("could not read data file")
+	}
+	doc := provider.Document{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		logrus.WithField("file", file).WithError(err).Fatal("invalid data file")
+	}
+	return doc
+}
+
+// parsePolicyFiles parses the seal files (directories are searched for .seal files)
+// into a single list of policies, in file order
+func parsePolicyFiles(cplr *compiler.PolicyCompiler, paths []string) *ast.Policies {
+	files, err := sealFiles(paths)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list seal files")
+	}
+	if len(files) == 0 {
+		logrus.Fatal("seal file is required")
+	}
+
+	pols := &ast.Policies{}
+	for _, fil := range files {
+		input, err := ioutil.ReadFile(fil)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not read rules file")
+		}
+		filePols, err := cplr.Parse(fil, string(input))
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not parse rules file")
+		}
+		if filePols != nil {
+			pols.Statements = append(pols.Statements, filePols.Statements...)
+		}
+	}
+	return pols
+}
+
+// namedRequest is a request read from a request file, named after the file
+// and its index in the file if the file has several requests
+type namedRequest struct {
+	name string
+	*eval.Request
+}
+
+// readRequestFiles returns the requests of the JSON or YAML request files, read from stdin without request file
+func readRequestFiles(files []string) []*namedRequest {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	out := []*namedRequest{}
+	for _, fil := range files {
+		var content []byte
+		var err error
+		if fil == "-" {
+			content, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			content, err = ioutil.ReadFile(fil)
+		}
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not read request file")
+		}
+
+		reqs, err := eval.ParseRequests(content)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("invalid request file")
+		}
+		for i, req := range reqs {
+			name := fil
+			if len(reqs) > 1 {
+				name = fmt.Sprintf("%s[%d]", fil, i)
+			}
+			out = append(out, &namedRequest{name: name, Request: req})
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/access"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+)
+
+var reportSettings struct {
+	files        []string // seal files or directories to report on
+	swaggerFiles []string // swagger files to read in types
+	format       string   // output format: csv, markdown or json
+}
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Reports on the policies of seal files",
+}
+
+// reportAccessCmd represents the report access command
+var reportAccessCmd = &cobra.Command{
+	Use:   "access",
+	Short: "Reports the effective access of every subject",
+	Long: `access expands every rule of seal files through the
+base verbs of the types matching its type pattern, and
+reports the effective access (allow, deny or conditional)
+of every subject (group or user) to every type and base
+verb, with the conditions of the conditional access.
+Rules without subject apply to every subject.`,
+	Run: reportAccessFunc,
+}
+
+func reportAccessFunc(cmd *cobra.Command, args []string) {
+	switch reportSettings.format {
+	case "csv", "markdown", "json":
+	default:
+		logrus.WithField("format", reportSettings.format).Fatal("invalid output format, expected csv, markdown or json")
+	}
+
+	swaggerSpec := readSwaggerFiles(reportSettings.swaggerFiles)
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggerSpec...)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create policy compiler")
+	}
+
+	rules, err := access.Expand(parsePolicyFiles(cplr, reportSettings.files), cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithError(err).Fatal("could not expand rules files")
+	}
+	entries := access.Matrix(rules, cplr.SwaggerTypes())
+
+	switch reportSettings.format {
+	case "csv":
+		err = access.WriteCSV(os.Stdout, entries)
+	case "markdown":
+		err = access.WriteMarkdown(os.Stdout, entries)
+	case "json":
+		var js []byte
+		if js, err = json.MarshalIndent(entries, "", "  "); err == nil {
+			fmt.Println(string(js))
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("could not write access report")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportAccessCmd)
+
+	reportAccessCmd.Flags().StringArrayVarP(&reportSettings.files, "file", "f", []string{},
+		"filename or directory to read seal files")
+	reportAccessCmd.Flags().StringArrayVarP(&reportSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	reportAccessCmd.Flags().StringVar(&reportSettings.format, "format", "csv",
+		"output format: csv, markdown or json")
+}
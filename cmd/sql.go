@@ -0,0 +1,155 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+	sqlcompiler "github.com/infobloxopen/seal/pkg/compiler/sql"
+)
+
+var sqlSettings struct {
+	mappingFile  string   // YAML or JSON SQL mapping
+	swaggerFiles []string // swagger files to read in types
+	condition    string   // annotated obligation condition to compile
+	policyFile   string   // seal file to compile
+	dataFile     string   // YAML or JSON document resolving data references
+	dialect      string   // overrides the dialect of the mapping
+	verb         string   // base verb or seal verb the policies are compiled for
+	bind         bool     // placeholders and bind arguments instead of inlined literals
+}
+
+// sqlCmd represents the sql command
+var sqlCmd = &cobra.Command{
+	Use:   "sql",
+	Short: "Compiles an obligation condition or a seal file into SQL",
+	Long: `sql compiles an annotated obligation condition, e.g.
+"type:petstore.pet; ctx.name == \"bob\"", or the policies
+of a seal file into SQL WHERE predicates, mapping types
+and properties to tables and columns with a YAML or
+JSON mapping file.`,
+	Args: cobra.NoArgs,
+	Run:  sqlFunc,
+}
+
+func sqlFunc(cmd *cobra.Command, args []string) {
+	if (sqlSettings.condition == "") == (sqlSettings.policyFile == "") {
+		logrus.Fatal("either a condition or a seal file is required")
+	}
+
+	sqlc := sqlcompiler.NewSQLCompiler().WithDialect(sqlcompiler.DialectPostgres).WithQuotedIdentifiers(true)
+	var cplr *compiler.PolicyCompiler
+	if sqlSettings.policyFile != "" {
+		swaggerSpec := readSwaggerFiles(sqlSettings.swaggerFiles)
+
+		var err error
+		if cplr, err = compiler.NewPolicyCompiler(sqlcompiler.Language, swaggerSpec...); err != nil {
+			logrus.WithError(err).Fatal("could not create policy compiler")
+		}
+		sqlc = cplr.BackendCompiler().(*sqlcompiler.SQLCompiler)
+	}
+
+	if sqlSettings.mappingFile != "" {
+		content, err := ioutil.ReadFile(sqlSettings.mappingFile)
+		if err != nil {
+			logrus.WithField("file", sqlSettings.mappingFile).WithError(err).Fatal("could not read mapping file")
+		}
+		mpg, err := sqlcompiler.ParseMapping(content)
+		if err != nil {
+			logrus.WithField("file", sqlSettings.mappingFile).WithError(err).Fatal("invalid mapping file")
+		}
+		if _, err := sqlc.WithMapping(mpg); err != nil {
+			logrus.WithField("file", sqlSettings.mappingFile).WithError(err).Fatal("invalid mapping file")
+		}
+	}
+
+	if sqlSettings.dialect != "" {
+		dia, err := sqlcompiler.ParseDialect(sqlSettings.dialect)
+		if err != nil {
+			logrus.WithError(err).Fatal("invalid dialect")
+		}
+		sqlc.WithDialect(dia)
+	}
+
+	if sqlSettings.dataFile != "" {
+		sqlc.WithDataProvider(readDataFile(sqlSettings.dataFile))
+	}
+
+	sqlc.WithVerb(sqlSettings.verb).WithBindArgs(sqlSettings.bind)
+
+	if sqlSettings.condition != "" {
+		if !sqlSettings.bind {
+			where, err := sqlc.CompileCondition(sqlSettings.condition)
+			if err != nil {
+				logrus.WithError(err).Fatal("could not compile condition")
+			}
+			fmt.Println(where)
+			return
+		}
+
+		where, whereArgs, err := sqlc.CompileConditionArgs(sqlSettings.condition)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not compile condition")
+		}
+		fmt.Println(where)
+		js, err := json.Marshal(whereArgs)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not encode bind arguments")
+		}
+		fmt.Printf("-- args: %s\n", js)
+		return
+	}
+
+	input, err := ioutil.ReadFile(sqlSettings.policyFile)
+	if err != nil {
+		logrus.WithField("file", sqlSettings.policyFile).WithError(err).Fatal("could not read rules file")
+	}
+	pkgname := strings.TrimSuffix(path.Base(sqlSettings.policyFile), ".seal")
+	out, err := cplr.CompileFile(sqlSettings.policyFile, pkgname, string(input))
+	if err != nil {
+		logrus.WithField("file", sqlSettings.policyFile).WithError(err).Fatal("could not compile rules file")
+	}
+	fmt.Println(out)
+}
+
+func init() {
+	rootCmd.AddCommand(sqlCmd)
+
+	sqlCmd.Flags().StringVarP(&sqlSettings.mappingFile, "mapping", "m", "",
+		"YAML or JSON file mapping types and properties to tables and columns")
+	sqlCmd.Flags().StringArrayVarP(&sqlSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types")
+	sqlCmd.Flags().StringVarP(&sqlSettings.condition, "condition", "c", "",
+		`annotated obligation condition, e.g. "type:petstore.pet; ctx.name == \"bob\""`)
+	sqlCmd.Flags().StringVarP(&sqlSettings.policyFile, "file", "f", "",
+		"seal file to compile")
+	sqlCmd.Flags().StringVar(&sqlSettings.dataFile, "data", "",
+		"YAML or JSON document resolving data references")
+	sqlCmd.Flags().StringVar(&sqlSettings.dialect, "dialect", "",
+		"SQL dialect: postgres, mysql, sqlite or sqlserver (default is the dialect of the mapping, or postgres)")
+	sqlCmd.Flags().StringVar(&sqlSettings.verb, "verb", sqlcompiler.DefaultVerb,
+		"base verb or seal verb the policies are compiled for")
+	sqlCmd.Flags().BoolVar(&sqlSettings.bind, "bind", false,
+		"print placeholders and bind arguments instead of inlined literals")
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2020 Infoblox <dev@infoblox.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/sealtest"
+)
+
+var testSettings struct {
+	files        []string // seal files or directories, instead of the policies of the test files
+	swaggerFiles []string // swagger files, instead of the swaggers of the test files
+	dataFile     string   // data document, instead of the data of the test files
+	verbose      bool     // also report passing tests
+}
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [test file or directory]...",
+	Short: "Runs seal test files against the policies",
+	Long: `test decides the requests of seal test files (YAML or
+JSON) with the policies and reports the tests whose
+decision differs from the expected one. Directories
+are searched recursively for *.sealtest.yaml files.
+Exits with status 1 if a test fails.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  testFunc,
+}
+
+func testFunc(cmd *cobra.Command, args []string) {
+	files, err := sealTestFiles(args)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list seal test files")
+	}
+
+	failed := 0
+	for _, fil := range files {
+		content, err := ioutil.ReadFile(fil)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not read seal test file")
+		}
+		suite, err := sealtest.Parse(fil, content)
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("invalid seal test file")
+		}
+
+		results, err := suite.Run(newSuiteEvaluator(suite))
+		if err != nil {
+			logrus.WithField("file", fil).WithError(err).Fatal("could not run seal tests")
+		}
+
+		suiteFailed := 0
+		for _, res := range results {
+			if res.Passed() {
+				if testSettings.verbose {
+					fmt.Printf("--- PASS: %s: %s\n", fil, res.Test.Name)
+				}
+				continue
+			}
+
+			suiteFailed++
+			fmt.Printf("--- FAIL: %s: %s\n", fil, res.Test.Name)
+			for _, failure := range res.Failures {
+				fmt.Printf("    %s\n", failure)
+			}
+			for _, m := range res.Decision.Matches {
+				fmt.Printf("    matched %s %s: %s\n", m.Action, m.Pos, m.Rule)
+			}
+		}
+
+		if suiteFailed > 0 {
+			fmt.Printf("FAIL\t%s\t%d of %d tests failed\n", fil, suiteFailed, len(results))
+		} else {
+			fmt.Printf("ok\t%s\t%d tests\n", fil, len(results))
+		}
+		failed += suiteFailed
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// newSuiteEvaluator returns the evaluator of the policies of the test file, or of the command line
+func newSuiteEvaluator(suite *sealtest.Suite) *eval.Evaluator {
+	swaggerFiles, policyFiles, dataFile := testSettings.swaggerFiles, testSettings.files, testSettings.dataFile
+	if len(swaggerFiles) == 0 {
+		for _, sw := range suite.Swaggers {
+			swaggerFiles = append(swaggerFiles, suite.Path(sw))
+		}
+	}
+	if len(policyFiles) == 0 {
+		for _, pol := range suite.Policies {
+			policyFiles = append(policyFiles, suite.Path(pol))
+		}
+	}
+	if dataFile == "" && suite.Data != "" {
+		dataFile = suite.Path(suite.Data)
+	}
+
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, readSwaggerFiles(swaggerFiles)...)
+	if err != nil {
+		logrus.WithField("file", suite.File).WithError(err).Fatal("could not create policy compiler")
+	}
+	evaluator, err := eval.New(parsePolicyFiles(cplr, policyFiles), cplr.SwaggerTypes())
+	if err != nil {
+		logrus.WithField("file", suite.File).WithError(err).Fatal("could not evaluate rules files")
+	}
+	if dataFile != "" {
+		evaluator.WithDataProvider(readDataFile(dataFile))
+	}
+	return evaluator
+}
+
+// sealTestFiles expands directories in paths to the seal test files they contain
+func sealTestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, pth := range paths {
+		err := filepath.Walk(pth, func(fil string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			base := filepath.Base(fil)
+			isTestFile := strings.HasSuffix(base, ".sealtest.yaml") || strings.HasSuffix(base, ".sealtest.yml") ||
+				strings.HasSuffix(base, ".sealtest.json")
+			if fil == pth && !info.IsDir() || !info.IsDir() && isTestFile {
+				files = append(files, fil)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringArrayVarP(&testSettings.files, "file", "f", []string{},
+		"filename or directory to read seal files, instead of the policies of the test files")
+	testCmd.Flags().StringArrayVarP(&testSettings.swaggerFiles, "swagger-file", "s", []string{},
+		"filenames to read types, instead of the swaggers of the test files")
+	testCmd.Flags().StringVar(&testSettings.dataFile, "data", "",
+		"YAML or JSON document resolving data references, instead of the data of the test files")
+	testCmd.Flags().BoolVarP(&testSettings.verbose, "verbose", "v", false,
+		"also report passing tests")
+}
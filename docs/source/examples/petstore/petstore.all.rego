@@ -94,6 +94,13 @@ base_verbs := {
 	},
 }
 
+default_actions := {
+	"petstore.order": "deny",
+	"petstore.pet": "deny",
+	"petstore.stor3": "deny",
+	"petstore.user": "deny",
+}
+
 deny {
 	seal_list_contains(base_verbs[input.type].deliver, input.verb)
 	re_match(`petstore.order`, input.type)
@@ -208,6 +215,24 @@ allow {
 	input.ctx[i].potty_trained
 }
 
+deny {
+	seal_list_contains(seal_subject.groups, `everyone`)
+	seal_list_contains(base_verbs[input.type].provision, input.verb)
+	re_match(`petstore.pet`, input.type)
+
+	some i
+	seal_list_contains(data.company.list["name=endangered"], input.ctx[i].category.name)
+}
+
+deny_properties[{"notify": true}] {
+	seal_list_contains(seal_subject.groups, `everyone`)
+	seal_list_contains(base_verbs[input.type].provision, input.verb)
+	re_match(`petstore.pet`, input.type)
+
+	some i
+	seal_list_contains(data.company.list["name=endangered"], input.ctx[i].category.name)
+}
+
 deny {
 	seal_list_contains(seal_subject.groups, `everyone`)
 	seal_list_contains(base_verbs[input.type].buy, input.verb)
@@ -217,6 +242,15 @@ deny {
 	input.ctx[i].tags.endangered == "true"
 }
 
+deny_properties[{"notify": true}] {
+	seal_list_contains(seal_subject.groups, `everyone`)
+	seal_list_contains(base_verbs[input.type].buy, input.verb)
+	re_match(`petstore.pet`, input.type)
+
+	some i
+	input.ctx[i].tags.endangered == "true"
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, `operators`)
 	seal_list_contains(base_verbs[input.type].use, input.verb)
@@ -321,8 +355,8 @@ line16_not1_cnd {
 }
 
 obligations := {
-	`stmt21`: [`type:petstore.order; (ctx.marketplace != "amazon")`],
-	`stmt22`: [`type:petstore.user; ((ctx.occupation != "unemployed") and (ctx.salary > 200000))`],
+	`stmt22`: [`type:petstore.order; (ctx.marketplace != "amazon")`],
+	`stmt23`: [`type:petstore.user; ((ctx.occupation != "unemployed") and (ctx.salary > 200000))`],
 }
 
 # rego functions defined by seal
@@ -336,3 +370,19 @@ seal_subject = payload {
 seal_list_contains(list, elem) {
 	list[_] = elem
 }
+
+# decision is the action decided for the request: deny overrides allow,
+# requests matching neither are decided by the default action of the type
+decision = "deny" {
+	deny
+}
+
+decision = "allow" {
+	allow
+	not deny
+}
+
+decision = default_actions[input.type] {
+	not allow
+	not deny
+}
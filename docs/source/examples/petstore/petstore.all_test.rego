@@ -0,0 +1,1206 @@
+package petstore.all
+
+# Code generated by seal compile --emit-tests. DO NOT EDIT.
+
+# deny to deliver petstore.order where ("boss" in subject.groups);
+
+test_stmt0_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "deliver",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["boss"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt0_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["boss"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside ("boss" in subject.groups)
+test_stmt0_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "deliver",
+		"jwt": sealtest_jwt_encode_sign({"groups": []}),
+	}
+
+	not deny with input as in
+}
+
+# allow to buy petstore.pet where (ctx.breed in ["half-breed","mongrel","mutt",]);
+
+test_stmt1_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "half-breed"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	allow with input as in
+}
+
+test_stmt1_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"breed": "half-breed"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.breed in ["half-breed","mongrel","mutt",])
+test_stmt1_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "not_half-breed"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not allow with input as in
+}
+
+# deny to use petstore.order where (ctx.id == "-1");
+
+test_stmt2_0_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"ctx": [{"id": "-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	deny with input as in
+}
+
+test_stmt2_0_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"ctx": [{"id": "-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.id == "-1")
+test_stmt2_0_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"ctx": [{"id": "not_-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# deny to use petstore.user where (ctx.id == "-1");
+
+test_stmt2_1_positive {
+	in := {
+		"type": "petstore.user",
+		"verb": "update",
+		"ctx": [{"id": "-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	deny with input as in
+}
+
+test_stmt2_1_wrong_verb {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"id": "-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.id == "-1")
+test_stmt2_1_outside1 {
+	in := {
+		"type": "petstore.user",
+		"verb": "update",
+		"ctx": [{"id": "not_-1"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# deny to use petstore.order where (subject.iss != "context.petstore.swagger.io");
+
+test_stmt2_2_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"iss": "not_context.petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+test_stmt2_2_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"iss": "not_context.petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# outside (subject.iss != "context.petstore.swagger.io")
+test_stmt2_2_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"iss": "context.petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# deny to use petstore.user where (subject.iss != "context.petstore.swagger.io");
+
+test_stmt2_3_positive {
+	in := {
+		"type": "petstore.user",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"iss": "not_context.petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+test_stmt2_3_wrong_verb {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"iss": "not_context.petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# outside (subject.iss != "context.petstore.swagger.io")
+test_stmt2_3_outside1 {
+	in := {
+		"type": "petstore.user",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"iss": "context.petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# deny to deliver petstore.order where (ctx.status == "delivered");
+
+test_stmt3_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "deliver",
+		"ctx": [{"status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	deny with input as in
+}
+
+test_stmt3_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"ctx": [{"status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.status == "delivered")
+test_stmt3_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "deliver",
+		"ctx": [{"status": "placed"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not deny with input as in
+}
+
+# deny subject group regexp to use petstore.* where (subject.jti =~ "@petstore.swagger.io$");
+
+test_stmt4_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["regexp"], "jti": "@petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+test_stmt4_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_regexp"], "jti": "@petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt4_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["regexp"], "jti": "@petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# outside (subject.jti =~ "@petstore.swagger.io$")
+test_stmt4_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["regexp"], "jti": ""}),
+	}
+
+	not deny with input as in
+}
+
+# deny subject group everyone to use petstore.* where (subject.iss != "petstore.swagger.io");
+
+test_stmt5_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"], "iss": "not_petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+test_stmt5_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_everyone"], "iss": "not_petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+test_stmt5_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"], "iss": "not_petstore.swagger.io"}),
+	}
+
+	not deny with input as in
+}
+
+# outside (subject.iss != "petstore.swagger.io")
+test_stmt5_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"], "iss": "petstore.swagger.io"}),
+	}
+
+	deny with input as in
+}
+
+# deny subject group everyone to buy petstore.pet where ((ctx.age <= 2) and (ctx.name == "specificPetName"));
+
+test_stmt6_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"age": 2, "name": "specificPetName"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt6_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"age": 2, "name": "specificPetName"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt6_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"age": 2, "name": "specificPetName"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.age <= 2)
+test_stmt6_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"age": 3, "name": "specificPetName"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.name == "specificPetName")
+test_stmt6_outside2 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"age": 2, "name": "not_specificPetName"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# deny subject group banned to manage petstore.*;
+
+test_stmt7_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["banned"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt7_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_banned"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt7_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["banned"]}),
+	}
+
+	not deny with input as in
+}
+
+# deny subject group managers to sell petstore.pet where (ctx.status != "available");
+
+test_stmt8_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "sell",
+		"ctx": [{"status": "pending"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["managers"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt8_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "sell",
+		"ctx": [{"status": "pending"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_managers"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt8_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"status": "pending"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["managers"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.status != "available")
+test_stmt8_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "sell",
+		"ctx": [{"status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["managers"]}),
+	}
+
+	not deny with input as in
+}
+
+# deny subject group fussy to buy petstore.pet where ((notctx.neutered) and (notctx.potty_trained));
+
+test_stmt9_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": false}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt9_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": false}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_fussy"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt9_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"neutered": false, "potty_trained": false}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (notctx.neutered)
+test_stmt9_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": true, "potty_trained": false}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (notctx.potty_trained)
+test_stmt9_outside2 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	not deny with input as in
+}
+
+# allow subject group fussy to buy petstore.pet where (not(ctx.neutered and ctx.potty_trained));
+
+test_stmt10_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt10_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_fussy"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt10_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (not(ctx.neutered and ctx.potty_trained))
+test_stmt10_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": true, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["fussy"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group not_operator_precedence to buy petstore.pet where ((notctx.neutered) and ctx.potty_trained);
+
+test_stmt11_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_operator_precedence"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt11_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_not_operator_precedence"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt11_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"neutered": false, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_operator_precedence"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (notctx.neutered)
+test_stmt11_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": true, "potty_trained": true}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_operator_precedence"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside ctx.potty_trained
+test_stmt11_outside2 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"neutered": false, "potty_trained": false}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_operator_precedence"]}),
+	}
+
+	not allow with input as in
+}
+
+# deny (notify="true") subject group everyone to provision petstore.pet where (ctx.category.name in $company.list["name=endangered"]);
+
+test_stmt12_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "provision",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt12_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "provision",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt12_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.category.name in $company.list["name=endangered"])
+test_stmt12_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "provision",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# deny (notify="true") subject group everyone to buy petstore.pet where (ctx.tags["endangered"] == "true");
+
+test_stmt13_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"tags": {"endangered": "true"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	deny with input as in
+}
+
+test_stmt13_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"tags": {"endangered": "true"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+test_stmt13_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"tags": {"endangered": "true"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# outside (ctx.tags["endangered"] == "true")
+test_stmt13_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"tags": {"endangered": "not_true"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not deny with input as in
+}
+
+# allow subject group operators to use petstore.*;
+
+test_stmt14_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["operators"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt14_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "update",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_operators"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt14_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["operators"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group managers to manage petstore.*;
+
+test_stmt15_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["managers"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt15_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_managers"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt15_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["managers"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject user cto@petstore.swagger.io to manage petstore.*;
+
+test_stmt16_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"sub": "cto@petstore.swagger.io"}),
+	}
+
+	allow with input as in
+}
+
+test_stmt16_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "create",
+		"jwt": sealtest_jwt_encode_sign({"sub": "not_cto@petstore.swagger.io"}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt16_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"jwt": sealtest_jwt_encode_sign({"sub": "cto@petstore.swagger.io"}),
+	}
+
+	not allow with input as in
+}
+
+# allow to inspect petstore.pet;
+
+test_stmt17_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "list",
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	allow with input as in
+}
+
+test_stmt17_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group everyone to inspect petstore.pet;
+
+test_stmt18_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "list",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt18_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "list",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_everyone"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt18_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["everyone"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group customers to read petstore.pet;
+
+test_stmt19_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "get",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt19_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "get",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_customers"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt19_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group customers to buy petstore.pet where (ctx.status == "available");
+
+test_stmt20_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt20_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_customers"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt20_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.status == "available")
+test_stmt20_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"status": "pending"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group breeders_maltese to buy petstore.pet where ((ctx.status == "reserved") and (ctx.breed == "maltese"));
+
+test_stmt21_positive {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "maltese", "status": "reserved"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["breeders_maltese"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt21_non_member {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "maltese", "status": "reserved"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_breeders_maltese"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt21_wrong_verb {
+	in := {
+		"type": "petstore.pet",
+		"verb": "create",
+		"ctx": [{"breed": "maltese", "status": "reserved"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["breeders_maltese"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.status == "reserved")
+test_stmt21_outside1 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "maltese", "status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["breeders_maltese"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.breed == "maltese")
+test_stmt21_outside2 {
+	in := {
+		"type": "petstore.pet",
+		"verb": "buy",
+		"ctx": [{"breed": "not_maltese", "status": "reserved"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["breeders_maltese"]}),
+	}
+
+	not allow with input as in
+}
+
+# allow subject group employees to inspect petstore.order where ((ctx.status == "delivered") and (ctx.marketplace != "amazon"));
+
+test_stmt22_positive {
+	in := {
+		"type": "petstore.order",
+		"verb": "list",
+		"ctx": [{"marketplace": "alibaba", "status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employees"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt22_non_member {
+	in := {
+		"type": "petstore.order",
+		"verb": "list",
+		"ctx": [{"marketplace": "alibaba", "status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_employees"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt22_wrong_verb {
+	in := {
+		"type": "petstore.order",
+		"verb": "approve",
+		"ctx": [{"marketplace": "alibaba", "status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employees"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.status == "delivered")
+test_stmt22_outside1 {
+	in := {
+		"type": "petstore.order",
+		"verb": "list",
+		"ctx": [{"marketplace": "alibaba", "status": "placed"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employees"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.marketplace != "amazon")
+test_stmt22_outside2 {
+	in := {
+		"type": "petstore.order",
+		"verb": "list",
+		"ctx": [{"marketplace": "amazon", "status": "delivered"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employees"]}),
+	}
+
+	allow with input as in
+}
+
+# allow subject group supervisors to manage petstore.user where (((ctx.email =~ ".*@acme.com") and (ctx.occupation != "unemployed")) and (ctx.salary > 200000));
+
+test_stmt23_positive {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"email": "@acme.com", "occupation": "not_unemployed", "salary": 200001}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["supervisors"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt23_non_member {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"email": "@acme.com", "occupation": "not_unemployed", "salary": 200001}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_supervisors"]}),
+	}
+
+	not allow with input as in
+}
+
+test_stmt23_wrong_verb {
+	in := {
+		"type": "petstore.user",
+		"verb": "sign_in",
+		"ctx": [{"email": "@acme.com", "occupation": "not_unemployed", "salary": 200001}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["supervisors"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.email =~ ".*@acme.com")
+test_stmt23_outside1 {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"email": "", "occupation": "not_unemployed", "salary": 200001}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["supervisors"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.occupation != "unemployed")
+test_stmt23_outside2 {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"email": "@acme.com", "occupation": "unemployed", "salary": 200001}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["supervisors"]}),
+	}
+
+	allow with input as in
+}
+
+# outside (ctx.salary > 200000)
+test_stmt23_outside3 {
+	in := {
+		"type": "petstore.user",
+		"verb": "create",
+		"ctx": [{"email": "@acme.com", "occupation": "not_unemployed", "salary": 200000}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["supervisors"]}),
+	}
+
+	allow with input as in
+}
+
+# allow subject group employ33s to oper4te petstore.stor3 where ((ctx.addre55 == "1234 Main St.") and (ctx.t4gs["0"] == "zer0"));
+
+test_stmt24_positive {
+	in := {
+		"type": "petstore.stor3",
+		"verb": "op3n",
+		"ctx": [{"addre55": "1234 Main St.", "t4gs": {"0": "zer0"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employ33s"]}),
+	}
+
+	allow with input as in
+}
+
+test_stmt24_non_member {
+	in := {
+		"type": "petstore.stor3",
+		"verb": "op3n",
+		"ctx": [{"addre55": "1234 Main St.", "t4gs": {"0": "zer0"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["not_employ33s"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.addre55 == "1234 Main St.")
+test_stmt24_outside1 {
+	in := {
+		"type": "petstore.stor3",
+		"verb": "op3n",
+		"ctx": [{"addre55": "not_1234 Main St.", "t4gs": {"0": "zer0"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employ33s"]}),
+	}
+
+	not allow with input as in
+}
+
+# outside (ctx.t4gs["0"] == "zer0")
+test_stmt24_outside2 {
+	in := {
+		"type": "petstore.stor3",
+		"verb": "op3n",
+		"ctx": [{"addre55": "1234 Main St.", "t4gs": {"0": "not_zer0"}}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["employ33s"]}),
+	}
+
+	not allow with input as in
+}
+
+# sealtest_jwt_encode_sign returns HMAC signed jwt from claims for testing purposes
+sealtest_jwt_encode_sign(claims) = jwt {
+	jwt = io.jwt.encode_sign(
+		{
+			"typ": "JWT",
+			"alg": "HS256",
+		},
+		claims, {
+			"kty": "oct",
+			# k from https://tools.ietf.org/html/rfc7517#appendix-A.3
+			"k": "AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAowg",
+		},
+	)
+}
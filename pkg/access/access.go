@@ -0,0 +1,201 @@
+// Package access expands the rules of the policies into the permissions they grant or deny:
+// the base verbs of the swagger types matching their type pattern and verb, for their subject.
+package access
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+	"github.com/mb0/glob"
+)
+
+// Kinds of subjects
+const (
+	SubjectGroup = "group"
+	SubjectUser  = "user"
+)
+
+// Subject is the group or user of a rule, the zero Subject is anyone
+type Subject struct {
+	Kind string `json:"kind,omitempty"` // group or user, empty for anyone
+	Name string `json:"name,omitempty"`
+}
+
+// String returns the subject as `group customers`, `user bob` or `*` for anyone
+func (s Subject) String() string {
+	if s.Kind == "" {
+		return "*"
+	}
+	return s.Kind + " " + s.Name
+}
+
+// Covers returns true if the rules of the subject apply to every request of the other subject
+func (s Subject) Covers(other Subject) bool {
+	return s.Kind == "" || s == other
+}
+
+// Permission is a base verb of a swagger type
+type Permission struct {
+	Type string `json:"type"` // e.g. petstore.pet
+	Verb string `json:"verb"` // base verb, e.g. get
+}
+
+// String returns the permission as type:verb
+func (p Permission) String() string {
+	return p.Type + ":" + p.Verb
+}
+
+// Rule is a linearized statement with the permissions of its type pattern and verb
+type Rule struct {
+	Stmt        int                  // index of the statement in the policies
+	Pos         token.Position       // position of the statement in the seal source, if known
+	Statement   *ast.ActionStatement // context statements are linearized into one rule per action
+	Action      string               // allow, deny or a custom action
+	Subject     Subject
+	Condition   ast.Condition // condition of the where clause, nil if the rule is unconditional
+	Permissions []Permission  // sorted by type and verb
+}
+
+// Conditional returns true if the rule has a where clause
+func (r *Rule) Conditional() bool {
+	return !types.IsNilInterface(r.Condition)
+}
+
+// Expand returns the rules of the policies in policy order.
+// The permissions of a rule are the base verbs of its verb for each type matching its type pattern
+// which has the verb, so a rule matching no type has no permission.
+func Expand(pols *ast.Policies, swaggerTypes []types.Type) ([]*Rule, error) {
+	if pols == nil {
+		return nil, compiler_error.ErrEmptyPolicies
+	}
+
+	rules := []*Rule{}
+	for idx, stmt := range pols.Statements {
+		var stmts []*ast.ActionStatement
+		switch s := stmt.(type) {
+		case *ast.ActionStatement:
+			stmts = []*ast.ActionStatement{s}
+		case *ast.ContextStatement:
+			stmts = ast.LinearizeContext(s)
+		}
+
+		for _, st := range stmts {
+			r, err := expandRule(idx, st, swaggerTypes)
+			if err != nil {
+				return nil, compiler_error.New(err, idx, stmt.String()).WithPos(stmt.Pos())
+			}
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+func expandRule(idx int, st *ast.ActionStatement, swaggerTypes []types.Type) (*Rule, error) {
+	if st.Verb == nil {
+		return nil, compiler_error.ErrEmptyVerb
+	}
+	if st.TypePattern == nil {
+		return nil, compiler_error.ErrEmptyTypePattern
+	}
+
+	r := &Rule{
+		Stmt:        idx,
+		Pos:         st.Pos(),
+		Statement:   st,
+		Action:      st.Token.Literal,
+		Permissions: []Permission{},
+	}
+	switch s := st.Subject.(type) {
+	case nil:
+	case *ast.SubjectGroup:
+		r.Subject = Subject{Kind: SubjectGroup, Name: s.Group}
+	case *ast.SubjectUser:
+		r.Subject = Subject{Kind: SubjectUser, Name: s.User}
+	default:
+		return nil, compiler_error.ErrInvalidSubject
+	}
+	if wc, ok := st.WhereClause.(*ast.WhereClause); ok && !types.IsNilInterface(wc.Condition) {
+		r.Condition = wc.Condition
+	}
+
+	for _, swt := range swaggerTypes {
+		m, err := glob.Match(st.TypePattern.Value, swt.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid type pattern '%s': %s", st.TypePattern.Value, err)
+		}
+		if !m || !types.IsValidVerb(swt, st.Verb.Value) {
+			continue
+		}
+		for _, vrb := range swt.GetVerbs() {
+			if vrb.GetName() != st.Verb.Value {
+				continue
+			}
+			for _, bv := range vrb.GetBaseVerbs() {
+				r.Permissions = append(r.Permissions, Permission{Type: swt.String(), Verb: bv})
+			}
+		}
+	}
+	SortPermissions(r.Permissions)
+	r.Permissions = unique(r.Permissions)
+	return r, nil
+}
+
+// SortPermissions sorts the permissions by type and verb
+func SortPermissions(perms []Permission) {
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].Type != perms[j].Type {
+			return perms[i].Type < perms[j].Type
+		}
+		return perms[i].Verb < perms[j].Verb
+	})
+}
+
+// unique removes the duplicates of the sorted permissions
+func unique(perms []Permission) []Permission {
+	out := perms[:0]
+	for i, p := range perms {
+		if i == 0 || p != perms[i-1] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Intersect returns the permissions of both sorted lists
+func Intersect(a, b []Permission) []Permission {
+	set := map[Permission]bool{}
+	for _, p := range b {
+		set[p] = true
+	}
+	out := []Permission{}
+	for _, p := range a {
+		if set[p] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Contains returns true if every permission of b is in a
+func Contains(a, b []Permission) bool {
+	return len(Intersect(b, a)) == len(b)
+}
+
+// FormatPermissions returns the permissions grouped by type, e.g. `petstore.pet (get, list)`
+func FormatPermissions(perms []Permission) string {
+	groups := []string{}
+	for i := 0; i < len(perms); {
+		j, verbs := i, []string{}
+		for ; j < len(perms) && perms[j].Type == perms[i].Type; j++ {
+			verbs = append(verbs, perms[j].Verb)
+		}
+		groups = append(groups, fmt.Sprintf("%s (%s)", perms[i].Type, strings.Join(verbs, ", ")))
+		i = j
+	}
+	return strings.Join(groups, ", ")
+}
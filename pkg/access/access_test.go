@@ -0,0 +1,98 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/internal/fixture"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+func expand(t *testing.T, policies string) ([]*Rule, []types.Type) {
+	t.Helper()
+	pols, swaggerTypes := fixture.Parse(t, "shop.seal", policies)
+	rules, err := Expand(pols, swaggerTypes)
+	if err != nil {
+		t.Fatalf("could not expand rules: %s", err)
+	}
+	return rules, swaggerTypes
+}
+
+func TestExpand(t *testing.T) {
+	type expectedRule struct {
+		stmt        int
+		pos         string
+		action      string
+		subject     string
+		condition   string
+		permissions string
+	}
+	tests := map[string]struct {
+		policies string
+		expected []expectedRule
+	}{
+		"type pattern": {
+			policies: `allow subject group managers to manage shop.*;`,
+			expected: []expectedRule{
+				{0, "shop.seal:1:1", "allow", "group managers", "", "shop.order (delete, get), shop.pet (get, update)"},
+			},
+		},
+		"where clause": {
+			policies: `deny subject user bob to buy shop.pet where ctx.age < 2;`,
+			expected: []expectedRule{
+				{0, "shop.seal:1:1", "deny", "user bob", "(ctx.age < 2)", "shop.pet (buy)"},
+			},
+		},
+		"context statement": {
+			policies: `context {
+    where ctx.id == "-1";
+} to ship {
+    deny shop.order;
+}`,
+			expected: []expectedRule{
+				{0, "shop.seal:4:5", "deny", "*", `(ctx.id == "-1")`, "shop.order (ship)"},
+			},
+		},
+	}
+
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules, _ := expand(t, tst.policies)
+			if len(rules) != len(tst.expected) {
+				t.Fatalf("expected %d rules, got %d", len(tst.expected), len(rules))
+			}
+			for idx, exp := range tst.expected {
+				r := rules[idx]
+				condition := ""
+				if r.Conditional() {
+					condition = r.Condition.String()
+				}
+				if r.Stmt != exp.stmt || r.Pos.String() != exp.pos || r.Action != exp.action || r.Subject.String() != exp.subject ||
+					condition != exp.condition || FormatPermissions(r.Permissions) != exp.permissions {
+					t.Errorf("rule #%d: expected %d %s %s %s %s %s, got %d %s %s %s %s %s", idx,
+						exp.stmt, exp.pos, exp.action, exp.subject, exp.condition, exp.permissions,
+						r.Stmt, r.Pos, r.Action, r.Subject, condition, FormatPermissions(r.Permissions))
+				}
+			}
+		})
+	}
+}
+
+func TestSubjectCovers(t *testing.T) {
+	anyone, managers, bob := Subject{}, Subject{Kind: SubjectGroup, Name: "managers"}, Subject{Kind: SubjectUser, Name: "bob"}
+	tests := []struct {
+		s, other Subject
+		expected bool
+	}{
+		{anyone, managers, true},
+		{anyone, anyone, true},
+		{managers, managers, true},
+		{managers, anyone, false},
+		{managers, bob, false},
+		{bob, Subject{Kind: SubjectGroup, Name: "bob"}, false},
+	}
+	for idx, tst := range tests {
+		if actual := tst.s.Covers(tst.other); actual != tst.expected {
+			t.Errorf("tst #%d: %s covers %s: expected %v, got %v", idx, tst.s, tst.other, tst.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package access
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// Kinds of changes of the effective access
+const (
+	ChangeGranted = "granted" // denied before, allowed or conditional now
+	ChangeRevoked = "revoked" // allowed or conditional before, denied now
+	ChangeChanged = "changed" // allowed or conditional before and now, with another access or other conditions
+)
+
+// Change is a change of the effective access of a subject to a permission between two versions of the policies
+type Change struct {
+	Kind string `json:"kind"`
+	Old  *Entry `json:"old"`
+	New  *Entry `json:"new"`
+}
+
+// Widening is a rule of the new policies which is a rule of the old policies with a broader type pattern
+type Widening struct {
+	Old         *Rule        `json:"-"`
+	New         *Rule        `json:"-"`
+	Permissions []Permission `json:"permissions"` // permissions added by the new type pattern
+}
+
+// Diff returns the changes of the effective access between the old and new rules, sorted by subject,
+// type and verb. Only the effective access is compared, so reordered or reformatted rules show no change.
+func Diff(oldRules, newRules []*Rule, swaggerTypes []types.Type) []*Change {
+	subs := subjects(oldRules)
+	for sub := range subjects(newRules) {
+		subs[sub] = true
+	}
+
+	type key struct {
+		Subject
+		Permission
+	}
+	olds, news := map[key]*Entry{}, map[key]*Entry{}
+	keys := []key{}
+	for _, e := range matrix(oldRules, swaggerTypes, subs) {
+		k := key{e.Subject, e.Permission}
+		olds[k] = e
+		keys = append(keys, k)
+	}
+	for _, e := range matrix(newRules, swaggerTypes, subs) {
+		k := key{e.Subject, e.Permission}
+		news[k] = e
+		if _, ok := olds[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	// permissions not reported by the matrix are decided by the default action of the type
+	defaults := defaultActions(swaggerTypes)
+	entry := func(entries map[key]*Entry, k key) *Entry {
+		if e, ok := entries[k]; ok {
+			return e
+		}
+		e := &Entry{Subject: k.Subject, Permission: k.Permission, Access: AccessDeny}
+		if defaults[k.Type] == eval.ActionAllow {
+			e.Access = AccessAllow
+		}
+		return e
+	}
+
+	changes := []*Change{}
+	for _, k := range keys {
+		o, n := entry(olds, k), entry(news, k)
+		c := &Change{Old: o, New: n}
+		switch {
+		case o.Access == AccessDeny && n.Access != AccessDeny:
+			c.Kind = ChangeGranted
+		case o.Access != AccessDeny && n.Access == AccessDeny:
+			c.Kind = ChangeRevoked
+		case o.Access != AccessDeny && !o.equal(n):
+			c.Kind = ChangeChanged
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return less(changes[i].New, changes[j].New) })
+	return changes
+}
+
+// Widened returns the rules of the new rules which are rules of the old rules, with the same action, subject,
+// verb and where clause, but with a type pattern applying to more permissions
+func Widened(oldRules, newRules []*Rule) []*Widening {
+	signature := func(r *Rule) string {
+		st := *r.Statement
+		st.TypePattern = nil
+		return st.String()
+	}
+
+	olds := map[string][]*Rule{}
+	for _, r := range oldRules {
+		olds[signature(r)] = append(olds[signature(r)], r)
+	}
+
+	widenings := []*Widening{}
+	for _, n := range newRules {
+		for _, o := range olds[signature(n)] {
+			if o.Statement.TypePattern.Value == n.Statement.TypePattern.Value || !Contains(n.Permissions, o.Permissions) {
+				continue
+			}
+			added := []Permission{}
+			for _, p := range n.Permissions {
+				if !Contains(o.Permissions, []Permission{p}) {
+					added = append(added, p)
+				}
+			}
+			if len(added) > 0 {
+				widenings = append(widenings, &Widening{Old: o, New: n, Permissions: added})
+				break
+			}
+		}
+	}
+	return widenings
+}
+
+// equal returns true if the entries have the same access and conditions, in any order
+func (e *Entry) equal(other *Entry) bool {
+	return e.Access == other.Access &&
+		strings.Join(sorted(e.AllowIf), "\n") == strings.Join(sorted(other.AllowIf), "\n") &&
+		strings.Join(sorted(e.DenyIf), "\n") == strings.Join(sorted(other.DenyIf), "\n")
+}
+
+// less orders the entries by subject, type and verb
+func less(a, b *Entry) bool {
+	switch {
+	case a.Subject != b.Subject:
+		return a.Subject.String() < b.Subject.String()
+	case a.Type != b.Type:
+		return a.Type < b.Type
+	}
+	return a.Verb < b.Verb
+}
+
+func sorted(strs []string) []string {
+	out := append([]string{}, strs...)
+	sort.Strings(out)
+	return out
+}
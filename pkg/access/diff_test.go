@@ -0,0 +1,87 @@
+package access
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldRules, swaggerTypes := expand(t, `
+allow subject group managers to manage shop.pet;
+deny subject group managers to manage shop.pet where ctx.age < 2;
+allow subject user bob to buy shop.pet;
+allow subject group customers to buy shop.pet where ctx.age > 2;
+deny subject group banned to manage shop.order;
+`)
+
+	tests := []struct {
+		name     string
+		policies string
+		expected []string
+		widened  []string
+	}{
+		{
+			name: "reordered and reformatted",
+			policies: `
+deny subject group banned to manage shop.order;
+allow subject group customers   to buy shop.pet
+    where ctx.age > 2;
+allow subject user bob to buy shop.pet;
+deny subject group managers to manage shop.pet where ctx.age < 2;
+allow subject group managers to manage shop.pet;
+`,
+		},
+		{
+			name: "granted, revoked and changed",
+			policies: `
+allow subject group managers to manage shop.*;
+deny subject group managers to manage shop.pet where ctx.age < 2 or ctx.age > 20;
+allow subject group customers to buy shop.pet where ctx.age > 3;
+deny subject group banned to manage shop.order;
+`,
+			expected: []string{
+				"changed group customers shop.pet:buy conditional [(ctx.age > 2)] [] -> conditional [(ctx.age > 3)] []",
+				"changed group managers shop.pet:get conditional [] [(ctx.age < 2)] -> conditional [] [((ctx.age < 2) or (ctx.age > 20))]",
+				"changed group managers shop.pet:update conditional [] [(ctx.age < 2)] -> conditional [] [((ctx.age < 2) or (ctx.age > 20))]",
+				"revoked user bob shop.pet:buy allow [] [] -> deny [] []",
+			},
+			widened: []string{"shop.seal:2:1 shop.pet -> shop.*: [shop.order:delete shop.order:get]"},
+		},
+		{
+			name: "granted by removing a deny",
+			policies: `
+allow subject group managers to manage shop.pet;
+deny subject group managers to manage shop.pet where ctx.age < 2;
+allow subject user bob to buy shop.pet;
+allow subject group customers to buy shop.pet where ctx.age > 2;
+`,
+			expected: []string{
+				"granted group banned shop.order:delete deny [] [] -> allow [] []",
+				"granted group banned shop.order:get deny [] [] -> allow [] []",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		newRules, _ := expand(t, tst.policies)
+
+		actual := []string{}
+		for _, c := range Diff(oldRules, newRules, swaggerTypes) {
+			actual = append(actual, fmt.Sprintf("%s %s %s %s %v %v -> %s %v %v", c.Kind, c.New.Subject, c.New.Permission,
+				c.Old.Access, c.Old.AllowIf, c.Old.DenyIf, c.New.Access, c.New.AllowIf, c.New.DenyIf))
+		}
+		if strings.Join(actual, "\n") != strings.Join(tst.expected, "\n") {
+			t.Errorf("%s: expected changes:\n%s\ngot:\n%s", tst.name, strings.Join(tst.expected, "\n"), strings.Join(actual, "\n"))
+		}
+
+		widened := []string{}
+		for _, w := range Widened(oldRules, newRules) {
+			widened = append(widened, fmt.Sprintf("%s %s -> %s: %s", w.New.Pos,
+				w.Old.Statement.TypePattern.Value, w.New.Statement.TypePattern.Value, w.Permissions))
+		}
+		if strings.Join(widened, "\n") != strings.Join(tst.widened, "\n") {
+			t.Errorf("%s: expected widened rules:\n%s\ngot:\n%s", tst.name, strings.Join(tst.widened, "\n"), strings.Join(widened, "\n"))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package access
+
+import (
+	"sort"
+
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// Effective access of a subject to a permission
+const (
+	AccessAllow       = "allow"
+	AccessDeny        = "deny"
+	AccessConditional = "conditional" // allowed depending on the conditions of the rules
+)
+
+// Entry is the effective access of a subject to a permission, deny overriding allow.
+// Rules without subject apply to every subject.
+type Entry struct {
+	Subject Subject `json:"subject"`
+	Permission
+	Access  string   `json:"access"`             // allow, deny or conditional
+	AllowIf []string `json:"allow_if,omitempty"` // conditions of the allow rules of a conditional access
+	DenyIf  []string `json:"deny_if,omitempty"`  // conditions of the deny rules of a conditional access
+}
+
+// Matrix returns the effective access of every subject of the rules to every permission of the allow and deny
+// rules applying to it, sorted by subject, type and verb. Permissions without allow rule are decided by
+// the default action of the type: they are not reported if the type denies by default,
+// unless an unconditional deny rule applies to the subject.
+func Matrix(rules []*Rule, swaggerTypes []types.Type) []*Entry {
+	return matrix(rules, swaggerTypes, subjects(rules))
+}
+
+// subjects returns the subjects of the allow and deny rules
+func subjects(rules []*Rule) map[Subject]bool {
+	subs := map[Subject]bool{}
+	for _, r := range rules {
+		if r.Action == eval.ActionAllow || r.Action == eval.ActionDeny {
+			subs[r.Subject] = true
+		}
+	}
+	return subs
+}
+
+// matrix returns the effective access of the subjects
+func matrix(rules []*Rule, swaggerTypes []types.Type, subjects map[Subject]bool) []*Entry {
+	defaults := defaultActions(swaggerTypes)
+	entries := []*Entry{}
+	for sub := range subjects {
+		byPerm := map[Permission]*Entry{}
+		always := map[Permission]map[string]bool{} // actions of the unconditional rules
+		for _, r := range rules {
+			if (r.Action != eval.ActionAllow && r.Action != eval.ActionDeny) || !r.Subject.Covers(sub) {
+				continue
+			}
+			for _, p := range r.Permissions {
+				e, ok := byPerm[p]
+				if !ok {
+					e = &Entry{Subject: sub, Permission: p}
+					byPerm[p] = e
+					always[p] = map[string]bool{}
+				}
+				switch {
+				case !r.Conditional():
+					always[p][r.Action] = true
+				case r.Action == eval.ActionAllow:
+					e.AllowIf = appendUnique(e.AllowIf, r.Condition.String())
+				default:
+					e.DenyIf = appendUnique(e.DenyIf, r.Condition.String())
+				}
+			}
+		}
+
+		for p, e := range byPerm {
+			allowAlways, denyAlways := always[p][eval.ActionAllow], always[p][eval.ActionDeny]
+			if !allowAlways && len(e.AllowIf) == 0 && !denyAlways && defaults[p.Type] != eval.ActionAllow {
+				continue // denied by default whatever the conditional deny rules
+			}
+			e.decide(allowAlways, denyAlways, defaults[p.Type])
+			entries = append(entries, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return entries
+}
+
+// decide sets the access of the entry, keeping the conditions only if the access is conditional
+func (e *Entry) decide(allowAlways, denyAlways bool, defaultAction string) {
+	switch {
+	case denyAlways:
+		e.Access = AccessDeny
+	case allowAlways || len(e.AllowIf) > 0 && defaultAction == eval.ActionAllow:
+		e.Access = AccessAllow
+		if len(e.DenyIf) > 0 {
+			e.Access = AccessConditional
+		}
+	case len(e.AllowIf) > 0:
+		e.Access = AccessConditional
+	case defaultAction == eval.ActionAllow && len(e.DenyIf) > 0:
+		e.Access = AccessConditional
+	case defaultAction == eval.ActionAllow:
+		e.Access = AccessAllow
+	default:
+		e.Access = AccessDeny
+	}
+
+	if e.Access != AccessConditional {
+		e.AllowIf, e.DenyIf = nil, nil
+	} else if allowAlways || defaultAction == eval.ActionAllow {
+		e.AllowIf = nil // allowed unless denied
+	}
+}
+
+// defaultActions returns the default actions by type
+func defaultActions(swaggerTypes []types.Type) map[string]string {
+	defaults := map[string]string{}
+	for _, swt := range swaggerTypes {
+		defaults[swt.String()] = swt.DefaultAction()
+	}
+	return defaults
+}
+
+func appendUnique(strs []string, s string) []string {
+	for _, str := range strs {
+		if str == s {
+			return strs
+		}
+	}
+	return append(strs, s)
+}
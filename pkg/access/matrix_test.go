@@ -0,0 +1,62 @@
+package access
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	rules, swaggerTypes := expand(t, `
+allow subject group managers to manage shop.*;
+deny subject group managers to manage shop.pet where ctx.age < 2;
+allow to buy shop.pet where ctx.age > 2;
+allow subject user bob to buy shop.pet;
+deny subject user bob to manage shop.order;
+deny to ship shop.order where ctx.id == "-1";
+deny to manage shop.pet where ctx.age == 1;
+`)
+
+	var out bytes.Buffer
+	if err := WriteCSV(&out, Matrix(rules, swaggerTypes)); err != nil {
+		t.Fatalf("could not write csv: %s", err)
+	}
+
+	expected := `subject,type,verb,access,allow if,deny if
+*,shop.order,ship,conditional,,"(ctx.id == ""-1"")"
+*,shop.pet,buy,conditional,(ctx.age > 2),
+group managers,shop.order,delete,allow,,
+group managers,shop.order,get,allow,,
+group managers,shop.order,ship,conditional,,"(ctx.id == ""-1"")"
+group managers,shop.pet,buy,conditional,(ctx.age > 2),
+group managers,shop.pet,get,conditional,,(ctx.age < 2) or (ctx.age == 1)
+group managers,shop.pet,update,conditional,,(ctx.age < 2) or (ctx.age == 1)
+user bob,shop.order,delete,deny,,
+user bob,shop.order,get,deny,,
+user bob,shop.order,ship,conditional,,"(ctx.id == ""-1"")"
+user bob,shop.pet,buy,allow,,
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	entries := []*Entry{
+		{Subject: Subject{Kind: SubjectGroup, Name: "managers"}, Permission: Permission{Type: "shop.pet", Verb: "get"}, Access: AccessAllow},
+		{Subject: Subject{}, Permission: Permission{Type: "shop.pet", Verb: "buy"}, Access: AccessConditional,
+			AllowIf: []string{`(ctx.name =~ "a|b")`, `(ctx.age > 2)`}},
+	}
+
+	var out bytes.Buffer
+	if err := WriteMarkdown(&out, entries); err != nil {
+		t.Fatalf("could not write markdown: %s", err)
+	}
+
+	expected := "| subject | type | verb | access | allow if | deny if |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| group managers | shop.pet | get | allow |  |  |\n" +
+		"| * | shop.pet | buy | conditional | `(ctx.name =~ \"a\\|b\") or (ctx.age > 2)` |  |\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
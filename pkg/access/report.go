@@ -0,0 +1,59 @@
+package access
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// header of the CSV and Markdown reports
+var header = []string{"subject", "type", "verb", "access", "allow if", "deny if"}
+
+// row returns the columns of the entry, the conditions of the rules are or-ed
+func (e *Entry) row() []string {
+	return []string{
+		e.Subject.String(),
+		e.Type,
+		e.Verb,
+		e.Access,
+		strings.Join(e.AllowIf, " or "),
+		strings.Join(e.DenyIf, " or "),
+	}
+}
+
+// WriteCSV writes the entries as CSV, with a header line
+func WriteCSV(w io.Writer, entries []*Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write(e.row()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the entries as a Markdown table
+func WriteMarkdown(w io.Writer, entries []*Entry) error {
+	if _, err := fmt.Fprintf(w, "| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat(" --- |", len(header))); err != nil {
+		return err
+	}
+	escaper := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, e := range entries {
+		cols := e.row()
+		for i, col := range cols {
+			cols[i] = escaper.Replace(col)
+			if i >= 4 && col != "" {
+				cols[i] = "`" + cols[i] + "`"
+			}
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cols, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ type Policy interface {
 type Statement interface {
 	Node
 	statementNode()
+	Pos() token.Position // position of the statement in the seal source
 }
 
 type Action interface {
@@ -39,6 +40,7 @@ type Condition interface {
 	Node
 	conditionNode()
 	GetTypes() []*Identifier
+	Pos() token.Position // position of the condition in the seal source
 }
 
 // concrete types
@@ -67,6 +69,7 @@ type Identifier struct {
 }
 
 func (slf *Identifier) conditionNode()       {}
+func (slf *Identifier) Pos() token.Position  { return slf.Token.Pos }
 func (slf *Identifier) TokenLiteral() string { return slf.Token.Literal }
 func (slf *Identifier) String() string {
 	switch slf.Token.Type {
@@ -89,6 +92,7 @@ type IntegerLiteral struct {
 }
 
 func (slf *IntegerLiteral) conditionNode()       {}
+func (slf *IntegerLiteral) Pos() token.Position  { return slf.Token.Pos }
 func (slf *IntegerLiteral) TokenLiteral() string { return slf.Token.Literal }
 func (slf *IntegerLiteral) String() string       { return slf.Token.Literal }
 func (slf *IntegerLiteral) GetTypes() []*Identifier {
@@ -100,7 +104,8 @@ type ArrayLiteral struct {
 	Items []Condition
 }
 
-func (slf *ArrayLiteral) conditionNode()       {}
+func (slf *ArrayLiteral) conditionNode()      {}
+func (slf *ArrayLiteral) Pos() token.Position { return slf.Token.Pos }
 func (slf *ArrayLiteral) TokenLiteral() string {
 	var bldr strings.Builder
 	bldr.WriteString(`[`)
@@ -118,9 +123,55 @@ func (slf *ArrayLiteral) GetTypes() []*Identifier {
 	return []*Identifier{}
 }
 
+// DataReference is a reference to external data, e.g. `$threat.feed["over_21_skus"]`
+type DataReference struct {
+	Token token.Token
+	Name  string // dotted name after $, e.g. threat.feed
+	Key   string // index key, empty if the reference is not indexed
+}
+
+func (slf *DataReference) conditionNode()       {}
+func (slf *DataReference) Pos() token.Position  { return slf.Token.Pos }
+func (slf *DataReference) TokenLiteral() string { return slf.Token.Literal }
+func (slf *DataReference) String() string {
+	if slf.Key == "" {
+		return fmt.Sprintf(`$%s`, slf.Name)
+	}
+	return fmt.Sprintf(`$%s["%s"]`, slf.Name, slf.Key)
+}
+func (slf *DataReference) GetTypes() []*Identifier {
+	return []*Identifier{}
+}
+
+// ActionProperty defines an action property, e.g. `notify="true"` in `deny (notify="true") ...`
+type ActionProperty struct {
+	Token token.Token // the property name token
+	Name  string
+	Value Condition
+}
+
+func (a *ActionProperty) Pos() token.Position  { return a.Token.Pos }
+func (a *ActionProperty) TokenLiteral() string { return a.Token.Literal }
+func (a *ActionProperty) String() string {
+	if types.IsNilInterface(a.Value) {
+		return a.Name
+	}
+	return fmt.Sprintf("%s=%s", a.Name, a.Value.String())
+}
+
+// ActionPropertiesString returns the action properties as `(p1=v1, p2=v2)`
+func ActionPropertiesString(props []*ActionProperty) string {
+	strs := []string{}
+	for _, prop := range props {
+		strs = append(strs, prop.String())
+	}
+	return fmt.Sprintf("(%s)", strings.Join(strs, ", "))
+}
+
 type ActionStatement struct {
 	Token       token.Token
 	Action      *Identifier
+	Properties  []*ActionProperty
 	Subject     Subject
 	Verb        *Identifier
 	TypePattern *Identifier
@@ -128,10 +179,14 @@ type ActionStatement struct {
 }
 
 func (a *ActionStatement) statementNode()       {}
+func (a *ActionStatement) Pos() token.Position  { return a.Token.Pos }
 func (a *ActionStatement) TokenLiteral() string { return a.Token.Literal }
 func (a *ActionStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(a.TokenLiteral() + " ")
+	if len(a.Properties) > 0 {
+		out.WriteString(ActionPropertiesString(a.Properties) + " ")
+	}
 	if !types.IsNilInterface(a.Subject) {
 		out.WriteString(a.Subject.String() + " ")
 	}
@@ -168,6 +223,7 @@ func (a *ContextCondition) String() string {
 type ContextActionRule struct {
 	Context     *ContextStatement
 	Action      *Identifier
+	Properties  []*ActionProperty
 	Subject     Subject
 	Verb        *Identifier
 	TypePattern *Identifier
@@ -182,6 +238,9 @@ func (a *ContextActionRule) String() string {
 	if !types.IsNilInterface(a.Action) {
 		out.WriteString(fmt.Sprintf("%s ", a.Action.String()))
 	}
+	if len(a.Properties) > 0 {
+		out.WriteString(fmt.Sprintf("%s ", ActionPropertiesString(a.Properties)))
+	}
 	if !types.IsNilInterface(a.Subject) {
 		out.WriteString(fmt.Sprintf("%s ", a.Subject.String()))
 	}
@@ -212,6 +271,7 @@ type ContextStatement struct {
 }
 
 func (a *ContextStatement) statementNode()       {}
+func (a *ContextStatement) Pos() token.Position  { return a.Token.Pos }
 func (a *ContextStatement) TokenLiteral() string { return a.Token.Literal }
 func (a *ContextStatement) String() string {
 	var out bytes.Buffer
@@ -272,6 +332,7 @@ type WhereClause struct {
 }
 
 func (slf *WhereClause) conditionNode()       {}
+func (slf *WhereClause) Pos() token.Position  { return slf.Token.Pos }
 func (slf *WhereClause) TokenLiteral() string { return slf.Token.Literal }
 func (slf *WhereClause) String() string {
 	if !types.IsNilInterface(slf.Condition) {
@@ -294,6 +355,7 @@ type PrefixCondition struct {
 }
 
 func (slf *PrefixCondition) conditionNode()       {}
+func (slf *PrefixCondition) Pos() token.Position  { return slf.Token.Pos }
 func (slf *PrefixCondition) TokenLiteral() string { return slf.Token.Literal }
 func (slf *PrefixCondition) String() string {
 	var out bytes.Buffer
@@ -320,7 +382,13 @@ type InfixCondition struct {
 	Right    Condition
 }
 
-func (slf *InfixCondition) conditionNode()       {}
+func (slf *InfixCondition) conditionNode() {}
+func (slf *InfixCondition) Pos() token.Position {
+	if !types.IsNilInterface(slf.Left) {
+		return slf.Left.Pos()
+	}
+	return slf.Token.Pos
+}
 func (slf *InfixCondition) TokenLiteral() string { return slf.Token.Literal }
 func (slf *InfixCondition) String() string {
 	var out bytes.Buffer
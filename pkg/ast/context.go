@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// LinearizeContext explodes the context statement into the list of action statements it defines.
+// The 'upper' part named 'Conditions' is the list of objects, that contain subjects and\or conditions,
+// the 'lower' part named 'ActionRules' contains actions, that also might contain a nested context.
+// Every condition is combined with every action rule.
+func LinearizeContext(stmt *ContextStatement) []*ActionStatement {
+	line := []*ActionStatement{}
+
+	// conditions defined for 'parent' context, inherited by the nested contexts,
+	// but only in case they are not blank, mean parent does not looks like context {}...
+	inherited := []*ContextCondition{}
+	for _, cond := range stmt.Conditions {
+		if !types.IsNilInterface(cond.Subject) || !types.IsNilInterface(cond.Where) {
+			inherited = append(inherited, cond)
+		}
+	}
+
+	// range for each condition and action
+	for i, cond := range stmt.Conditions {
+		for _, act := range stmt.ActionRules {
+			if types.IsNilInterface(act.Context) {
+				// non-context ActionRule, it should be mapped to the single ActionStatement
+				// initializing it with default values
+				cAction := &ActionStatement{
+					Token:       act.Action.Token, // token is taken from ActionRule
+					Action:      act.Action,       // and Action (allow, deny, etc) too.
+					Properties:  act.Properties,   // and action properties.
+					Verb:        stmt.Verb,        // By default Verb (to operate\read\...) is taken from context record
+					TypePattern: stmt.TypePattern, // and TypePattern (petstore.pet, as an example) too.
+					Subject:     cond.Subject,     // Subject is taken from condition
+					WhereClause: cond.Where,       // and WhereClause too
+				}
+
+				if !types.IsNilInterface(act.Verb) { // If Verb is defined for action - context's verb should be replaced
+					cAction.Verb = act.Verb
+				}
+				if !types.IsNilInterface(act.Subject) { // and subject
+					cAction.Subject = act.Subject
+				}
+				if !types.IsNilInterface(act.TypePattern) { // and type
+					cAction.TypePattern = act.TypePattern
+				}
+
+				if !types.IsNilInterface(act.Where) { // and Where, but it's a little harder
+					if types.IsNilInterface(cond.Where) {
+						// if no Where in context - just use Where from action
+						cAction.WhereClause = act.Where
+					} else {
+						// if Where defined in context and in ActionRule
+						// I should use both like (Where1) and (Where2)
+						cAction.WhereClause = &WhereClause{
+							Token: act.Where.Token,
+							Condition: &InfixCondition{
+								Token:    token.Token{Type: token.AND, Literal: token.AND},
+								Left:     act.Where.Condition,
+								Operator: token.AND,
+								Right:    cond.Where.Condition,
+							},
+						}
+					}
+				}
+
+				// And append generated ActionStatement to the list
+				line = append(line, cAction)
+			} else if i == 0 {
+				// in case of context in action it also should be exploded to list of ActionStatement,
+				// once with the conditions of the 'parent' context. The AST is not modified:
+				// the nested context is linearized from a copy with its own and the inherited conditions.
+				ctx := *act.Context
+				ctx.Conditions = append(append([]*ContextCondition{}, act.Context.Conditions...), inherited...)
+
+				// expand nested context and add resulting []ActionStatement to the current list
+				line = append(line, LinearizeContext(&ctx)...)
+			}
+		}
+	}
+	return line
+}
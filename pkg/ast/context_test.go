@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/token"
+)
+
+func ident(typ token.TokenType, value string) *Identifier {
+	return &Identifier{Token: token.Token{Type: typ, Literal: value}, Value: value}
+}
+
+func where(prop, value string) *WhereClause {
+	return &WhereClause{
+		Token: token.Token{Type: token.WHERE, Literal: "where"},
+		Condition: &InfixCondition{
+			Token:    token.Token{Type: token.OP_EQUAL_TO, Literal: "=="},
+			Left:     ident(token.IDENT, prop),
+			Operator: "==",
+			Right:    ident(token.LITERAL, value),
+		},
+	}
+}
+
+func TestLinearizeContext(t *testing.T) {
+	// context { where ctx.id == "1"; where ctx.id == "2"; } to use petstore.pet {
+	//     allow subject group foo;
+	//     context { subject group bar; } to manage { deny; };
+	// }
+	stmt := &ContextStatement{
+		Token: token.Token{Type: token.CONTEXT, Literal: "context"},
+		Conditions: []*ContextCondition{
+			{Where: where("ctx.id", "1")},
+			{Where: where("ctx.id", "2")},
+		},
+		Verb:        ident(token.IDENT, "use"),
+		TypePattern: ident(token.TYPE_PATTERN, "petstore.pet"),
+		ActionRules: []*ContextActionRule{
+			{
+				Action:  ident(token.IDENT, "allow"),
+				Subject: &SubjectGroup{Token: token.SUBJECT, Group: "foo"},
+			},
+			{
+				Context: &ContextStatement{
+					Token:      token.Token{Type: token.CONTEXT, Literal: "context"},
+					Conditions: []*ContextCondition{{Subject: &SubjectGroup{Token: token.SUBJECT, Group: "bar"}}},
+					Verb:       ident(token.IDENT, "manage"),
+					ActionRules: []*ContextActionRule{
+						{Action: ident(token.IDENT, "deny"), TypePattern: ident(token.TYPE_PATTERN, "petstore.pet")},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []string{
+		`allow subject group foo to use petstore.pet where (ctx.id == "1");`,
+		`deny subject group bar to manage petstore.pet;`,
+		`deny to manage petstore.pet where (ctx.id == "1");`,
+		`deny to manage petstore.pet where (ctx.id == "2");`,
+		`allow subject group foo to use petstore.pet where (ctx.id == "2");`,
+	}
+
+	// linearizing does not modify the AST, so every call returns the same statements
+	for call := 1; call <= 3; call++ {
+		actual := []string{}
+		for _, st := range LinearizeContext(stmt) {
+			actual = append(actual, st.String())
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("call #%d: expected:\n%q\ngot:\n%q", call, expected, actual)
+		}
+	}
+	if n := len(stmt.ActionRules[1].Context.Conditions); n != 1 {
+		t.Errorf("expected the nested context to keep its 1 condition, got %d", n)
+	}
+}
@@ -0,0 +1,92 @@
+package ast
+
+import (
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// ContainsOr returns true if the condition contains an `or` operator anywhere in its tree
+func ContainsOr(cnd Condition) bool {
+	switch s := cnd.(type) {
+	case *WhereClause:
+		return !types.IsNilInterface(s.Condition) && ContainsOr(s.Condition)
+	case *PrefixCondition:
+		return !types.IsNilInterface(s.Right) && ContainsOr(s.Right)
+	case *InfixCondition:
+		if s.Token.Type == token.OR {
+			return true
+		}
+		return (!types.IsNilInterface(s.Left) && ContainsOr(s.Left)) ||
+			(!types.IsNilInterface(s.Right) && ContainsOr(s.Right))
+	}
+	return false
+}
+
+// DisjunctiveNormalForm expands the condition into a list of `or`-free conditions (disjuncts).
+// The original condition is true if and only if at least one of the disjuncts is true.
+// Conditions without an `or` operator are returned unchanged as the single disjunct.
+// Negations are only pushed inwards (De Morgan) when the negated condition contains an `or`.
+func DisjunctiveNormalForm(cnd Condition) []Condition {
+	if types.IsNilInterface(cnd) || !ContainsOr(cnd) {
+		return []Condition{cnd}
+	}
+
+	switch s := cnd.(type) {
+	case *WhereClause:
+		return DisjunctiveNormalForm(s.Condition)
+
+	case *PrefixCondition:
+		if s.Token.Type != token.NOT {
+			return []Condition{cnd}
+		}
+		switch r := s.Right.(type) {
+		case *PrefixCondition:
+			if r.Token.Type == token.NOT { // not not X == X
+				return DisjunctiveNormalForm(r.Right)
+			}
+		case *InfixCondition:
+			switch r.Token.Type {
+			case token.OR: // not (A or B) == not A and not B
+				return DisjunctiveNormalForm(newLogicalCondition(token.AND, negate(r.Left), negate(r.Right)))
+			case token.AND: // not (A and B) == not A or not B
+				return DisjunctiveNormalForm(newLogicalCondition(token.OR, negate(r.Left), negate(r.Right)))
+			}
+		case *WhereClause:
+			return DisjunctiveNormalForm(negate(r.Condition))
+		}
+		return []Condition{cnd}
+
+	case *InfixCondition:
+		switch s.Token.Type {
+		case token.OR:
+			return append(DisjunctiveNormalForm(s.Left), DisjunctiveNormalForm(s.Right)...)
+		case token.AND:
+			out := []Condition{}
+			for _, l := range DisjunctiveNormalForm(s.Left) {
+				for _, r := range DisjunctiveNormalForm(s.Right) {
+					out = append(out, newLogicalCondition(token.AND, l, r))
+				}
+			}
+			return out
+		}
+	}
+
+	return []Condition{cnd}
+}
+
+func negate(cnd Condition) Condition {
+	return &PrefixCondition{
+		Token:    token.Token{Type: token.NOT, Literal: token.NOT},
+		Operator: token.NOT,
+		Right:    cnd,
+	}
+}
+
+func newLogicalCondition(op token.TokenType, left, right Condition) Condition {
+	return &InfixCondition{
+		Token:    token.Token{Type: op, Literal: string(op)},
+		Left:     left,
+		Operator: string(op),
+		Right:    right,
+	}
+}
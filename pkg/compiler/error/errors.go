@@ -3,6 +3,8 @@ package compiler_error
 import (
 	"errors"
 	"fmt"
+
+	"github.com/infobloxopen/seal/pkg/token"
 )
 
 // Errors ...
@@ -21,15 +23,30 @@ var (
 // Error defines a compiler specific error type
 type Error struct {
 	Err  error
-	Line int
+	Line int            // index of the statement
+	Pos  token.Position // position of the statement in the seal source, if known
 	Desc string
 }
 
 // Error satisfies the error interface
 func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("compiler_rego: at %s %s due to error: %s", e.Pos, e.Desc, e.Err)
+	}
 	return fmt.Sprintf("compiler_rego: at #%d %s due to error: %s", e.Line, e.Desc, e.Err)
 }
 
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithPos sets the position of the statement in the seal source
+func (e *Error) WithPos(pos token.Position) *Error {
+	e.Pos = pos
+	return e
+}
+
 // New is a convenience function to create an Error
 func New(err error, line int, desc string) *Error {
 	return &Error{
@@ -0,0 +1,336 @@
+package compiler_ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	compiler_semantic "github.com/infobloxopen/seal/pkg/compiler/semantic"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+	"github.com/mb0/glob"
+)
+
+// CompilerIR defines the compiler backend emitting the JSON intermediate representation
+type CompilerIR struct {
+	swaggerTypes []types.Type
+	swaggerMap   map[string]types.Type // by-name convenience map into swaggerTypes slice
+}
+
+// New creates a new compiler
+func New() (compiler.Compiler, error) {
+	return &CompilerIR{}, nil
+}
+
+// String satifies stringer interface
+func (c *CompilerIR) String() string {
+	return fmt.Sprintf("compiler for %s language", Language)
+}
+
+// Compile converts the AST policies to a JSON IR document
+func (c *CompilerIR) Compile(pkgname string, pols *ast.Policies, swaggerTypes []types.Type) (string, error) {
+	if pols == nil {
+		return "", compiler_error.ErrEmptyPolicies
+	}
+
+	c.swaggerTypes = swaggerTypes
+	c.swaggerMap = map[string]types.Type{}
+	for _, swt := range swaggerTypes {
+		c.swaggerMap[swt.String()] = swt
+	}
+
+	doc := &Document{
+		Version: Version,
+		Package: pkgname,
+		Types:   c.compileTypes(),
+		Rules:   []*Rule{},
+	}
+
+	for idx, stmt := range pols.Statements {
+		var stmts []*ast.ActionStatement
+		switch s := stmt.(type) {
+		case *ast.ActionStatement:
+			stmts = []*ast.ActionStatement{s}
+		case *ast.ContextStatement:
+			stmts = ast.LinearizeContext(s)
+		}
+
+		for _, act := range stmts {
+			rule, err := c.compileRule(idx, act)
+			if err != nil {
+				return "", compiler_error.New(err, idx, fmt.Sprintf("%s", stmt)).WithPos(act.Pos())
+			}
+			doc.Rules = append(doc.Rules, rule)
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// compileTypes converts the swagger types, sorted by name for deterministic output
+func (c *CompilerIR) compileTypes() []*Type {
+	out := []*Type{}
+	for _, swt := range c.swaggerTypes {
+		typ := &Type{
+			Name:          swt.String(),
+			DefaultAction: swt.DefaultAction(),
+			Verbs:         []*Verb{},
+			Actions:       []*Action{},
+			Properties:    compileProperties(swt),
+		}
+
+		for _, vrb := range swt.GetVerbs() {
+			typ.Verbs = append(typ.Verbs, &Verb{
+				Name:      vrb.GetName(),
+				BaseVerbs: append([]string{}, vrb.GetBaseVerbs()...),
+			})
+		}
+
+		for name, act := range swt.GetActions() {
+			action := &Action{Name: name}
+			if !types.IsNilInterface(act) {
+				for pname, prop := range act.GetProperties() {
+					action.Properties = append(action.Properties, &Property{Name: pname, Type: prop.GetType()})
+				}
+				sort.Slice(action.Properties, func(i, j int) bool {
+					return action.Properties[i].Name < action.Properties[j].Name
+				})
+			}
+			typ.Actions = append(typ.Actions, action)
+		}
+		sort.Slice(typ.Actions, func(i, j int) bool { return typ.Actions[i].Name < typ.Actions[j].Name })
+
+		out = append(out, typ)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func compileProperties(swt types.Type) []*Property {
+	out := []*Property{}
+	for name, prop := range swt.GetProperties() {
+		if prop == nil {
+			continue
+		}
+		isObligation, _ := compiler_semantic.IsObligationProperty(swt, "ctx."+name)
+		out = append(out, &Property{
+			Name:                 name,
+			Type:                 prop.GetType(),
+			Obligation:           isObligation,
+			AdditionalProperties: prop.HasAdditionalProperties(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// compileRule converts the AST action statement to a rule
+func (c *CompilerIR) compileRule(idx int, stmt *ast.ActionStatement) (*Rule, error) {
+	if stmt.Verb == nil {
+		return nil, compiler_error.ErrEmptyVerb
+	}
+	if stmt.TypePattern == nil {
+		return nil, compiler_error.ErrEmptyTypePattern
+	}
+
+	pos := stmt.Pos()
+	rule := &Rule{
+		Stmt:        idx,
+		Pos:         Position{File: pos.File, Line: pos.Line, Column: pos.Column},
+		Action:      stmt.Token.Literal,
+		Verb:        stmt.Verb.Value,
+		TypePattern: stmt.TypePattern.Value,
+		Types:       c.matchTypes(stmt.TypePattern.Value),
+	}
+
+	// properties of the rule are only resolved if the type pattern is a single registered type
+	swtype := c.swaggerMap[stmt.TypePattern.Value]
+
+	for _, prop := range stmt.Properties {
+		value, err := c.compileExpr(swtype, prop.Value)
+		if err != nil {
+			return nil, err
+		}
+		rule.Properties = append(rule.Properties, &PropertyValue{Name: prop.Name, Value: value})
+	}
+
+	if !types.IsNilInterface(stmt.Subject) {
+		switch s := stmt.Subject.(type) {
+		case *ast.SubjectGroup:
+			rule.Subject = &Subject{Kind: SubjectGroup, Name: s.Group}
+		case *ast.SubjectUser:
+			rule.Subject = &Subject{Kind: SubjectUser, Name: s.User}
+		default:
+			return nil, compiler_error.ErrInvalidSubject
+		}
+	}
+
+	// conditions are deferred to obligations as in rego, see compiler_semantic.Deferral
+	bodies, deferred, err := compiler_semantic.Deferral(swtype, stmt.WhereClause)
+	if err != nil {
+		return nil, err
+	}
+
+	where := []*Expr{}
+	for idx, body := range bodies {
+		if types.IsNilInterface(body) {
+			// a body without condition always holds
+			where = nil
+		} else if where != nil {
+			expr, err := c.compileExpr(swtype, body)
+			if err != nil {
+				return nil, err
+			}
+			where = append(where, expr)
+		}
+
+		oblige := []*Expr{}
+		for _, cnd := range deferred[idx] {
+			expr, err := c.compileExpr(swtype, cnd)
+			if err != nil {
+				return nil, err
+			}
+			oblige = append(oblige, expr)
+		}
+		switch len(oblige) {
+		case 0:
+		case 1:
+			rule.Obligations = append(rule.Obligations, oblige[0])
+		default:
+			rule.Obligations = append(rule.Obligations, &Expr{Kind: KindAnd, Args: oblige})
+		}
+	}
+
+	switch len(where) {
+	case 0:
+	case 1:
+		rule.Where = where[0]
+	default:
+		rule.Where = &Expr{Kind: KindOr, Args: where}
+	}
+
+	return rule, nil
+}
+
+// matchTypes returns the names of the registered types matching the type pattern
+func (c *CompilerIR) matchTypes(pattern string) []string {
+	out := []string{}
+	for _, swt := range c.swaggerTypes {
+		if m, err := glob.Match(pattern, swt.String()); err == nil && m {
+			out = append(out, swt.String())
+		}
+	}
+	return out
+}
+
+// compileExpr converts the AST condition to an expression
+func (c *CompilerIR) compileExpr(swtype types.Type, cnd ast.Condition) (*Expr, error) {
+	if types.IsNilInterface(cnd) {
+		return nil, compiler_error.ErrUnknownCondition
+	}
+
+	switch s := cnd.(type) {
+	case *ast.WhereClause:
+		return c.compileExpr(swtype, s.Condition)
+
+	case *ast.Identifier:
+		if s.Token.Type == token.LITERAL {
+			return &Expr{Kind: KindString, String: s.Token.Literal}, nil
+		}
+		for _, root := range []string{"ctx", types.SUBJECT} {
+			if strings.HasPrefix(s.Token.Literal, root+".") {
+				path := refPath(lexer.SplitIdentifier(s.Token.Literal))
+				return &Expr{Kind: KindRef, Root: root, Path: path, Type: c.refType(swtype, root, path)}, nil
+			}
+		}
+		return &Expr{Kind: KindIdent, Name: s.Token.Literal}, nil
+
+	case *ast.IntegerLiteral:
+		return &Expr{Kind: KindInt, Int: s.Value}, nil
+
+	case *ast.ArrayLiteral:
+		expr := &Expr{Kind: KindArray, Args: []*Expr{}}
+		for _, item := range s.Items {
+			it, err := c.compileExpr(swtype, item)
+			if err != nil {
+				return nil, err
+			}
+			expr.Args = append(expr.Args, it)
+		}
+		return expr, nil
+
+	case *ast.DataReference:
+		return &Expr{Kind: KindData, Name: s.Name, Key: s.Key}, nil
+
+	case *ast.PrefixCondition:
+		if s.Token.Type != token.NOT {
+			return nil, compiler_error.ErrUnknownCondition
+		}
+		rhs, err := c.compileExpr(swtype, s.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Kind: KindNot, Args: []*Expr{rhs}}, nil
+
+	case *ast.InfixCondition:
+		lhs, err := c.compileExpr(swtype, s.Left)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := c.compileExpr(swtype, s.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch s.Token.Type {
+		case token.AND, token.OR:
+			// nested operands of the same logical operator are flattened
+			expr := &Expr{Kind: string(s.Token.Type)}
+			for _, operand := range []*Expr{lhs, rhs} {
+				if operand.Kind == expr.Kind {
+					expr.Args = append(expr.Args, operand.Args...)
+				} else {
+					expr.Args = append(expr.Args, operand)
+				}
+			}
+			return expr, nil
+		}
+		return &Expr{Kind: KindCompare, Op: s.Token.Literal, Args: []*Expr{lhs, rhs}}, nil
+	}
+
+	return nil, compiler_error.ErrUnknownCondition
+}
+
+// refType returns the swagger type of the property referenced by root and path, if known
+func (c *CompilerIR) refType(swtype types.Type, root string, path []string) string {
+	if root == types.SUBJECT {
+		swtype = c.swaggerMap["unknown."+types.SUBJECT]
+	}
+	if types.IsNilInterface(swtype) {
+		return ""
+	}
+	prop, ok := types.LookupProperty(swtype.GetProperties(), strings.Join(path, "."))
+	if !ok {
+		return ""
+	}
+	return prop.GetType()
+}
+
+// refPath returns the components of the property path of the identifier parts,
+// e.g. [tags endangered] for ctx.tags["endangered"]
+func refPath(parts *lexer.IdentifierParts) []string {
+	path := []string{parts.Field}
+	if parts.Key != "" {
+		path = append(path, parts.Key)
+	}
+	return append(path, parts.Path...)
+}
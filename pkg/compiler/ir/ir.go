@@ -0,0 +1,160 @@
+package compiler_ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Version is the version of the IR documents, changed on incompatible changes of the layout
+const Version = "seal.ir/v1"
+
+// Document is the JSON intermediate representation of the policies of a seal package
+type Document struct {
+	Version string  `json:"version"`
+	Package string  `json:"package"`
+	Types   []*Type `json:"types"`
+	Rules   []*Rule `json:"rules"`
+}
+
+// Position is a location in a seal source
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Type is a registered swagger type
+type Type struct {
+	Name          string      `json:"name"` // group.name, e.g. petstore.pet
+	DefaultAction string      `json:"default_action,omitempty"`
+	Verbs         []*Verb     `json:"verbs"`
+	Actions       []*Action   `json:"actions"`
+	Properties    []*Property `json:"properties"`
+}
+
+// Verb is a seal verb with the base verbs it maps to
+type Verb struct {
+	Name      string   `json:"name"`
+	BaseVerbs []string `json:"base_verbs"`
+}
+
+// Action is an action of a type with the properties it accepts
+type Action struct {
+	Name       string      `json:"name"`
+	Properties []*Property `json:"properties,omitempty"`
+}
+
+// Property is a property of a type or of an action
+type Property struct {
+	Name                 string `json:"name"`
+	Type                 string `json:"type,omitempty"`                  // swagger type, e.g. string or integer
+	Obligation           bool   `json:"obligation,omitempty"`            // x-seal-obligation
+	AdditionalProperties bool   `json:"additional_properties,omitempty"` // tags, e.g. ctx.tags["color"]
+}
+
+// Rule is a single action statement. Context statements are linearized into
+// one rule per combination of context condition and action rule.
+type Rule struct {
+	Stmt        int              `json:"stmt"` // index of the seal statement the rule was compiled from
+	Pos         Position         `json:"pos"`
+	Action      string           `json:"action"`
+	Properties  []*PropertyValue `json:"properties,omitempty"`
+	Subject     *Subject         `json:"subject,omitempty"`
+	Verb        string           `json:"verb"`
+	TypePattern string           `json:"type_pattern"`
+	Types       []string         `json:"types"` // registered types matched by the type pattern
+	Where       *Expr            `json:"where,omitempty"`
+	// Obligations are the conditions of the where clause referring to x-seal-obligation properties,
+	// they are not part of Where and have to be enforced by the caller.
+	// They are deferred as in rego, see compiler_semantic.Deferral: a where clause containing `or`
+	// is deferred as a whole if any of its disjuncts refers to an obligation property.
+	Obligations []*Expr `json:"obligations,omitempty"`
+}
+
+// PropertyValue is an action property set by a rule, e.g. `notify="true"`
+type PropertyValue struct {
+	Name  string `json:"name"`
+	Value *Expr  `json:"value"`
+}
+
+// Subject kinds
+const (
+	SubjectGroup = "group"
+	SubjectUser  = "user"
+)
+
+// Subject is the subject of a rule
+type Subject struct {
+	Kind string `json:"kind"` // group or user
+	Name string `json:"name"`
+}
+
+// Expression kinds
+const (
+	KindAnd     = "and"     // Args: two or more operands
+	KindOr      = "or"      // Args: two or more operands
+	KindNot     = "not"     // Args: the negated operand
+	KindCompare = "compare" // Op: ==, !=, <, >, <=, >=, =~ or in, Args: left and right operand
+	KindRef     = "ref"     // Root: ctx or subject, Path: property path
+	KindString  = "string"  // String: the string value
+	KindInt     = "int"     // Int: the integer value
+	KindArray   = "array"   // Args: the items
+	KindData    = "data"    // Name: dotted name, Key: index key
+	KindIdent   = "ident"   // Name: the identifier
+)
+
+// Expr is a node of a where clause
+type Expr struct {
+	Kind   string   `json:"kind"`
+	Op     string   `json:"op,omitempty"`
+	Args   []*Expr  `json:"args,omitempty"`
+	Root   string   `json:"root,omitempty"`
+	Path   []string `json:"path,omitempty"` // e.g. ["tags", "endangered"] for ctx.tags["endangered"]
+	Type   string   `json:"type,omitempty"` // swagger type of the referenced property, if resolved
+	Name   string   `json:"name,omitempty"`
+	Key    string   `json:"key,omitempty"`
+	String string   `json:"string,omitempty"`
+	Int    int64    `json:"int,omitempty"`
+}
+
+// Unmarshal decodes a single IR document
+func Unmarshal(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if err := doc.checkVersion(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Decoder reads a stream of IR documents, e.g. the output of compiling several seal files
+type Decoder struct {
+	dec *json.Decoder
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// Decode reads the next IR document, it returns io.EOF at the end of the stream
+func (d *Decoder) Decode() (*Document, error) {
+	doc := &Document{}
+	if err := d.dec.Decode(doc); err != nil {
+		return nil, err
+	}
+	if err := doc.checkVersion(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (doc *Document) checkVersion() error {
+	if doc.Version != Version {
+		return fmt.Errorf("unsupported IR version %q, expected %q", doc.Version, Version)
+	}
+	return nil
+}
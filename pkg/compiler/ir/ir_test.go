@@ -0,0 +1,231 @@
+package compiler_ir
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+const irSwagger = `
+openapi: "3.0.0"
+info:
+  title: IR
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    shop.order:
+      type: object
+      properties:
+        status:
+          type: string
+        total:
+          type: integer
+        marketplace:
+          type: string
+          x-seal-obligation: true
+        tags:
+          type: object
+          additionalProperties: true
+        buyer:
+          type: object
+          properties:
+            email:
+              type: string
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        inspect:
+        - get
+        - list
+      x-seal-default-action: deny
+    subject:
+      type: object
+      properties:
+        groups:
+          type: array
+          items:
+            type: string
+      x-seal-type: none
+`
+
+func TestCompile(t *testing.T) {
+	cplr, err := compiler.NewPolicyCompiler(Language, irSwagger)
+	if err != nil {
+		t.Fatalf("could not create policy compiler: %s", err)
+	}
+
+	out, err := cplr.CompileFile("shop.seal", "shop", `allow subject group staff to inspect shop.order
+    where ctx.status == "open" and ctx.marketplace != "amazon" and ctx.tags["vip"] == "true";
+context {
+    subject user bob;
+} to inspect shop.* {
+    deny where ctx.buyer.email in $blocked.emails and not "staff" in subject.groups;
+}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	doc, err := Unmarshal([]byte(out))
+	if err != nil {
+		t.Fatalf("could not decode IR: %s", err)
+	}
+
+	if doc.Version != Version || doc.Package != "shop" {
+		t.Errorf("unexpected header: %s %s", doc.Version, doc.Package)
+	}
+
+	if len(doc.Types) != 2 || doc.Types[0].Name != "shop.order" || doc.Types[1].Name != "unknown.subject" {
+		t.Fatalf("expected types shop.order and unknown.subject, got: %s", mustMarshal(doc.Types))
+	}
+	expectedVerbs := []*Verb{{Name: "inspect", BaseVerbs: []string{"get", "list"}}}
+	if !reflect.DeepEqual(doc.Types[0].Verbs, expectedVerbs) {
+		t.Errorf("expected verbs %#v, got %#v", expectedVerbs, doc.Types[0].Verbs)
+	}
+	expectedProperties := []*Property{
+		{Name: "buyer", Type: "object"},
+		{Name: "marketplace", Type: "string", Obligation: true},
+		{Name: "status", Type: "string"},
+		{Name: "tags", Type: "object", AdditionalProperties: true},
+		{Name: "total", Type: "integer"},
+	}
+	if !reflect.DeepEqual(doc.Types[0].Properties, expectedProperties) {
+		t.Errorf("expected properties %#v, got %#v", expectedProperties, doc.Types[0].Properties)
+	}
+
+	ref := func(root, typ string, path ...string) *Expr {
+		return &Expr{Kind: KindRef, Root: root, Path: path, Type: typ}
+	}
+	str := func(s string) *Expr { return &Expr{Kind: KindString, String: s} }
+	cmp := func(op string, l, r *Expr) *Expr { return &Expr{Kind: KindCompare, Op: op, Args: []*Expr{l, r}} }
+
+	expectedRules := []*Rule{
+		{
+			Stmt:        0,
+			Pos:         Position{File: "shop.seal", Line: 1, Column: 1},
+			Action:      "allow",
+			Subject:     &Subject{Kind: SubjectGroup, Name: "staff"},
+			Verb:        "inspect",
+			TypePattern: "shop.order",
+			Types:       []string{"shop.order"},
+			Where: &Expr{Kind: KindAnd, Args: []*Expr{
+				cmp("==", ref("ctx", "string", "status"), str("open")),
+				cmp("==", ref("ctx", "", "tags", "vip"), str("true")),
+			}},
+			Obligations: []*Expr{
+				cmp("!=", ref("ctx", "string", "marketplace"), str("amazon")),
+			},
+		},
+		{
+			Stmt:        1,
+			Pos:         Position{File: "shop.seal", Line: 6, Column: 5},
+			Action:      "deny",
+			Subject:     &Subject{Kind: SubjectUser, Name: "bob"},
+			Verb:        "inspect",
+			TypePattern: "shop.*",
+			Types:       []string{"shop.order"},
+			Where: &Expr{Kind: KindAnd, Args: []*Expr{
+				cmp("in", ref("ctx", "", "buyer", "email"), &Expr{Kind: KindData, Name: "blocked.emails"}),
+				{Kind: KindNot, Args: []*Expr{cmp("in", str("staff"), ref("subject", "array", "groups"))}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(doc.Rules, expectedRules) {
+		t.Errorf("expected rules:\n%s\ngot:\n%s", mustMarshal(expectedRules), mustMarshal(doc.Rules))
+	}
+}
+
+func TestObligations(t *testing.T) {
+	ref := func(name, typ string) *Expr {
+		return &Expr{Kind: KindRef, Root: "ctx", Path: []string{name}, Type: typ}
+	}
+	str := func(s string) *Expr { return &Expr{Kind: KindString, String: s} }
+	status := &Expr{Kind: KindCompare, Op: "==", Args: []*Expr{ref("status", "string"), str("open")}}
+	total := &Expr{Kind: KindCompare, Op: ">", Args: []*Expr{ref("total", "integer"), &Expr{Kind: KindInt, Int: 10}}}
+	marketplace := &Expr{Kind: KindCompare, Op: "==", Args: []*Expr{ref("marketplace", "string"), str("amazon")}}
+
+	tests := []struct {
+		name        string
+		where       string
+		expected    *Expr
+		obligations []*Expr
+	}{
+		{
+			name:        "obligation operand of and",
+			where:       `ctx.status == "open" and ctx.marketplace == "amazon"`,
+			expected:    status,
+			obligations: []*Expr{marketplace},
+		},
+		{
+			name:     "or expanded into disjuncts",
+			where:    `ctx.status == "open" and (ctx.total > 10 or ctx.status == "open")`,
+			expected: &Expr{Kind: KindOr, Args: []*Expr{{Kind: KindAnd, Args: []*Expr{status, total}}, {Kind: KindAnd, Args: []*Expr{status, status}}}},
+		},
+		{
+			name:  "obligation in a disjunct",
+			where: `ctx.status == "open" and (ctx.total > 10 or ctx.marketplace == "amazon")`,
+			obligations: []*Expr{{Kind: KindAnd, Args: []*Expr{
+				status,
+				{Kind: KindOr, Args: []*Expr{total, marketplace}},
+			}}},
+		},
+	}
+
+	cplr, err := compiler.NewPolicyCompiler(Language, irSwagger)
+	if err != nil {
+		t.Fatalf("could not create policy compiler: %s", err)
+	}
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			out, err := cplr.Compile("shop", "allow to inspect shop.order where "+tst.where+";")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			doc, err := Unmarshal([]byte(out))
+			if err != nil {
+				t.Fatalf("could not decode IR: %s", err)
+			}
+			rule := doc.Rules[0]
+			if !reflect.DeepEqual(rule.Where, tst.expected) {
+				t.Errorf("expected where:\n%s\ngot:\n%s", mustMarshal(tst.expected), mustMarshal(rule.Where))
+			}
+			if !reflect.DeepEqual(rule.Obligations, tst.obligations) {
+				t.Errorf("expected obligations:\n%s\ngot:\n%s", mustMarshal(tst.obligations), mustMarshal(rule.Obligations))
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"version": "seal.ir/v1", "package": "a", "rules": []}
+{"version": "seal.ir/v1", "package": "b", "rules": []}`))
+	for _, pkg := range []string{"a", "b"} {
+		doc, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if doc.Package != pkg {
+			t.Errorf("expected package %s, got %s", pkg, doc.Package)
+		}
+	}
+	if _, err := dec.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got: %v", err)
+	}
+
+	_, err := Unmarshal([]byte(`{"version": "seal.ir/v0"}`))
+	if err == nil || err.Error() != `unsupported IR version "seal.ir/v0", expected "seal.ir/v1"` {
+		t.Errorf("expected version error, got: %v", err)
+	}
+}
+
+func mustMarshal(v interface{}) string {
+	out, _ := json.MarshalIndent(v, "", "  ")
+	return string(out)
+}
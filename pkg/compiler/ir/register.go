@@ -0,0 +1,14 @@
+package compiler_ir
+
+import (
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+// const...
+const (
+	Language = "ir"
+)
+
+func init() {
+	compiler.Register(Language, New)
+}
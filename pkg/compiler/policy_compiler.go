@@ -7,6 +7,7 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ghodss/yaml"
+	"github.com/infobloxopen/seal/pkg/ast"
 	"github.com/infobloxopen/seal/pkg/lexer"
 	"github.com/infobloxopen/seal/pkg/parser"
 	"github.com/infobloxopen/seal/pkg/types"
@@ -14,6 +15,7 @@ import (
 
 type IPolicyCompiler interface {
 	Compile(packageName string, policyString string) (string, error)
+	CompileFile(fileName string, packageName string, policyString string) (string, error)
 }
 
 var _ IPolicyCompiler = &PolicyCompiler{}
@@ -53,6 +55,11 @@ func (rc *PolicyCompiler) BackendCompiler() Compiler {
 	return rc.cmplr
 }
 
+// SwaggerTypes returns the types read from the swaggers
+func (rc *PolicyCompiler) SwaggerTypes() []types.Type {
+	return rc.swaggerTypes
+}
+
 func (rc *PolicyCompiler) mergeSwaggers(swaggerTypes ...string) (string, error) {
 	var rSw *openapi3.T
 
@@ -79,12 +86,14 @@ func (rc *PolicyCompiler) mergeSwaggers(swaggerTypes ...string) (string, error)
 }
 
 func (rc *PolicyCompiler) Compile(packageName string, policyString string) (string, error) {
-	l := lexer.New(policyString)
-	p := parser.New(l, rc.swaggerTypes)
-	pols := p.ParsePolicies()
-	polErrors := p.Errors()
-	if n := len(polErrors); n > 0 {
-		return "", errors.New(strings.Join(polErrors, "\n"))
+	return rc.CompileFile("", packageName, policyString)
+}
+
+// CompileFile compiles the policies read from fileName, errors are reported with file:line:col positions
+func (rc *PolicyCompiler) CompileFile(fileName string, packageName string, policyString string) (string, error) {
+	pols, err := rc.Parse(fileName, policyString)
+	if err != nil {
+		return "", err
 	}
 	if pols == nil {
 		return "", fmt.Errorf("unable to find any policies in package %s", packageName)
@@ -98,3 +107,16 @@ func (rc *PolicyCompiler) Compile(packageName string, policyString string) (stri
 
 	return content, nil
 }
+
+// Parse parses the policies read from fileName against the swagger types,
+// errors are reported with file:line:col positions
+func (rc *PolicyCompiler) Parse(fileName string, policyString string) (*ast.Policies, error) {
+	l := lexer.New(policyString).WithFile(fileName)
+	p := parser.New(l, rc.swaggerTypes)
+	pols := p.ParsePolicies()
+	polErrors := p.Errors()
+	if n := len(polErrors); n > 0 {
+		return nil, errors.New(strings.Join(polErrors, "\n"))
+	}
+	return pols, nil
+}
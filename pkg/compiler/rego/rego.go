@@ -1,13 +1,18 @@
 package compiler_rego
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/infobloxopen/seal/pkg/ast"
 	"github.com/infobloxopen/seal/pkg/compiler"
 	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	compiler_semantic "github.com/infobloxopen/seal/pkg/compiler/semantic"
+	"github.com/infobloxopen/seal/pkg/lexer"
 	"github.com/infobloxopen/seal/pkg/token"
 	"github.com/infobloxopen/seal/pkg/types"
 	"github.com/sirupsen/logrus"
@@ -17,9 +22,13 @@ const (
 	SOME_I = "some.i"
 )
 
+var regoRuleNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // CompilerRego defines the compiler rego backend
 type CompilerRego struct {
 	inputName    string // name of the generated OPA request input document, default "input"
+	decisions    bool   // emit the decisions set of the rules matching the request
+	stmtIdx      int    // index of the statement currently compiled, for the decisions set
 	lineNots     int    // number of nots per line currently encountered during compileCondition
 	swaggerTypes []types.Type
 	swaggerMap   map[string]*types.Type // by-name convenience map into swaggerTypes slice
@@ -42,6 +51,14 @@ func (c *CompilerRego) WithInputName(name string) *CompilerRego {
 	return c
 }
 
+// WithDecisions enables the decisions set, which traces the rules matching the request
+// with their action, statement id (as in obligations) and source line, e.g.
+// {"action": "deny", "line": 20, "stmt": "stmt6"}
+func (c *CompilerRego) WithDecisions(enabled bool) *CompilerRego {
+	c.decisions = enabled
+	return c
+}
+
 // CompilerRegoOption defines options
 type CompilerRegoOption func(c *CompilerRego)
 
@@ -67,10 +84,12 @@ func (c *CompilerRego) Compile(pkgname string, pols *ast.Policies, swaggerTypes
 		fmt.Sprintf("package %s", pkgname),
 	}
 
-	compiled = append(compiled, c.compileSetDefaults("false", "allow", "deny")...)
+	compiled = append(compiled, c.compileSetDefaults("false", c.actionNames()...)...)
 
 	compiled = append(compiled, c.compileBaseVerbs()...)
 
+	compiled = append(compiled, c.compileDefaultActions()...)
+
 	compiledObligationsMap := map[int][]string{}
 	compiledObligationsArr := []int{} // for deterministic testing output
 
@@ -81,6 +100,7 @@ func (c *CompilerRego) Compile(pkgname string, pols *ast.Policies, swaggerTypes
 		var stmtObligations []string
 
 		lineNum += 1
+		c.stmtIdx = idx
 		switch stmt.(type) {
 		case *ast.ActionStatement:
 			out, stmtObligations, err = c.compileStatement(stmt.(*ast.ActionStatement), lineNum)
@@ -93,7 +113,7 @@ func (c *CompilerRego) Compile(pkgname string, pols *ast.Policies, swaggerTypes
 		}).Trace("stmtObligations")
 
 		if err != nil {
-			return "", compiler_error.New(err, idx, fmt.Sprintf("%s", stmt))
+			return "", compiler_error.New(err, idx, fmt.Sprintf("%s", stmt)).WithPos(stmt.Pos())
 		}
 		compiled = append(compiled, out)
 
@@ -136,7 +156,7 @@ func (c *CompilerRego) Compile(pkgname string, pols *ast.Policies, swaggerTypes
 	}
 	compiled = append(compiled, "}")
 
-	compiled = append(compiled, CompiledRegoHelpers)
+	compiled = append(compiled, compileHelpers(c.inputName))
 
 	return c.prettify(strings.Join(compiled, "\n")), nil
 }
@@ -211,6 +231,46 @@ func (c *CompilerRego) compileSetDefaults(val string, ids ...string) []string {
 	return compiled
 }
 
+// compileDefaultActions defines the default action (x-seal-default-action) of every type,
+// used by the decision helper for requests matching neither an allow nor a deny rule
+func (c *CompilerRego) compileDefaultActions() []string {
+	compiled := []string{}
+	compiled = append(compiled, "")
+	compiled = append(compiled, "default_actions := {")
+	for _, swt := range c.swaggerTypes {
+		if defaultAction := swt.DefaultAction(); defaultAction != "" {
+			compiled = append(compiled, fmt.Sprintf("\"%s\": \"%s\",", swt.String(), defaultAction))
+		}
+	}
+	compiled = append(compiled, "}")
+
+	return compiled
+}
+
+// actionNames returns the names of the actions of all swagger types: allow and deny
+// followed by the custom actions in sorted order (for deterministic testing output).
+// Actions which are not valid rego rule names are skipped.
+func (c *CompilerRego) actionNames() []string {
+	names := []string{"allow", "deny"}
+	custom := []string{}
+	seen := map[string]bool{"allow": true, "deny": true}
+	for _, swt := range c.swaggerTypes {
+		for name := range swt.GetActions() {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if !regoRuleNameRegex.MatchString(name) {
+				logrus.WithField("action", name).Warn("action_is_not_a_valid_rego_rule_name")
+				continue
+			}
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
 // compileBaseVerbs defines base_verb mappings
 func (c *CompilerRego) compileBaseVerbs() []string {
 	compiled := []string{}
@@ -235,81 +295,6 @@ func (c *CompilerRego) compileBaseVerbs() []string {
 	return compiled
 }
 
-// ast.ContextStatement contains tree-like data
-// 'upper' part named 'Conditions' is the list of objects, that contain subjects and\or conditions
-// 'lower' part named 'ActionRules' contains action, that also might contain context
-// it should be exploded to the list of ActionStatement
-func (c *CompilerRego) linearizeContext(stmt *ast.ContextStatement) []*ast.ActionStatement {
-	logger := logrus.WithField("method", "linearizeContext")
-	logger.WithField("stmt", stmt.String()).Trace("linearize_stmt")
-	line := []*ast.ActionStatement{}
-
-	// range for each condition and action
-	for _, cond := range stmt.Conditions {
-		for _, act := range stmt.ActionRules {
-			if types.IsNilInterface(act.Context) {
-				// non-context ActionRule, it should be mapped to the single ActionStatement
-				// initializing it with default values
-				cAction := &ast.ActionStatement{
-					Token:       act.Action.Token, // token is taken from ActionRule
-					Action:      act.Action,       // and Action (allow, deny, etc) too.
-					Verb:        stmt.Verb,        // By default Verb (to operate\read\...) is taken from context record
-					TypePattern: stmt.TypePattern, // and TypePattern (petstore.pet, as an example) too.
-					Subject:     cond.Subject,     // Subject is taken from condition
-					WhereClause: cond.Where,       // and WhereClause too
-				}
-
-				if !types.IsNilInterface(act.Verb) { // If Verb is defined for action - context's verb should be replaced
-					cAction.Verb = act.Verb
-				}
-				if !types.IsNilInterface(act.Subject) { // and subject
-					cAction.Subject = act.Subject
-				}
-				if !types.IsNilInterface(act.TypePattern) { // and type
-					cAction.TypePattern = act.TypePattern
-				}
-
-				if !types.IsNilInterface(act.Where) { // and Where, but it's a little harder
-					if types.IsNilInterface(cond.Where) {
-						// if no Where in context - just use Where from action
-						cAction.WhereClause = act.Where
-					} else {
-						// if Where defined in context and in ActionRule
-						// I should use both like (Where1) and (Where2)
-						cAction.WhereClause = &ast.WhereClause{
-							Token: act.Where.Token,
-							Condition: &ast.InfixCondition{
-								Token:    token.Token{Type: token.AND, Literal: token.AND},
-								Left:     act.Where.Condition,
-								Operator: token.AND,
-								Right:    cond.Where.Condition,
-							},
-						}
-					}
-				}
-
-				// And append generated ActionStatement to the list
-				line = append(line, cAction)
-			} else {
-				// in case of context in action it also should be exploded to list of ActionStatement
-				ctx := act.Context
-
-				for _, icond := range stmt.Conditions { // add conditions defined for 'parent' context
-					// but only in case it's not blank, mean parent does not looks like context {}...
-					if !types.IsNilInterface(icond.Subject) || !types.IsNilInterface(icond.Where) {
-						ctx.Conditions = append(ctx.Conditions, icond)
-					}
-				}
-
-				// expand nested context and add resulting []ActionStatement to the current list
-				line = append(line, c.linearizeContext(ctx)...)
-			}
-		}
-	}
-	logger.WithField("len_line", len(line)).WithField("line", line).Trace("linearize")
-	return line
-}
-
 func (c *CompilerRego) compileContextStatement(stmt *ast.ContextStatement, lineNum *int) (string, []string, error) {
 	logger := logrus.WithField("method", "compileContextStatement").WithField("lineNum", *lineNum)
 	var err error
@@ -317,7 +302,7 @@ func (c *CompilerRego) compileContextStatement(stmt *ast.ContextStatement, lineN
 	var line []*ast.ActionStatement
 	var contextObligations []string
 
-	line = c.linearizeContext(stmt)
+	line = ast.LinearizeContext(stmt)
 	logger.WithFields(logrus.Fields{
 		"lineNum":    *lineNum,
 		"stmt":       stmt.String(),
@@ -340,64 +325,157 @@ func (c *CompilerRego) compileContextStatement(stmt *ast.ContextStatement, lineN
 	return rego, contextObligations, err
 }
 
-// compileStatement converts the AST statement to a string
+// compileStatement converts the AST statement to a string.
+// A where clause containing `or` is expanded into disjunctive normal form
+// and each disjunct is compiled into its own rule body.
 func (c *CompilerRego) compileStatement(stmt *ast.ActionStatement, lineNum int) (string, []string, error) {
 	logger := logrus.WithField("method", "compileStatement").WithField("lineNum", lineNum)
 
-	compiled := []string{}
 	action := stmt.Token.Literal
-	switch action {
-	case "allow":
-		compiled = append(compiled, "allow {")
-	case "deny":
-		compiled = append(compiled, "deny {")
-	}
 	logger.WithField("stmt", stmt.String()).WithField("action", action).Trace("stmt")
 
+	// every action is compiled into a rule set of the same name, e.g. allow, deny or redirect
+	if !regoRuleNameRegex.MatchString(action) {
+		return "", nil, fmt.Errorf("action '%s' is not a valid rego rule name", action)
+	}
+	ruleHeads := []string{action}
+
+	// action properties are emitted as a per-decision partial set,
+	// sharing the rule body of the action
+	if len(stmt.Properties) > 0 {
+		props, err := c.compileActionProperties(action, stmt.TypePattern, stmt.Properties)
+		if err != nil {
+			return "", nil, err
+		}
+		ruleHeads = append(ruleHeads, fmt.Sprintf("%s_properties[%s]", action, props))
+	}
+
+	// the decisions set shares the rule body of the action as well
+	if c.decisions {
+		ruleHeads = append(ruleHeads, c.compileDecision(action, stmt.Pos()))
+	}
+
+	head := []string{}
+
 	if !types.IsNilInterface(stmt.Subject) {
 		sub, err := c.compileSubject(stmt.Subject)
 		if err != nil {
 			return "", nil, err
 		}
-		compiled = append(compiled, sub)
+		head = append(head, sub)
 	}
 
 	vrb, err := c.compileVerb(stmt.Verb)
 	if err != nil {
 		return "", nil, err
 	}
-	compiled = append(compiled, vrb)
+	head = append(head, vrb)
 
 	tp, swtype, err := c.compileTypePattern(stmt.TypePattern)
 	if err != nil {
 		return "", nil, err
 	}
-	compiled = append(compiled, tp)
+	head = append(head, tp)
 
-	cnds, whereObligations, err := c.compileWhereClause(swtype, stmt.WhereClause, lineNum)
+	var swt types.Type
+	if swtype != nil {
+		swt = *swtype
+	}
+	bodies, stmtObligations, err := compiler_semantic.WhereClause(swt, stmt.WhereClause)
 	if err != nil {
 		return "", nil, err
 	}
-	if cnds != "" {
-		compiled = append(compiled, cnds)
-	}
 
-	compiled = append(compiled, "}")
+	// negation helpers are numbered per statement, not per disjunct
+	c.lineNots = 0
+
+	compiled := []string{}
+	for _, disjunct := range bodies {
+		cnds, err := c.compileWhereClause(disjunct, lineNum)
+		if err != nil {
+			return "", nil, err
+		}
 
-	var stmtObligations []string
-	if len(whereObligations) > 0 {
-		stmtObligations = append(stmtObligations, whereObligations[0])
-		if len(whereObligations) > 1 {
-			stmtObligations[0] = fmt.Sprintf("(%s)",
-				strings.Join(whereObligations, " and "))
+		body := head
+		if cnds != "" {
+			body = append(append([]string{}, head...), cnds)
 		}
-		stmtObligations[0] = fmt.Sprintf("type:%s; %s",
-			(*swtype).String(), stmtObligations[0])
+		compiled = append(compiled, compileRules(ruleHeads, body)...)
 	}
 
 	return strings.Join(compiled, "\n"), stmtObligations, nil
 }
 
+// compileRules emits one rule per rule head, all sharing the same body.
+// A statement without any rule head still emits its body to keep the output traceable.
+func compileRules(ruleHeads []string, body []string) []string {
+	if len(ruleHeads) == 0 {
+		ruleHeads = []string{""}
+	}
+
+	compiled := []string{}
+	for _, rh := range ruleHeads {
+		if rh != "" {
+			compiled = append(compiled, rh+" {")
+		}
+		compiled = append(compiled, body...)
+		compiled = append(compiled, "}")
+	}
+	return compiled
+}
+
+// compileDecision returns the head of the decisions set entry of the statement,
+// the line is omitted if the position of the statement is unknown
+func (c *CompilerRego) compileDecision(action string, pos token.Position) string {
+	fields := []string{fmt.Sprintf(`"action": "%s"`, action)}
+	if pos.IsValid() {
+		fields = append(fields, fmt.Sprintf(`"line": %d`, pos.Line))
+	}
+	fields = append(fields, fmt.Sprintf(`"stmt": "stmt%d"`, c.stmtIdx))
+	return fmt.Sprintf("decisions[{%s}]", strings.Join(fields, ", "))
+}
+
+// compileActionProperties converts the AST action properties to a rego object,
+// the properties are typed by the action of the types matching the type pattern of the statement
+func (c *CompilerRego) compileActionProperties(action string, tp *ast.Identifier, props []*ast.ActionProperty) (string, error) {
+	if tp == nil {
+		return "", compiler_error.ErrEmptyTypePattern
+	}
+
+	fields := []string{}
+	for _, prop := range props {
+		value, err := compiler_semantic.ActionPropertyValue(c.swaggerTypes, tp.Value, action, prop)
+		if err != nil {
+			return "", err
+		}
+
+		var regoValue string
+		switch v := value.(type) {
+		case bool:
+			regoValue = strconv.FormatBool(v)
+		case json.Number:
+			regoValue = v.String()
+		case string:
+			regoValue = strconv.Quote(v)
+		case *ast.DataReference:
+			regoValue = compileDataReference(v)
+		}
+
+		fields = append(fields, fmt.Sprintf(`"%s": %s`, prop.Name, regoValue))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", ")), nil
+}
+
+// compileDataReference converts the external data reference to a lookup in the rego data document,
+// e.g. $threat.feed["over_21_skus"] to data.threat.feed["over_21_skus"]
+func compileDataReference(ref *ast.DataReference) string {
+	if ref.Key == "" {
+		return fmt.Sprintf("data.%s", ref.Name)
+	}
+	return fmt.Sprintf("data.%s[%q]", ref.Name, ref.Key)
+}
+
 // compileSubject converts the AST subject to a string
 func (c *CompilerRego) compileSubject(sub ast.Subject) (string, error) {
 	switch t := sub.(type) {
@@ -429,8 +507,7 @@ func (c *CompilerRego) compileTypePattern(tp *ast.Identifier) (string, *types.Ty
 	}
 
 	// TODO: optimize with list of registered types instead of regex
-	quoted := strings.ReplaceAll(tp.Value, "*", ".*")
-	quoted = strings.ReplaceAll(quoted, "..*", ".*")
+	quoted := compiler_semantic.TypePattern(tp.Value)
 
 	swtype := c.swaggerMap[tp.Value]
 	swtypeStr := "nil"
@@ -454,47 +531,35 @@ func (c *CompilerRego) String() string {
 	return fmt.Sprintf("compiler for %s language", Language)
 }
 
-func (c *CompilerRego) compileWhereClause(swtype *types.Type, cnds ast.Condition, lineNum int) (string, []string, error) {
-	if types.IsNilInterface(cnds) {
-		return "", nil, nil
+// compileWhereClause converts a body of the where clause, without obligations, to a string
+func (c *CompilerRego) compileWhereClause(body ast.Condition, lineNum int) (string, error) {
+	condString, err := c.compileCondition(body, 0, lineNum)
+	if err != nil {
+		return "", err
 	}
 
-	c.lineNots = 0
-	switch s := cnds.(type) {
-	case *ast.WhereClause:
-		condString, obligations, isObligation, err := c.compileCondition(swtype, s.Condition, 0, lineNum)
-		if err != nil {
-			return "", nil, err
-		}
-
-		if isObligation {
-			condString = ""
-			obligations = append(obligations, s.Condition.String())
-		}
-
-		// some.i is added everywhere it might be needed
-		// and now extra some.i should be removed
-		arr := strings.Split(condString, "{")
-		for i := 0; i < len(arr); i++ {
-			arr[i] = cleanupSomeI(arr[i])
-		}
-		condString = strings.Join(arr, "{")
-
-		// add blank line before 'some i'
-		condString = strings.ReplaceAll(condString, "some i", "\nsome i")
-		// and remove it in case 'some i' in the beginning of the block
-		condString = strings.ReplaceAll(condString, "{\n\nsome i", "{\nsome i")
-		return condString, obligations, nil
-	default:
-		return "", nil, compiler_error.ErrUnknownWhereClause
+	// some.i is added everywhere it might be needed
+	// and now extra some.i should be removed
+	arr := strings.Split(condString, "{")
+	for i := 0; i < len(arr); i++ {
+		arr[i] = cleanupSomeI(arr[i])
 	}
+	condString = strings.Join(arr, "{")
+
+	// add blank line before 'some i'
+	condString = strings.ReplaceAll(condString, "some i", "\nsome i")
+	// and remove it in case 'some i' in the beginning of the block
+	condString = strings.ReplaceAll(condString, "{\n\nsome i", "{\nsome i")
+	return condString, nil
 }
 
-func (c *CompilerRego) compileCondition(swtype *types.Type, o ast.Condition, lvl, lineNum int) (string, []string, bool, error) {
-	logger := logrus.WithField("method", "compileCondition").WithField("lvl", lvl).WithField("condition", o.String())
+// compileCondition converts the AST condition to a string,
+// conditions touching obligation properties are deferred before, see compiler_semantic.WhereClause
+func (c *CompilerRego) compileCondition(o ast.Condition, lvl, lineNum int) (string, error) {
 	if types.IsNilInterface(o) {
-		return "", nil, false, nil
+		return "", nil
 	}
+	logger := logrus.WithField("method", "compileCondition").WithField("lvl", lvl).WithField("condition", o.String())
 
 	logger.WithField("type", fmt.Sprintf("%#v", o)).Trace("compileCondition")
 
@@ -503,95 +568,67 @@ func (c *CompilerRego) compileCondition(swtype *types.Type, o ast.Condition, lvl
 		switch s.Token.Type {
 		case token.LITERAL:
 			logger.WithField("result", s.String()).Trace("s.Token.Type==token.LITERAL")
-			return s.String(), nil, false, nil
+			return s.String(), nil
 		}
 
 		id := s.Token.Literal
 		logger.WithField("id", id).Trace("s.Token.Type!=token.LITERAL")
-		var isObligation bool
 
 		if strings.HasPrefix(id, "ctx.") {
-			id = strings.Replace(id, "ctx.", "", 1)
-			id = strings.Replace(id, "\"]", "", 1)
-			id = strings.Replace(id, "[\"", ".", 1)
-
-			// If object-type is known, check property exists and if it is obligation
-			if swtype != nil {
-				swlogger := logger.WithField("swtype", (*swtype).String()).WithField("id", id)
-				// Get the 0th component of the property, in case the condition
-				// is something like: ctx.tags["color"] == "blue"
-				id0 := strings.Split(id, ".")[0]
-
-				propMap := (*swtype).GetProperties()
-				pprop, ok := propMap[id0]
-				if !ok {
-					return "", nil, false, fmt.Errorf("Unknown property '%s' of type '%s'",
-						id0, (*swtype).String())
-				}
-
-				x_seal_obligation, ok, err := pprop.GetExtensionProp("x-seal-obligation")
-				if err != nil {
-					return "", nil, false, fmt.Errorf("type '%s': %s", (*swtype).String(), err)
-				} else if ok {
-					swlogger.WithField("x_seal_obligation", x_seal_obligation).Trace("x_seal_obligation")
-					var err error
-					isObligation, err = strconv.ParseBool(x_seal_obligation)
-					if err != nil {
-						return "", nil, false, fmt.Errorf("Bad bool value '%s' for property '%s' of type '%s'",
-							x_seal_obligation, id0, (*swtype).String())
-					}
-				}
+			// property path, e.g. ["tags", "labels", "team"] for ctx.tags["labels"]["team"]
+			idParts := lexer.SplitIdentifier(id)
+			lid := []string{idParts.Field}
+			if idParts.Key != "" {
+				lid = append(append(lid, idParts.Key), idParts.Path...)
 			}
 
-			lid := strings.Split(id, ".")
 			id = c.inputName + ".ctx[i][\"" + strings.Join(lid, "\"][\"") + "\"]"
 		}
 		if strings.HasPrefix(id, types.SUBJECT+".") {
 			id = strings.Replace(id, types.SUBJECT, "seal_subject", 1)
 		}
 
-		logger.WithField("id", id).WithField("isObligation", isObligation).Trace("isObligation")
-		return id, nil, isObligation, nil
+		logger.WithField("id", id).Trace("id")
+		return id, nil
 
 	case *ast.IntegerLiteral:
 		id := s.Token.Literal
-		return id, nil, false, nil
+		return id, nil
 
 	case *ast.ArrayLiteral:
-		return s.String(), nil, false, nil
+		return s.String(), nil
+
+	case *ast.DataReference:
+		return compileDataReference(s), nil
 
 	case *ast.PrefixCondition:
-		rhs, subObligations, subIsObligation, err := c.compileCondition(swtype, s.Right, lvl+1, lineNum)
+		rhs, err := c.compileCondition(s.Right, lvl+1, lineNum)
 		if err != nil {
-			return "", nil, false, err
+			return "", err
 		}
 
 		switch s.Token.Type {
 		case token.NOT:
 			c.lineNots += 1
 			ref := fmt.Sprintf("line%d_not%d_cnd", lineNum, c.lineNots)
-			if !subIsObligation {
-				c.negationMap[ref] = rhs
-				c.negationArr = append(c.negationArr, ref)
-			}
-			return fmt.Sprintf("%snot %s", spaces(lvl+1), ref), subObligations, subIsObligation, nil
+			c.negationMap[ref] = rhs
+			c.negationArr = append(c.negationArr, ref)
+			return fmt.Sprintf("%snot %s", spaces(lvl+1), ref), nil
 		default:
 			logger.WithField("token_type", s.Token.Type).Warn("unknown_prefix_condition")
 		}
-		return fmt.Sprintf(SOME_I+"\n%s %s", s.Token.Literal, rhs), subObligations, subIsObligation, nil
+		return fmt.Sprintf(SOME_I+"\n%s %s", s.Token.Literal, rhs), nil
 
 	case *ast.InfixCondition:
-		lhs, subObligations, lhsIsObligation, err := c.compileCondition(swtype, s.Left, lvl+1, lineNum)
+		lhs, err := c.compileCondition(s.Left, lvl+1, lineNum)
 		if err != nil {
-			return "", nil, false, err
+			return "", err
 		}
-		rhs, rhsObligations, rhsIsObligation, err := c.compileCondition(swtype, s.Right, lvl+1, lineNum)
+		rhs, err := c.compileCondition(s.Right, lvl+1, lineNum)
 		if err != nil {
-			return "", nil, false, err
+			return "", err
 		}
 
-		subObligations = append(subObligations, rhsObligations...)
-
 		// if strings.Contains(lhs, SOME_I) && strings.Contains(rhs, SOME_I) {
 		// 	lhs = strings.ReplaceAll(lhs, SOME_I+"\n", "")
 		// 	rhs = strings.ReplaceAll(rhs, SOME_I+"\n", "")
@@ -599,24 +636,11 @@ func (c *CompilerRego) compileCondition(swtype *types.Type, o ast.Condition, lvl
 		condString := ""
 		switch s.Token.Type {
 		case token.AND:
-			// Assume obligation conditions cannot include AND nor OR,
-			// so we can now generate fully-specified obligation condition(s)
-			// if either side of the AND/OR has an obligation property
-			if lhsIsObligation {
-				lhsIsObligation = false
-				subObligations = append(subObligations, s.Left.String())
-			} else {
-				condString = fmt.Sprintf("%s", lhs)
-			}
-			if rhsIsObligation {
-				rhsIsObligation = false
-				subObligations = append(subObligations, s.Right.String())
-			} else {
-				condString = fmt.Sprintf("%s\n%s", condString, rhs)
-			}
-			condString = strings.Trim(condString, "\n")
+			condString = strings.Trim(fmt.Sprintf("%s\n%s", lhs, rhs), "\n")
 		case token.OR:
-			return "", nil, false, fmt.Errorf("OR operator not supported yet")
+			// compileStatement expands OR into separate rule bodies, so an OR
+			// can only be encountered here if the expansion was bypassed
+			return "", fmt.Errorf("OR operator must be expanded before compiling condition '%s'", s)
 		case token.OP_MATCH:
 			condString = fmt.Sprintf("re_match(`%s`, %s)", strings.Trim(rhs, "\""), lhs)
 		case token.OP_IN:
@@ -636,10 +660,10 @@ func (c *CompilerRego) compileCondition(swtype *types.Type, o ast.Condition, lvl
 			condString = SOME_I + "\n" + condString
 		}
 
-		return condString, subObligations, (lhsIsObligation || rhsIsObligation), nil
+		return condString, nil
 	default:
 		logger.WithField("type", fmt.Sprintf("%#v", o)).Warn("unknown_condition")
-		return "", nil, false, compiler_error.ErrUnknownCondition
+		return "", compiler_error.ErrUnknownCondition
 	}
 }
 
@@ -672,8 +696,17 @@ func cleanupSomeI(inputStr string) string {
 	return inputStr
 }
 
+// CompiledRegoHelpers are the rego functions defined by seal, for the default input name
+var CompiledRegoHelpers = compileHelpers("input")
+
+// compileHelpers returns the rego functions defined by seal,
+// the decision reads the type of the request from the named input document
+func compileHelpers(inputName string) string {
+	return fmt.Sprintf(compiledRegoHelpersFormat, inputName)
+}
+
 const (
-	CompiledRegoHelpers = `
+	compiledRegoHelpersFormat = `
 # rego functions defined by seal
 
 # Helper to get the token payload.
@@ -685,5 +718,21 @@ seal_subject = payload {
 seal_list_contains(list, elem) {
     list[_] = elem
 }
+
+# decision is the action decided for the request: deny overrides allow,
+# requests matching neither are decided by the default action of the type
+decision = "deny" {
+    deny
+}
+
+decision = "allow" {
+    allow
+    not deny
+}
+
+decision = default_actions[%[1]s.type] {
+    not allow
+    not deny
+}
 `
 )
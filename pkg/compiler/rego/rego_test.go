@@ -1,10 +1,13 @@
 package compiler_rego
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/infobloxopen/seal/pkg/ast"
 	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/parser"
 	"github.com/infobloxopen/seal/pkg/token"
 	"github.com/infobloxopen/seal/pkg/types"
 )
@@ -105,6 +108,9 @@ default deny = false
 base_verbs := {
 }
 
+default_actions := {
+}
+
 allow {
     seal_list_contains(seal_subject.groups, ` + "`foo`" + `)
     seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
@@ -148,6 +154,9 @@ default deny = false
 base_verbs := {
 }
 
+default_actions := {
+}
+
 allow {
     seal_subject.sub == ` + "`foo`" + `
     seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
@@ -213,6 +222,9 @@ default deny = false
 base_verbs := {
 }
 
+default_actions := {
+}
+
 allow {
     seal_subject.sub == ` + "`foo`" + `
     seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
@@ -306,6 +318,9 @@ default deny = false
 base_verbs := {
 }
 
+default_actions := {
+}
+
 allow {
     seal_subject.sub == ` + "`foo`" + `
     seal_list_contains(base_verbs[abac_input.type][` + "`manage`" + `], abac_input.verb)
@@ -316,7 +331,7 @@ allow {
 }
 
 obligations := {
-}` + "\n" + CompiledRegoHelpers,
+}` + "\n" + compileHelpers("abac_input"),
 		},
 	}
 
@@ -337,6 +352,9 @@ obligations := {
 			t.Fatalf("expected output not returned for tst #%d %s.\n  EXPECTED: %s\n  ACTUAL: %s\n",
 				idx, tst.name, tst.expected, actual)
 		}
+		if !strings.Contains(actual, "decision = default_actions[abac_input.type] {") {
+			t.Errorf("expected the decision of tst #%d %s to read the type from abac_input", idx, tst.name)
+		}
 
 		if len(tst.expected) > 0 {
 			t.Logf("%s", tst.name)
@@ -345,6 +363,111 @@ obligations := {
 	}
 }
 
+func TestWithDecisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkg      string
+		pols     *ast.Policies
+		expected string
+		err      error
+	}{
+		{
+			name: "deny subject group banned to manage petstore.pet; allow to manage petstore.pet;",
+			pkg:  "foo",
+			pols: &ast.Policies{
+				Statements: []ast.Statement{
+					&ast.ActionStatement{
+						Token: token.Token{Type: token.IDENT, Literal: "deny", Pos: token.Position{File: "foo.seal", Line: 3, Column: 1}},
+						Action: &ast.Identifier{
+							Token: token.Token{Type: token.IDENT, Literal: "deny"},
+							Value: "deny",
+						},
+						Subject: &ast.SubjectGroup{Token: token.SUBJECT, Group: "banned"},
+						Verb: &ast.Identifier{
+							Token: token.Token{Type: token.IDENT, Literal: "manage"},
+							Value: "manage",
+						},
+						TypePattern: &ast.Identifier{
+							Token: token.Token{Type: token.TYPE_PATTERN, Literal: "petstore.pet"},
+							Value: "petstore.pet",
+						},
+					},
+					&ast.ActionStatement{
+						Token: token.Token{Type: token.IDENT, Literal: "allow"},
+						Action: &ast.Identifier{
+							Token: token.Token{Type: token.IDENT, Literal: "allow"},
+							Value: "allow",
+						},
+						Verb: &ast.Identifier{
+							Token: token.Token{Type: token.IDENT, Literal: "manage"},
+							Value: "manage",
+						},
+						TypePattern: &ast.Identifier{
+							Token: token.Token{Type: token.TYPE_PATTERN, Literal: "petstore.pet"},
+							Value: "petstore.pet",
+						},
+					},
+				},
+			},
+			expected: `
+package foo
+
+default allow = false
+default deny = false
+
+base_verbs := {
+}
+
+default_actions := {
+}
+
+deny {
+    seal_list_contains(seal_subject.groups, ` + "`banned`" + `)
+    seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
+    re_match(` + "`petstore.pet`" + `, input.type)
+}
+
+decisions[{"action": "deny", "line": 3, "stmt": "stmt0"}] {
+    seal_list_contains(seal_subject.groups, ` + "`banned`" + `)
+    seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
+    re_match(` + "`petstore.pet`" + `, input.type)
+}
+
+allow {
+    seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
+    re_match(` + "`petstore.pet`" + `, input.type)
+}
+
+decisions[{"action": "allow", "stmt": "stmt1"}] {
+    seal_list_contains(base_verbs[input.type][` + "`manage`" + `], input.verb)
+    re_match(` + "`petstore.pet`" + `, input.type)
+}
+
+obligations := {
+}` + "\n" + CompiledRegoHelpers,
+		},
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatalf("did not expect error creating backend - error: %s", err)
+	}
+	(c.(*CompilerRego)).WithDecisions(true)
+	var emptySwaggerTypes []types.Type
+	for idx, tst := range tests {
+		actual, err := c.Compile(tst.pkg, tst.pols, emptySwaggerTypes)
+		if tst.err == nil && err != nil || tst.err != nil && err == nil {
+			t.Fatalf("expected error state not returned for tst #%d tst:%s.\n  expected: %s  actual: %s",
+				idx+1, tst.name, tst.err, err)
+		}
+
+		if tst.expected != actual {
+			t.Fatalf("expected output not returned for tst #%d %s.\n  EXPECTED: %s\n  ACTUAL: %s\n",
+				idx, tst.name, tst.expected, actual)
+		}
+	}
+}
+
 func TestCleanupSomeI(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -388,3 +511,74 @@ func TestCleanupSomeI(t *testing.T) {
 		}
 	}
 }
+
+const pagerSwagger = `
+openapi: "3.0.0"
+components:
+  schemas:
+    redirect:
+      type: object
+      properties:
+        priority:
+          type: integer
+      x-seal-type: action
+    pager.alert:
+      type: object
+      x-seal-actions: [ "allow", "redirect" ]
+      x-seal-verbs:
+        use: [ "get" ]
+      x-seal-default-action: allow
+      properties:
+        id:
+          type: string
+`
+
+func TestActionPropertyTypes(t *testing.T) {
+	// the types of separate swagger specs declare actions of the same name with different properties
+	var swaggerTypes []types.Type
+	for _, spec := range []string{
+		pagerSwagger,
+		strings.NewReplacer("integer", "string", "pager.alert", "support.ticket").Replace(pagerSwagger),
+	} {
+		tps, err := types.NewTypeFromOpenAPIv3([]byte(spec))
+		if err != nil {
+			t.Fatalf("could not load swagger: %s", err)
+		}
+		swaggerTypes = append(swaggerTypes, tps...)
+	}
+
+	p := parser.New(lexer.New(`
+redirect (priority="1") to use support.ticket;
+redirect (priority="1") to use pager.alert;
+redirect (priority="\1") to use support.*;
+`), swaggerTypes)
+	pols := p.ParsePolicies()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("could not parse policies: %v", p.Errors())
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatalf("did not expect error creating backend - error: %s", err)
+	}
+	actual, err := c.Compile("support", pols, swaggerTypes)
+	if err != nil {
+		t.Fatalf("did not expect error compiling - error: %s", err)
+	}
+
+	// the properties are typed by the action of the type matching the statement
+	for _, expected := range []string{
+		"redirect_properties[{\"priority\": \"1\"}] {\n" +
+			"    seal_list_contains(base_verbs[input.type][`use`], input.verb)\n" +
+			"    re_match(`support.ticket`, input.type)\n",
+		"redirect_properties[{\"priority\": 1}] {\n" +
+			"    seal_list_contains(base_verbs[input.type][`use`], input.verb)\n" +
+			"    re_match(`pager.alert`, input.type)\n",
+		// string values are escaped
+		"redirect_properties[{\"priority\": \"\\\\1\"}] {\n",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected output to contain:\n%s\nACTUAL:\n%s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package rlscompiler
+
+import (
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+// const...
+const (
+	Language = "rls"
+)
+
+func init() {
+	compiler.Register(Language, New)
+}
@@ -0,0 +1,197 @@
+package rlscompiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	sqlcompiler "github.com/infobloxopen/seal/pkg/compiler/sql"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// SubjectSettings is the default prefix of the Postgres settings holding the subject claims,
+// e.g. SET seal.subject.sub = 'bob'; SET seal.subject.groups = '{admins,clerks}';
+const SubjectSettings = "seal.subject."
+
+// SQL commands of row level security policies
+const (
+	CommandAll    = "ALL"
+	CommandSelect = "SELECT"
+	CommandInsert = "INSERT"
+	CommandUpdate = "UPDATE"
+	CommandDelete = "DELETE"
+)
+
+// commands are the SQL commands in the order policies are generated
+var commands = []string{CommandSelect, CommandInsert, CommandUpdate, CommandDelete}
+
+// BaseVerbCommands maps the base verbs of x-seal-verbs to the SQL commands they perform
+var BaseVerbCommands = map[string]string{
+	"get":    CommandSelect,
+	"list":   CommandSelect,
+	"watch":  CommandSelect,
+	"read":   CommandSelect,
+	"create": CommandInsert,
+	"update": CommandUpdate,
+	"patch":  CommandUpdate,
+	"delete": CommandDelete,
+}
+
+// RLSCompiler compiles policies into Postgres row level security policies,
+// with the predicates of the SQL compiler
+type RLSCompiler struct {
+	SQLCompiler *sqlcompiler.SQLCompiler
+}
+
+// New creates a new row level security compiler backend
+func New() (compiler.Compiler, error) {
+	return NewRLSCompiler(), nil
+}
+
+// NewRLSCompiler returns a new instance of RLSCompiler for the Postgres dialect with quoted identifiers,
+// reading the subject claims from the SubjectSettings
+func NewRLSCompiler() *RLSCompiler {
+	return &RLSCompiler{
+		SQLCompiler: sqlcompiler.NewSQLCompiler().WithDialect(sqlcompiler.DialectPostgres).
+			WithQuotedIdentifiers(true).WithSubjectSettings(SubjectSettings),
+	}
+}
+
+// Compile compiles the policies into the statements enabling row level security on the table of each type,
+// and one policy per SQL command (a single policy FOR ALL if the commands share the same predicate).
+// The predicate of a command is allowed if the decision for one of its base verbs is allow:
+// each base verb is decided by all the statements whose verb includes it, denies included,
+// so a deny for a verb applies to every command of its base verbs.
+func (rlsc *RLSCompiler) Compile(pkgname string, pols *ast.Policies, swaggerTypes []types.Type) (string, error) {
+	sqlc := rlsc.SQLCompiler
+	savedVerb := sqlc.Verb
+	defer func() { sqlc.Verb = savedVerb }()
+
+	// predicates of each type, by base verb
+	verbPreds := map[string]map[string]string{}
+	for _, swt := range swaggerTypes {
+		for _, bv := range baseVerbs(swt) {
+			if _, done := verbPreds[bv]; done {
+				continue
+			}
+			preds, err := sqlc.WithVerb(bv).CompilePredicates(pols, swaggerTypes)
+			if err != nil {
+				return "", err
+			}
+			verbPreds[bv] = map[string]string{}
+			for _, pred := range preds {
+				verbPreds[bv][pred.Type] = pred.Where
+			}
+		}
+	}
+
+	compiled := []string{fmt.Sprintf("-- package %s", pkgname)}
+	for _, swt := range swaggerTypes {
+		if len(swt.GetVerbs()) == 0 {
+			continue // not a resource type, e.g. the subject
+		}
+
+		table := sqlc.Table(swt.String())
+		compiled = append(compiled, "", fmt.Sprintf("-- %s", swt), fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", table))
+
+		// the predicates of the base verbs of each command
+		cmdPreds := map[string][]string{}
+		unmapped := []string{}
+		for _, bv := range baseVerbs(swt) {
+			cmd, ok := BaseVerbCommands[bv]
+			if !ok {
+				unmapped = append(unmapped, bv)
+				continue
+			}
+			cmdPreds[cmd] = append(cmdPreds[cmd], verbPreds[bv][swt.String()])
+		}
+		if len(unmapped) > 0 {
+			compiled = append(compiled, fmt.Sprintf("-- no SQL command for base verbs %s", strings.Join(unmapped, ", ")))
+		}
+
+		cmds, wheres := []string{}, map[string]string{}
+		for _, cmd := range commands {
+			if preds, ok := cmdPreds[cmd]; ok {
+				cmds = append(cmds, cmd)
+				wheres[cmd] = rlsc.sqlOr(preds)
+			}
+		}
+		if len(cmds) == len(commands) && sameWhere(cmds, wheres) {
+			cmds = []string{CommandAll}
+			wheres[CommandAll] = wheres[CommandSelect]
+		}
+
+		for _, cmd := range cmds {
+			name := "seal_" + strings.ReplaceAll(swt.String(), ".", "_") + "_" + strings.ToLower(cmd)
+			if sqlc.QuoteIDs {
+				name = sqlc.Dialect.QuoteIdentifier(name)
+			}
+			compiled = append(compiled,
+				fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s;", name, table),
+				fmt.Sprintf("CREATE POLICY %s ON %s FOR %s %s;", name, table, cmd, policyClause(cmd, wheres[cmd])))
+		}
+	}
+	return strings.Join(compiled, "\n"), nil
+}
+
+// baseVerbs returns the distinct base verbs of the verbs of the type, in verb order
+func baseVerbs(swt types.Type) []string {
+	seen := map[string]bool{}
+	bvs := []string{}
+	for _, vrb := range swt.GetVerbs() {
+		for _, bv := range vrb.GetBaseVerbs() {
+			if !seen[bv] {
+				seen[bv] = true
+				bvs = append(bvs, bv)
+			}
+		}
+	}
+	return bvs
+}
+
+// sqlOr ORs the distinct predicates, a row is allowed if one of them holds
+func (rlsc *RLSCompiler) sqlOr(preds []string) string {
+	trueLit, falseLit := rlsc.SQLCompiler.Dialect.BoolLiteral(true), rlsc.SQLCompiler.Dialect.BoolLiteral(false)
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, pred := range preds {
+		switch {
+		case pred == trueLit:
+			return trueLit
+		case pred == falseLit || seen[pred]:
+			continue
+		}
+		seen[pred] = true
+		distinct = append(distinct, pred)
+	}
+
+	switch len(distinct) {
+	case 0:
+		return falseLit
+	case 1:
+		return distinct[0]
+	}
+	return "(" + strings.Join(distinct, ") OR (") + ")"
+}
+
+// sameWhere returns true if the commands share the same predicate
+func sameWhere(cmds []string, wheres map[string]string) bool {
+	for _, cmd := range cmds {
+		if wheres[cmd] != wheres[cmds[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// policyClause returns the USING clause filtering existing rows and/or the WITH CHECK clause checking new rows
+func policyClause(cmd, where string) string {
+	switch cmd {
+	case CommandSelect, CommandDelete:
+		return fmt.Sprintf("USING (%s)", where)
+	case CommandInsert:
+		return fmt.Sprintf("WITH CHECK (%s)", where)
+	}
+	return fmt.Sprintf("USING (%s) WITH CHECK (%s)", where, where)
+}
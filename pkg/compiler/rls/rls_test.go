@@ -0,0 +1,161 @@
+package rlscompiler
+
+import (
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+const rlsSwagger = `
+openapi: "3.0.0"
+info:
+  title: RLS
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    shop.order:
+      type: object
+      properties:
+        status:
+          type: string
+        owner:
+          type: string
+          nullable: true
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        read:
+        - get
+        - list
+        use:
+        - update
+        - delete
+        manage:
+        - create
+        - update
+        - delete
+        - get
+        - list
+        ship:
+        - ship
+      x-seal-default-action: deny
+    subject:
+      type: object
+      properties:
+        email:
+          type: string
+      x-seal-type: none
+`
+
+const rlsPolicies = `
+allow subject group clerks to read shop.order;
+allow to read shop.order where ctx.owner == subject.email;
+allow subject user boss to use shop.order where ctx.status == "open";
+allow subject group admins to manage shop.order;
+deny to manage shop.order where ctx.status == "archived";
+`
+
+func TestCompile(t *testing.T) {
+	cplr, err := compiler.NewPolicyCompiler(Language, rlsSwagger)
+	if err != nil {
+		t.Fatalf("could not create policy compiler: %s", err)
+	}
+
+	expected := `-- package shop
+
+-- shop.order
+ALTER TABLE "order" ENABLE ROW LEVEL SECURITY;
+-- no SQL command for base verbs ship
+DROP POLICY IF EXISTS "seal_shop_order_select" ON "order";
+CREATE POLICY "seal_shop_order_select" ON "order" FOR SELECT USING (((('clerks' = ANY(current_setting('seal.subject.groups', true)::text[])) OR ("order"."owner" = current_setting('seal.subject.email', true)) OR ('admins' = ANY(current_setting('seal.subject.groups', true)::text[]))) AND (("order"."status" = 'archived') IS NOT TRUE)));
+DROP POLICY IF EXISTS "seal_shop_order_insert" ON "order";
+CREATE POLICY "seal_shop_order_insert" ON "order" FOR INSERT WITH CHECK ((('admins' = ANY(current_setting('seal.subject.groups', true)::text[])) AND (("order"."status" = 'archived') IS NOT TRUE)));
+DROP POLICY IF EXISTS "seal_shop_order_update" ON "order";
+CREATE POLICY "seal_shop_order_update" ON "order" FOR UPDATE USING (((((current_setting('seal.subject.sub', true) = 'boss') AND ("order"."status" = 'open')) OR ('admins' = ANY(current_setting('seal.subject.groups', true)::text[]))) AND (("order"."status" = 'archived') IS NOT TRUE))) WITH CHECK (((((current_setting('seal.subject.sub', true) = 'boss') AND ("order"."status" = 'open')) OR ('admins' = ANY(current_setting('seal.subject.groups', true)::text[]))) AND (("order"."status" = 'archived') IS NOT TRUE)));
+DROP POLICY IF EXISTS "seal_shop_order_delete" ON "order";
+CREATE POLICY "seal_shop_order_delete" ON "order" FOR DELETE USING (((((current_setting('seal.subject.sub', true) = 'boss') AND ("order"."status" = 'open')) OR ('admins' = ANY(current_setting('seal.subject.groups', true)::text[]))) AND (("order"."status" = 'archived') IS NOT TRUE)));`
+
+	actual, err := cplr.Compile("shop", rlsPolicies)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if actual != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, actual)
+	}
+}
+
+func TestCompileCommands(t *testing.T) {
+	cplr, err := compiler.NewPolicyCompiler(Language, rlsSwagger)
+	if err != nil {
+		t.Fatalf("could not create policy compiler: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		policies string
+		expected string
+	}{
+		{
+			name: "deny of a broader verb applies to the commands of a narrower verb",
+			policies: `
+allow to read shop.order;
+deny subject group banned to manage shop.order;
+`,
+			expected: `
+DROP POLICY IF EXISTS "seal_shop_order_select" ON "order";
+CREATE POLICY "seal_shop_order_select" ON "order" FOR SELECT USING ((('banned' = ANY(current_setting('seal.subject.groups', true)::text[])) IS NOT TRUE));
+DROP POLICY IF EXISTS "seal_shop_order_insert" ON "order";
+CREATE POLICY "seal_shop_order_insert" ON "order" FOR INSERT WITH CHECK (FALSE);
+DROP POLICY IF EXISTS "seal_shop_order_update" ON "order";
+CREATE POLICY "seal_shop_order_update" ON "order" FOR UPDATE USING (FALSE) WITH CHECK (FALSE);
+DROP POLICY IF EXISTS "seal_shop_order_delete" ON "order";
+CREATE POLICY "seal_shop_order_delete" ON "order" FOR DELETE USING (FALSE);`,
+		},
+		{
+			// rows without owner (NULL) stay visible as in rego, where the deny does not fire
+			name: "deny of a nullable column",
+			policies: `
+allow to read shop.order;
+deny to manage shop.order where ctx.owner == "mallory";
+`,
+			expected: `
+DROP POLICY IF EXISTS "seal_shop_order_select" ON "order";
+CREATE POLICY "seal_shop_order_select" ON "order" FOR SELECT USING ((("order"."owner" = 'mallory') IS NOT TRUE));
+DROP POLICY IF EXISTS "seal_shop_order_insert" ON "order";
+CREATE POLICY "seal_shop_order_insert" ON "order" FOR INSERT WITH CHECK (FALSE);
+DROP POLICY IF EXISTS "seal_shop_order_update" ON "order";
+CREATE POLICY "seal_shop_order_update" ON "order" FOR UPDATE USING (FALSE) WITH CHECK (FALSE);
+DROP POLICY IF EXISTS "seal_shop_order_delete" ON "order";
+CREATE POLICY "seal_shop_order_delete" ON "order" FOR DELETE USING (FALSE);`,
+		},
+		{
+			name: "commands sharing the same predicate",
+			policies: `
+allow subject group admins to manage shop.order;
+`,
+			expected: `
+DROP POLICY IF EXISTS "seal_shop_order_all" ON "order";
+CREATE POLICY "seal_shop_order_all" ON "order" FOR ALL USING (('admins' = ANY(current_setting('seal.subject.groups', true)::text[]))) WITH CHECK (('admins' = ANY(current_setting('seal.subject.groups', true)::text[])));`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			expected := `-- package shop
+
+-- shop.order
+ALTER TABLE "order" ENABLE ROW LEVEL SECURITY;
+-- no SQL command for base verbs ship` + tst.expected
+
+			actual, err := cplr.Compile("shop", tst.policies)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != expected {
+				t.Errorf("expected:\n%s\nactual:\n%s", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package compiler_semantic holds the semantics of policies shared by the rego and IR backends and
+// the in-process evaluator: the deferral of where clause conditions to obligations, the typing
+// of action properties and the matching of type patterns.
+package compiler_semantic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// WhereClause returns the bodies of the where clause and the conditions deferred to obligations,
+// e.g. "type:petstore.pet; ctx.age > 2". A nil body always holds.
+// See Deferral for the expansion of `or`.
+func WhereClause(swtype types.Type, cnd ast.Condition) ([]ast.Condition, []string, error) {
+	bodies, deferred, err := Deferral(swtype, cnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var obligations []string
+	for _, cnds := range deferred {
+		if len(cnds) == 0 {
+			continue
+		}
+		// conditions deferred to obligations are and-ed into a single entry
+		oblige := cnds[0].String()
+		if len(cnds) > 1 {
+			operands := []string{}
+			for _, c := range cnds {
+				operands = append(operands, c.String())
+			}
+			oblige = fmt.Sprintf("(%s)", strings.Join(operands, " and "))
+		}
+		obligations = append(obligations, fmt.Sprintf("type:%s; %s", swtype, oblige))
+	}
+	return bodies, obligations, nil
+}
+
+// Deferral returns the bodies of the where clause and, for each body, the conditions deferred
+// to obligations. A nil body always holds.
+// A where clause containing `or` is expanded into disjunctive normal form, one body per disjunct,
+// unless a disjunct touches an obligation property: the obligations of a statement are not tied
+// to the body that matched, so then the whole where clause is deferred as a single obligation.
+func Deferral(swtype types.Type, cnd ast.Condition) ([]ast.Condition, [][]ast.Condition, error) {
+	if types.IsNilInterface(cnd) {
+		return []ast.Condition{nil}, [][]ast.Condition{nil}, nil
+	}
+	wc, ok := cnd.(*ast.WhereClause)
+	if !ok {
+		return nil, nil, compiler_error.ErrUnknownWhereClause
+	}
+	if types.IsNilInterface(wc.Condition) {
+		return []ast.Condition{nil}, [][]ast.Condition{nil}, nil
+	}
+
+	disjuncts := []ast.Condition{wc.Condition}
+	if ast.ContainsOr(wc) {
+		deferred, err := HasObligations(swtype, wc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if deferred {
+			return []ast.Condition{nil}, [][]ast.Condition{{wc.Condition}}, nil
+		}
+		disjuncts = ast.DisjunctiveNormalForm(wc)
+	}
+
+	bodies := []ast.Condition{}
+	obligations := [][]ast.Condition{}
+	for _, disjunct := range disjuncts {
+		body, deferred, isObligation, err := Residual(swtype, disjunct)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isObligation {
+			body = nil
+			deferred = append(deferred, disjunct)
+		}
+		bodies = append(bodies, body)
+		obligations = append(obligations, deferred)
+	}
+	return bodies, obligations, nil
+}
+
+// Residual returns the condition without the operands of `and` touching obligation properties,
+// the deferred operands, and whether the remaining condition itself touches an obligation property.
+// `or` must be expanded before, see WhereClause.
+func Residual(swtype types.Type, cnd ast.Condition) (ast.Condition, []ast.Condition, bool, error) {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type != token.LITERAL && strings.HasPrefix(s.Value, "ctx.") {
+			isObligation, err := IsObligationProperty(swtype, s.Value)
+			return s, nil, isObligation, err
+		}
+		return s, nil, false, nil
+
+	case *ast.IntegerLiteral, *ast.ArrayLiteral, *ast.DataReference:
+		return cnd, nil, false, nil
+
+	case *ast.PrefixCondition:
+		if s.Token.Type != token.NOT {
+			return nil, nil, false, compiler_error.ErrUnknownCondition
+		}
+		right, obligations, isObligation, err := Residual(swtype, s.Right)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return &ast.PrefixCondition{Token: s.Token, Operator: s.Operator, Right: right}, obligations, isObligation, nil
+
+	case *ast.InfixCondition:
+		left, obligations, lhsIsObligation, err := Residual(swtype, s.Left)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		right, rhsObligations, rhsIsObligation, err := Residual(swtype, s.Right)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		obligations = append(obligations, rhsObligations...)
+
+		switch s.Token.Type {
+		case token.AND:
+			if lhsIsObligation {
+				left = nil
+				obligations = append(obligations, s.Left)
+			}
+			if rhsIsObligation {
+				right = nil
+				obligations = append(obligations, s.Right)
+			}
+			switch {
+			case types.IsNilInterface(left):
+				return right, obligations, false, nil
+			case types.IsNilInterface(right):
+				return left, obligations, false, nil
+			}
+		case token.OR:
+			return nil, nil, false, fmt.Errorf("OR operator must be expanded before compiling condition '%s'", s)
+		}
+		infix := &ast.InfixCondition{Token: s.Token, Left: left, Operator: s.Operator, Right: right}
+		return infix, obligations, lhsIsObligation || rhsIsObligation, nil
+	}
+
+	return nil, nil, false, compiler_error.ErrUnknownCondition
+}
+
+// IsObligationProperty checks that the property of the ctx identifier (e.g. ctx.tags["color"])
+// exists for the object-type and returns whether it is marked with x-seal-obligation.
+// If the object-type is unknown, the property is never an obligation.
+func IsObligationProperty(swtype types.Type, id string) (bool, error) {
+	if types.IsNilInterface(swtype) {
+		return false, nil
+	}
+
+	prop := lexer.SplitIdentifier(id).Field
+	pprop, ok := swtype.GetProperties()[prop]
+	if !ok {
+		return false, fmt.Errorf("Unknown property '%s' of type '%s'", prop, swtype)
+	}
+
+	xSealObligation, ok, err := pprop.GetExtensionProp("x-seal-obligation")
+	if err != nil {
+		return false, fmt.Errorf("type '%s': %s", swtype, err)
+	} else if !ok {
+		return false, nil
+	}
+
+	isObligation, err := strconv.ParseBool(xSealObligation)
+	if err != nil {
+		return false, fmt.Errorf("Bad bool value '%s' for property '%s' of type '%s'", xSealObligation, prop, swtype)
+	}
+	return isObligation, nil
+}
+
+// HasObligations returns true if any ctx property referenced by the condition is an obligation
+func HasObligations(swtype types.Type, cnd ast.Condition) (bool, error) {
+	for _, id := range cnd.GetTypes() {
+		if !strings.HasPrefix(id.Value, "ctx.") {
+			continue
+		}
+		isObligation, err := IsObligationProperty(swtype, id.Value)
+		if err != nil || isObligation {
+			return isObligation, err
+		}
+	}
+	return false, nil
+}
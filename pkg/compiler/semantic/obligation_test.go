@@ -0,0 +1,139 @@
+package compiler_semantic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/parser"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+const ticketSwagger = `
+openapi: "3.0.0"
+components:
+  schemas:
+    redirect:
+      type: object
+      properties:
+        priority:
+          type: integer
+        log:
+          type: boolean
+        to:
+          type: string
+      x-seal-type: action
+    support.ticket:
+      type: object
+      x-seal-actions: [ "allow", "redirect" ]
+      x-seal-verbs:
+        use: [ "get" ]
+      x-seal-default-action: allow
+      properties:
+        severity:
+          type: string
+        queue:
+          type: string
+          x-seal-obligation: true
+`
+
+func ticketType(t *testing.T) types.Type {
+	t.Helper()
+	tps, err := types.NewTypeFromOpenAPIv3([]byte(ticketSwagger))
+	if err != nil {
+		t.Fatalf("could not load swagger: %s", err)
+	}
+	for _, tp := range tps {
+		if tp.String() == "support.ticket" {
+			return tp
+		}
+	}
+	t.Fatalf("support.ticket not found")
+	return nil
+}
+
+func TestWhereClause(t *testing.T) {
+	swtype := ticketType(t)
+
+	tests := []struct {
+		name        string
+		where       string
+		bodies      []string
+		obligations []string
+		err         string
+	}{
+		{
+			name:   "without where clause",
+			bodies: []string{""},
+		},
+		{
+			name:   "without obligation",
+			where:  `ctx.severity == "low"`,
+			bodies: []string{`(ctx.severity == "low")`},
+		},
+		{
+			name:        "obligation operand of and",
+			where:       `ctx.severity == "low" and ctx.queue == "spam"`,
+			bodies:      []string{`(ctx.severity == "low")`},
+			obligations: []string{`type:support.ticket; (ctx.queue == "spam")`},
+		},
+		{
+			name:        "negated obligation",
+			where:       `not ctx.queue == "spam"`,
+			bodies:      []string{""},
+			obligations: []string{`type:support.ticket; (not(ctx.queue == "spam"))`},
+		},
+		{
+			name:   "or expanded into bodies",
+			where:  `ctx.severity == "low" or ctx.severity == "high"`,
+			bodies: []string{`(ctx.severity == "low")`, `(ctx.severity == "high")`},
+		},
+		{
+			name:        "or deferred as a whole",
+			where:       `ctx.severity == "low" or ctx.queue == "spam"`,
+			bodies:      []string{""},
+			obligations: []string{`type:support.ticket; ((ctx.severity == "low") or (ctx.queue == "spam"))`},
+		},
+		{
+			name:  "unknown property",
+			where: `ctx.owner == "bob"`,
+			err:   "Unknown property 'owner' of type 'support.ticket'",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			var where ast.Condition
+			if tst.where != "" {
+				cnd, err := parser.ParseCondition(tst.where)
+				if err != nil {
+					t.Fatalf("could not parse condition: %s", err)
+				}
+				where = &ast.WhereClause{Condition: cnd}
+			}
+
+			bodies, obligations, err := WhereClause(swtype, where)
+			if tst.err != "" || err != nil {
+				if err == nil || err.Error() != tst.err {
+					t.Fatalf("expected error %q, got %v", tst.err, err)
+				}
+				return
+			}
+
+			actual := []string{}
+			for _, body := range bodies {
+				if types.IsNilInterface(body) {
+					actual = append(actual, "")
+				} else {
+					actual = append(actual, body.String())
+				}
+			}
+			if !reflect.DeepEqual(actual, tst.bodies) {
+				t.Errorf("expected bodies %q, got %q", tst.bodies, actual)
+			}
+			if !reflect.DeepEqual(obligations, tst.obligations) {
+				t.Errorf("expected obligations %q, got %q", tst.obligations, obligations)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package compiler_semantic
+
+import "strings"
+
+// TypePattern returns the regular expression matching the types of the type pattern, e.g. petstore.* to petstore.*:
+// `*` matches any characters and, as with the rego re_match builtin, the expression is not anchored
+func TypePattern(pattern string) string {
+	re := strings.ReplaceAll(pattern, "*", ".*")
+	return strings.ReplaceAll(re, "..*", ".*")
+}
@@ -0,0 +1,32 @@
+package compiler_semantic
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTypePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"petstore.pet", "petstore.pet", true},
+		{"petstore.pet", "petstore.order", false},
+		{"petstore.*", "petstore.order", true},
+		{"*.pet", "petstore.pet", true},
+		{"*", "petstore.pet", true},
+		// not anchored, as the rego re_match builtin
+		{"petstore.pet", "petstore.pets", true},
+	}
+
+	for idx, tst := range tests {
+		re, err := regexp.Compile(TypePattern(tst.pattern))
+		if err != nil {
+			t.Fatalf("tst #%d: could not compile %s: %s", idx, TypePattern(tst.pattern), err)
+		}
+		if actual := re.MatchString(tst.name); actual != tst.expected {
+			t.Errorf("tst #%d: %s matches %s: expected %v, got %v", idx, tst.pattern, tst.name, tst.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package compiler_semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// ActionPropertyValue returns the value of the action property, typed by the action of the types
+// matching the type pattern of the statement: a bool, a json.Number for integers and numbers, or a string.
+// Data references are returned as is, their value is only known when they are resolved.
+func ActionPropertyValue(swaggerTypes []types.Type, typePattern, action string, prop *ast.ActionProperty) (interface{}, error) {
+	var propType string
+	if aprop, ok := types.MatchActionProperty(swaggerTypes, typePattern, action, prop.Name); ok {
+		propType = aprop.GetType()
+	}
+
+	switch v := prop.Value.(type) {
+	case *ast.Identifier:
+		switch propType {
+		case "boolean":
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("Bad bool value '%s' for property '%s' of action '%s'", v.Value, prop.Name, action)
+			}
+			return b, nil
+		case "integer", "number":
+			if _, err := strconv.ParseFloat(v.Value, 64); err != nil {
+				return nil, fmt.Errorf("Bad number value '%s' for property '%s' of action '%s'", v.Value, prop.Name, action)
+			}
+			return json.Number(v.Value), nil
+		}
+		return v.Value, nil
+	case *ast.IntegerLiteral:
+		if propType == "string" {
+			return v.Token.Literal, nil
+		}
+		return json.Number(v.Token.Literal), nil
+	case *ast.DataReference:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported value '%s' for property '%s' of action '%s'", prop.Value, prop.Name, action)
+}
@@ -0,0 +1,47 @@
+package compiler_semantic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/parser"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+func TestActionPropertyValue(t *testing.T) {
+	swaggerTypes := []types.Type{ticketType(t)}
+
+	p := parser.New(lexer.New(`
+redirect (priority="1", log="true", to="911") to use support.ticket;
+redirect (priority=2, to=$support.oncall) to use support.*;
+`), swaggerTypes)
+	pols := p.ParsePolicies()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("could not parse policies: %v", p.Errors())
+	}
+
+	expected := [][]interface{}{
+		{json.Number("1"), true, "911"},
+		{json.Number("2"), &ast.DataReference{}},
+	}
+	for idx, stmt := range pols.Statements {
+		st := stmt.(*ast.ActionStatement)
+		for pidx, prop := range st.Properties {
+			value, err := ActionPropertyValue(swaggerTypes, st.TypePattern.Value, "redirect", prop)
+			if err != nil {
+				t.Fatalf("stmt %d: unexpected error: %s", idx, err)
+			}
+			exp := expected[idx][pidx]
+			if _, ok := exp.(*ast.DataReference); ok {
+				if _, ok := value.(*ast.DataReference); !ok {
+					t.Errorf("stmt %d: expected data reference for %s, got %#v", idx, prop.Name, value)
+				}
+			} else if !reflect.DeepEqual(value, exp) {
+				t.Errorf("stmt %d: expected %#v for %s, got %#v", idx, exp, prop.Name, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package sqlcompiler
+
+import (
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+// const...
+const (
+	Language = "sql"
+)
+
+func init() {
+	compiler.Register(Language, New)
+}
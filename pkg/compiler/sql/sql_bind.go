@@ -0,0 +1,88 @@
+package sqlcompiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// SubjectClaim is a bind argument standing for a claim of the subject (e.g. sub, groups or email)
+// when policies are compiled with bind arguments but without subject, see ResolveArgs
+type SubjectClaim string
+
+// String satisfies fmt.Stringer interface
+func (sc SubjectClaim) String() string {
+	return types.SUBJECT + "." + string(sc)
+}
+
+// MarshalJSON encodes the claim as {"subject_claim": "<claim>"}
+func (sc SubjectClaim) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"subject_claim": string(sc)})
+}
+
+// bindMarker matches the markers of bind arguments in compiled conditions
+var bindMarker = regexp.MustCompile("\x00([0-9]+)\x00")
+
+// literal returns the inlined SQL literal, or a marker of the bind argument value while binding.
+// Markers are replaced by placeholders in bindPlaceholders once the condition is complete,
+// so the arguments are numbered in the order they appear in the condition.
+func (sqlc *SQLCompiler) literal(value interface{}, inlined string) string {
+	if !sqlc.bind {
+		return inlined
+	}
+	sqlc.args = append(sqlc.args, value)
+	return fmt.Sprintf("\x00%d\x00", len(sqlc.args)-1)
+}
+
+// bindPlaceholders replaces the markers of bind arguments in the condition
+// with the placeholders of the dialect and returns the arguments in placeholder order
+func (sqlc *SQLCompiler) bindPlaceholders(cond string) (string, []interface{}) {
+	args := []interface{}{}
+	cond = bindMarker.ReplaceAllStringFunc(cond, func(marker string) string {
+		idx, _ := strconv.Atoi(strings.Trim(marker, "\x00"))
+		args = append(args, sqlc.args[idx])
+		return sqlc.Dialect.Placeholder(len(args))
+	})
+	return cond, args
+}
+
+// ResolveArgs returns a copy of the bind arguments of a predicate with the SubjectClaim arguments
+// replaced by the claims of the subject. Subject groups are returned as []string for Postgres,
+// to be bound as an array (e.g. with pq.Array), and as a JSON array string for the other dialects.
+// Missing claims are returned as nil (SQL NULL) and never match.
+func (sqlc *SQLCompiler) ResolveArgs(args []interface{}, sub *Subject) ([]interface{}, error) {
+	resolved := make([]interface{}, len(args))
+	for i, arg := range args {
+		claim, ok := arg.(SubjectClaim)
+		if !ok {
+			resolved[i] = arg
+			continue
+		}
+		if sub == nil {
+			return nil, fmt.Errorf("No subject to resolve bind argument %s", claim)
+		}
+
+		value, found := subjectClaim(sub, string(claim))
+		if !found {
+			continue
+		}
+		if groups, ok := value.([]string); ok {
+			groups = append([]string{}, groups...)
+			if sqlc.Dialect == DialectPostgres {
+				resolved[i] = groups
+				continue
+			}
+			js, err := json.Marshal(groups)
+			if err != nil {
+				return nil, err
+			}
+			value = string(js)
+		}
+		resolved[i] = value
+	}
+	return resolved, nil
+}
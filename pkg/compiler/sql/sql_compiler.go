@@ -0,0 +1,381 @@
+package sqlcompiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mb0/glob"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/provider"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// SQL boolean constants
+const (
+	SQLTrue  = "TRUE"
+	SQLFalse = "FALSE"
+)
+
+// DefaultVerb is the base verb the predicates are compiled for by default: list filtering
+const DefaultVerb = "list"
+
+// Subject is the subject the SQL predicates are compiled for.
+// Its groups and claims are inlined into the predicates as SQL literals.
+type Subject struct {
+	User   string                 // sub claim, matched by `subject user ...`
+	Groups []string               // groups claim, matched by `subject group ...`
+	Claims map[string]interface{} // other claims, e.g. {"email": "bob@acme.com"}
+}
+
+// Predicate is the SQL WHERE predicate filtering the rows of a swagger type
+type Predicate struct {
+	Type  string        // swagger type, e.g. petstore.pet
+	Where string        // with placeholders if compiled with bind arguments
+	Args  []interface{} // bind arguments in placeholder order, SubjectClaim for subject claims
+}
+
+// New creates a new SQL compiler backend for the Postgres dialect with quoted identifiers,
+// as tables such as order and user are reserved words, and with bind arguments,
+// as the backend has no subject to inline
+func New() (compiler.Compiler, error) {
+	return NewSQLCompiler().WithDialect(DialectPostgres).WithQuotedIdentifiers(true).WithBindArgs(true), nil
+}
+
+// WithSubject specifies the subject whose groups and claims are inlined into the predicates.
+// Default is none, subject groups and claims are then bound to SubjectClaim bind arguments
+// with WithBindArgs, or read from settings with WithSubjectSettings, and cannot be compiled otherwise.
+func (sqlc *SQLCompiler) WithSubject(sub *Subject) *SQLCompiler {
+	sqlc.Subject = sub
+	return sqlc
+}
+
+// WithVerb specifies the base verb (e.g. list or get) or the seal verb (e.g. inspect)
+// the predicates are compiled for. Default is DefaultVerb.
+func (sqlc *SQLCompiler) WithVerb(verb string) *SQLCompiler {
+	sqlc.Verb = verb
+	return sqlc
+}
+
+// WithSubjectSettings specifies the prefix of the Postgres settings the subject claims are read from
+// instead of bound parameters, e.g. seal.subject. reads subject.email with current_setting('seal.subject.email', true).
+// List claims (e.g. seal.subject.groups) are Postgres arrays, e.g. '{admins,clerks}'. Default is none.
+func (sqlc *SQLCompiler) WithSubjectSettings(prefix string) *SQLCompiler {
+	sqlc.SubjectSettings = prefix
+	return sqlc
+}
+
+// Table returns the SQL table the swagger type maps to, e.g. pet for petstore.pet without TypeMapper
+func (sqlc *SQLCompiler) Table(swtype string) string {
+	table := lexer.SplitSwaggerType(swtype).Type
+	if tmpr, found := sqlc.typeMapper(swtype); found && tmpr.SQLTable != "*" {
+		table = tmpr.SQLTable
+	}
+	if sqlc.QuoteIDs {
+		table = sqlc.Dialect.QuoteIdentifier(table)
+	}
+	return table
+}
+
+// Compile compiles the policies into one SQL WHERE predicate per swagger type
+func (sqlc *SQLCompiler) Compile(pkgname string, pols *ast.Policies, swaggerTypes []types.Type) (string, error) {
+	preds, err := sqlc.CompilePredicates(pols, swaggerTypes)
+	if err != nil {
+		return "", err
+	}
+
+	compiled := []string{fmt.Sprintf("-- package %s", pkgname)}
+	for _, pred := range preds {
+		compiled = append(compiled, "", fmt.Sprintf("-- %s", pred.Type), pred.Where)
+		if len(pred.Args) > 0 {
+			args, err := json.Marshal(pred.Args)
+			if err != nil {
+				return "", err
+			}
+			compiled = append(compiled, fmt.Sprintf("-- args: %s", args))
+		}
+	}
+	return strings.Join(compiled, "\n"), nil
+}
+
+// CompilePredicates compiles the policies into one SQL WHERE predicate per swagger type.
+// A row is matched if the decision for it is allow: at least one allow rule matches
+// (or the default action of the type is allow) and no deny rule matches.
+func (sqlc *SQLCompiler) CompilePredicates(pols *ast.Policies, swaggerTypes []types.Type) ([]*Predicate, error) {
+	if pols == nil {
+		return nil, compiler_error.ErrEmptyPolicies
+	}
+
+	sqlc.policyMode, sqlc.bind = true, sqlc.BindArgs
+	defer func() { sqlc.policyMode, sqlc.bind, sqlc.args = false, false, nil }()
+
+	// linearize context statements, keeping the index of the statement for error reporting
+	stmts := []*ast.ActionStatement{}
+	stmtIdx := []int{}
+	for idx, stmt := range pols.Statements {
+		switch s := stmt.(type) {
+		case *ast.ActionStatement:
+			stmts = append(stmts, s)
+			stmtIdx = append(stmtIdx, idx)
+		case *ast.ContextStatement:
+			for _, act := range ast.LinearizeContext(s) {
+				stmts = append(stmts, act)
+				stmtIdx = append(stmtIdx, idx)
+			}
+		}
+	}
+
+	preds := []*Predicate{}
+	for _, swt := range swaggerTypes {
+		if len(swt.GetVerbs()) == 0 {
+			continue // not a resource type, e.g. the subject
+		}
+
+		sqlc.args = nil
+		allow, deny := []string{}, []string{}
+		for i, stmt := range stmts {
+			if stmt.Verb == nil || stmt.TypePattern == nil || !sqlc.isApplicable(swt, stmt) {
+				continue
+			}
+			action := stmt.Token.Literal
+			if action != "allow" && action != "deny" {
+				continue // custom actions do not filter rows
+			}
+
+			pred, err := sqlc.compileRule(swt.String(), stmt)
+			if err != nil {
+				return nil, compiler_error.New(err, stmtIdx[i], stmt.String()).WithPos(stmt.Pos())
+			}
+			if pred == SQLFalse {
+				continue
+			}
+			if action == "allow" {
+				allow = append(allow, pred)
+			} else {
+				deny = append(deny, pred)
+			}
+		}
+
+		allowPred := sqlOr(allow)
+		if swt.DefaultAction() == "allow" {
+			allowPred = SQLTrue
+		}
+		// a deny rule comparing a NULL column does not fire, NOT would filter the row out
+		where := sqlAnd([]string{allowPred, sqlc.notTrue(sqlOr(deny))})
+		switch where {
+		case SQLTrue, SQLFalse:
+			where = sqlc.Dialect.BoolLiteral(where == SQLTrue)
+		}
+		pred := &Predicate{Type: swt.String(), Where: where}
+		if sqlc.bind {
+			pred.Where, pred.Args = sqlc.bindPlaceholders(where)
+		}
+		preds = append(preds, pred)
+	}
+
+	return preds, nil
+}
+
+// isApplicable returns true if the statement applies to the swagger type for the (base) verb of the compiler.
+// Statements with a type pattern matching several types only apply to the types having
+// all the properties referenced by their where clause.
+func (sqlc *SQLCompiler) isApplicable(swt types.Type, stmt *ast.ActionStatement) bool {
+	if m, err := glob.Match(stmt.TypePattern.Value, swt.String()); err != nil || !m {
+		return false
+	}
+
+	if !types.IsNilInterface(stmt.WhereClause) {
+		for _, id := range stmt.WhereClause.GetTypes() {
+			if strings.HasPrefix(id.Value, "ctx.") && !types.IsValidProperty(swt, id.Value) && !types.IsValidTag(swt, id.Value) {
+				return false
+			}
+		}
+	}
+
+	verb := sqlc.Verb
+	if verb == "" {
+		verb = DefaultVerb
+	}
+	for _, vrb := range swt.GetVerbs() {
+		if vrb.GetName() != stmt.Verb.Value {
+			continue
+		}
+		if vrb.GetName() == verb {
+			return true
+		}
+		for _, bv := range vrb.GetBaseVerbs() {
+			if bv == verb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileRule compiles the subject and where clause of the statement into a single predicate
+func (sqlc *SQLCompiler) compileRule(swtype string, stmt *ast.ActionStatement) (string, error) {
+	preds := []string{}
+
+	if !types.IsNilInterface(stmt.Subject) {
+		sub, err := sqlc.compileSubject(stmt.Subject)
+		if err != nil {
+			return "", err
+		}
+		preds = append(preds, sub)
+	}
+
+	if !types.IsNilInterface(stmt.WhereClause) {
+		wc, ok := stmt.WhereClause.(*ast.WhereClause)
+		if !ok {
+			return "", compiler_error.ErrUnknownWhereClause
+		}
+		if !types.IsNilInterface(wc.Condition) {
+			where, err := sqlc.astConditionToSQL(0, swtype, wc.Condition)
+			if err != nil {
+				return "", err
+			}
+			preds = append(preds, where)
+		}
+	}
+
+	return sqlAnd(preds), nil
+}
+
+// compileSubject compiles the subject of a statement, inlined if the subject of the compiler is known
+func (sqlc *SQLCompiler) compileSubject(sub ast.Subject) (string, error) {
+	switch s := sub.(type) {
+	case *ast.SubjectGroup:
+		if sqlc.Subject != nil {
+			for _, grp := range sqlc.Subject.Groups {
+				if grp == s.Group {
+					return SQLTrue, nil
+				}
+			}
+			return SQLFalse, nil
+		}
+		groups, err := sqlc.subjectParameter("groups", true)
+		if err != nil {
+			return "", err
+		}
+		return sqlc.Dialect.ListContains(groups, sqlc.literal(s.Group, sqlc.Dialect.StringLiteral(s.Group)))
+
+	case *ast.SubjectUser:
+		if sqlc.Subject != nil {
+			if sqlc.Subject.User == s.User {
+				return SQLTrue, nil
+			}
+			return SQLFalse, nil
+		}
+		user, err := sqlc.subjectParameter("sub", false)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s = %s)", user, sqlc.literal(s.User, sqlc.Dialect.StringLiteral(s.User))), nil
+	}
+
+	return "", compiler_error.ErrInvalidSubject
+}
+
+// subjectClaimToSQL converts the subject claim (without the "subject." prefix) into an SQL literal
+// if the subject of the compiler is known, see subjectParameter otherwise
+func (sqlc *SQLCompiler) subjectClaimToSQL(claim string) (string, error) {
+	if sqlc.Subject == nil {
+		return sqlc.subjectParameter(claim, false)
+	}
+
+	if claim == "groups" {
+		return "", fmt.Errorf("Cannot SQL-convert the list of subject groups: subject.%s", claim)
+	}
+	value, found := subjectClaim(sqlc.Subject, claim)
+	if !found {
+		return "NULL", nil // a missing claim never matches
+	}
+	return sqlc.valueToLiteral(value, types.SUBJECT+"."+claim)
+}
+
+// subjectClaim returns the claim (without the "subject." prefix) of the subject, false if it is missing
+func subjectClaim(sub *Subject, claim string) (interface{}, bool) {
+	switch claim {
+	case "sub":
+		return sub.User, true
+	case "groups":
+		return sub.Groups, true
+	}
+	value, err := provider.Document(sub.Claims).Resolve(claim, "")
+	return value, err == nil
+}
+
+// subjectParameter returns the SubjectClaim bind argument of the subject claim while compiling with
+// bind arguments, or the setting holding the claim with SubjectSettings.
+// list is true if the claim is used as a list, e.g. subject groups.
+// Without subject, the claim can only be inlined as one of them.
+func (sqlc *SQLCompiler) subjectParameter(claim string, list bool) (string, error) {
+	if sqlc.SubjectSettings != "" {
+		setting := fmt.Sprintf("current_setting(%s, true)", sqlc.Dialect.StringLiteral(sqlc.SubjectSettings+claim))
+		if list {
+			setting += "::text[]"
+		}
+		return setting, nil
+	}
+	if !sqlc.bind {
+		return "", fmt.Errorf("Cannot SQL-convert subject.%s without subject: compile with a subject, bind arguments or subject settings", claim)
+	}
+	return sqlc.literal(SubjectClaim(claim), ""), nil
+}
+
+// sqlAnd returns the conjunction of the predicates, simplifying constants
+func sqlAnd(preds []string) string {
+	out := []string{}
+	for _, pred := range preds {
+		switch pred {
+		case SQLTrue:
+			continue
+		case SQLFalse:
+			return SQLFalse
+		}
+		out = append(out, pred)
+	}
+	switch len(out) {
+	case 0:
+		return SQLTrue
+	case 1:
+		return out[0]
+	}
+	return "(" + strings.Join(out, " AND ") + ")"
+}
+
+// sqlOr returns the disjunction of the predicates, simplifying constants
+func sqlOr(preds []string) string {
+	out := []string{}
+	for _, pred := range preds {
+		switch pred {
+		case SQLTrue:
+			return SQLTrue
+		case SQLFalse:
+			continue
+		}
+		out = append(out, pred)
+	}
+	switch len(out) {
+	case 0:
+		return SQLFalse
+	case 1:
+		return out[0]
+	}
+	return "(" + strings.Join(out, " OR ") + ")"
+}
+
+// notTrue returns the predicate holding unless pred holds, simplifying constants
+func (sqlc *SQLCompiler) notTrue(pred string) string {
+	switch pred {
+	case SQLTrue:
+		return SQLFalse
+	case SQLFalse:
+		return SQLTrue
+	}
+	return sqlc.Dialect.NotTrue(pred)
+}
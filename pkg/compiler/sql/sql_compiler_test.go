@@ -0,0 +1,297 @@
+package sqlcompiler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/compiler"
+)
+
+const sqlSwagger = `
+openapi: "3.0.0"
+info:
+  title: SQL
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    shop.order:
+      type: object
+      properties:
+        status:
+          type: string
+        total:
+          type: integer
+        owner:
+          type: string
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        inspect:
+        - list
+        - get
+        manage:
+        - create
+        - delete
+      x-seal-default-action: deny
+    shop.item:
+      type: object
+      properties:
+        visibility:
+          type: string
+          nullable: true
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        inspect:
+        - list
+      x-seal-default-action: allow
+    subject:
+      type: object
+      properties:
+        email:
+          type: string
+        groups:
+          type: array
+          items:
+            type: string
+      x-seal-type: none
+`
+
+const sqlPolicies = `
+allow subject group clerks to inspect shop.order where ctx.status == "open";
+allow subject user boss to inspect shop.order;
+allow subject group everyone to inspect shop.order where ctx.owner == subject.email;
+deny to inspect shop.* where ctx.total > 1000 and not ctx.status == "audited";
+deny subject group clerks to manage shop.order;
+context {
+    subject group interns;
+} to inspect shop.item {
+    deny where ctx.visibility == "hidden";
+}
+`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  SQLDialectEnum // DialectPostgres if unset
+		unquoted bool           // identifiers are quoted by default
+		subject  *Subject
+		verb     string
+		bind     bool
+		expected string
+		err      string
+	}{
+		{
+			name: "subject neither inlined nor bound",
+			err:  "Cannot SQL-convert subject.groups without subject: compile with a subject, bind arguments or subject settings",
+		},
+		{
+			name:     "inlined subject",
+			unquoted: true,
+			subject:  &Subject{User: "alice", Groups: []string{"clerks", "everyone"}, Claims: map[string]interface{}{"email": "alice@shop.com"}},
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+(((order.status = 'open') OR (order.owner = 'alice@shop.com')) AND ((order.total > 1000 AND (NOT (order.status = 'audited'))) IS NOT TRUE))`,
+		},
+		{
+			name:     "inlined subject matching allow rule without condition",
+			unquoted: true,
+			subject:  &Subject{User: "boss", Groups: []string{"interns"}},
+			expected: `-- package shop
+
+-- shop.item
+((item.visibility = 'hidden') IS NOT TRUE)
+
+-- shop.order
+((order.total > 1000 AND (NOT (order.status = 'audited'))) IS NOT TRUE)`,
+		},
+		{
+			name:     "missing claim never matches",
+			unquoted: true,
+			subject:  &Subject{User: "bob", Groups: []string{"everyone"}},
+			verb:     "get",
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+((order.owner = NULL) AND ((order.total > 1000 AND (NOT (order.status = 'audited'))) IS NOT TRUE))`,
+		},
+		{
+			name:    "verb without rules",
+			subject: &Subject{User: "bob", Groups: []string{"clerks"}},
+			verb:    "delete",
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+FALSE`,
+		},
+		{
+			name:    "mysql bind arguments with quoted identifiers",
+			dialect: DialectMySQL,
+			bind:    true,
+			expected: `-- package shop
+
+-- shop.item
+((JSON_CONTAINS(?, JSON_QUOTE(?)) AND (` + "`item`.`visibility`" + ` = ?)) IS NOT TRUE)
+-- args: [{"subject_claim":"groups"},"interns","hidden"]
+
+-- shop.order
+(((JSON_CONTAINS(?, JSON_QUOTE(?)) AND (` + "`order`.`status`" + ` = ?)) OR (? = ?) OR (JSON_CONTAINS(?, JSON_QUOTE(?)) AND (` + "`order`.`owner`" + ` = ?))) AND ((` + "`order`.`total`" + ` > ? AND (NOT (` + "`order`.`status`" + ` = ?))) IS NOT TRUE))
+-- args: [{"subject_claim":"groups"},"clerks","open",{"subject_claim":"sub"},"boss",{"subject_claim":"groups"},"everyone",{"subject_claim":"email"},1000,"audited"]`,
+		},
+		{
+			name:    "sqlite bind arguments",
+			dialect: DialectSQLite,
+			bind:    true,
+			verb:    "get",
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+((((? IN (SELECT value FROM json_each(?))) AND ("order"."status" = ?)) OR (? = ?) OR ((? IN (SELECT value FROM json_each(?))) AND ("order"."owner" = ?))) AND (("order"."total" > ? AND (NOT ("order"."status" = ?))) IS NOT TRUE))
+-- args: ["clerks",{"subject_claim":"groups"},"open",{"subject_claim":"sub"},"boss","everyone",{"subject_claim":"groups"},{"subject_claim":"email"},1000,"audited"]`,
+		},
+		{
+			name:    "seal verb",
+			subject: &Subject{User: "bob", Groups: []string{"clerks"}},
+			verb:    "manage",
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+FALSE`,
+		},
+		{
+			name:    "sqlserver inlined subject",
+			dialect: DialectSQLServer,
+			subject: &Subject{User: "bob", Groups: []string{"clerks"}},
+			verb:    "delete",
+			expected: `-- package shop
+
+-- shop.item
+(1 = 1)
+
+-- shop.order
+(1 = 0)`,
+		},
+		{
+			// a deny rule does not fire on items without visibility (NULL) as in rego,
+			// NOT would filter them out
+			name:    "sqlserver deny of a nullable column",
+			dialect: DialectSQLServer,
+			subject: &Subject{User: "bob", Groups: []string{"interns"}},
+			expected: `-- package shop
+
+-- shop.item
+(CASE WHEN ([item].[visibility] = 'hidden') THEN 0 ELSE 1 END = 1)
+
+-- shop.order
+(1 = 0)`,
+		},
+		{
+			name:     "postgres bind arguments",
+			unquoted: true,
+			bind:     true,
+			expected: `-- package shop
+
+-- shop.item
+((($1 = ANY($2)) AND (item.visibility = $3)) IS NOT TRUE)
+-- args: ["interns",{"subject_claim":"groups"},"hidden"]
+
+-- shop.order
+(((($1 = ANY($2)) AND (order.status = $3)) OR ($4 = $5) OR (($6 = ANY($7)) AND (order.owner = $8))) AND ((order.total > $9 AND (NOT (order.status = $10))) IS NOT TRUE))
+-- args: ["clerks",{"subject_claim":"groups"},"open",{"subject_claim":"sub"},"boss","everyone",{"subject_claim":"groups"},{"subject_claim":"email"},1000,"audited"]`,
+		},
+		{
+			name:     "mysql bind arguments with subject",
+			unquoted: true,
+			dialect:  DialectMySQL,
+			subject:  &Subject{User: "bob", Groups: []string{"everyone"}, Claims: map[string]interface{}{"email": "bob@acme.com"}},
+			bind:     true,
+			expected: `-- package shop
+
+-- shop.item
+TRUE
+
+-- shop.order
+((order.owner = ?) AND ((order.total > ? AND (NOT (order.status = ?))) IS NOT TRUE))
+-- args: ["bob@acme.com",1000,"audited"]`,
+		},
+	}
+
+	for _, tst := range tests {
+		cplr, err := compiler.NewPolicyCompiler(Language, sqlSwagger)
+		if err != nil {
+			t.Fatalf("could not create policy compiler: %s", err)
+		}
+		sqlc := cplr.BackendCompiler().(*SQLCompiler).WithSubject(tst.subject).WithVerb(tst.verb).WithBindArgs(tst.bind)
+		if tst.unquoted {
+			sqlc.WithQuotedIdentifiers(false)
+		}
+		if tst.dialect != DialectUnknown {
+			sqlc.WithDialect(tst.dialect)
+		}
+
+		actual, err := cplr.Compile("shop", sqlPolicies)
+		if tst.err != "" || err != nil {
+			if err == nil || !strings.Contains(err.Error(), tst.err) {
+				t.Errorf("%s: expected error %q, got %v", tst.name, tst.err, err)
+			}
+			continue
+		}
+		if actual != tst.expected {
+			t.Errorf("%s:\nexpected:\n%s\nactual:\n%s", tst.name, tst.expected, actual)
+		}
+	}
+}
+
+func TestResolveArgs(t *testing.T) {
+	sub := &Subject{User: "bob", Groups: []string{"clerks"}, Claims: map[string]interface{}{"email": "bob@acme.com"}}
+	args := []interface{}{"open", SubjectClaim("groups"), SubjectClaim("sub"), SubjectClaim("email"), SubjectClaim("phone")}
+
+	tests := []struct {
+		dialect  SQLDialectEnum
+		expected []interface{}
+	}{
+		{
+			dialect:  DialectPostgres,
+			expected: []interface{}{"open", []string{"clerks"}, "bob", "bob@acme.com", nil},
+		},
+		{
+			dialect:  DialectMySQL,
+			expected: []interface{}{"open", `["clerks"]`, "bob", "bob@acme.com", nil},
+		},
+	}
+
+	for _, tst := range tests {
+		actual, err := NewSQLCompiler().WithDialect(tst.dialect).ResolveArgs(args, sub)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tst.dialect, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, tst.expected) {
+			t.Errorf("%s: expected %#v, actual %#v", tst.dialect, tst.expected, actual)
+		}
+	}
+
+	if _, err := NewSQLCompiler().ResolveArgs(args, nil); err == nil {
+		t.Errorf("expected error resolving subject claims without subject")
+	}
+}
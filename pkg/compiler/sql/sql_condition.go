@@ -15,6 +15,7 @@ import (
 	"github.com/infobloxopen/seal/pkg/ast"
 	"github.com/infobloxopen/seal/pkg/lexer"
 	"github.com/infobloxopen/seal/pkg/parser"
+	"github.com/infobloxopen/seal/pkg/provider"
 	"github.com/infobloxopen/seal/pkg/token"
 	"github.com/infobloxopen/seal/pkg/types"
 )
@@ -26,9 +27,25 @@ var SQLStringLiteralReplacer = strings.NewReplacer(
 
 // SQLCompiler contains SQL conversion parameters
 type SQLCompiler struct {
-	Logger      *logrus.Logger
-	Dialect     SQLDialectEnum
-	TypeMappers map[string]*TypeMapper
+	Logger       *logrus.Logger
+	Dialect      SQLDialectEnum
+	TypeMappers  map[string]*TypeMapper
+	DataProvider provider.Provider
+	Subject      *Subject // subject inlined into compiled policies, nil to bind subject parameters
+	QuoteIDs     bool     // quote table and column names with the quoting of the dialect
+	Verb         string   // base verb or seal verb policies are compiled for, empty for DefaultVerb
+	BindArgs     bool     // compile policies into predicates with placeholders and bind arguments
+	// SubjectSettings is the prefix of the Postgres settings holding the subject claims, e.g. seal.subject.
+	// for current_setting('seal.subject.email'), empty to bind subject claims to parameters
+	SubjectSettings string
+
+	// policyMode is set while compiling policies: subject claims are compiled
+	// with subjectClaimToSQL and types without a TypeMapper get a default mapping
+	policyMode bool
+
+	// bind is set while compiling with bind arguments: literals are collected into args
+	bind bool
+	args []interface{}
 }
 
 // NewSQLCompiler returns new instance of SQLCompiler.
@@ -55,6 +72,20 @@ func (sqlc *SQLCompiler) WithDialect(dialect SQLDialectEnum) *SQLCompiler {
 	return sqlc
 }
 
+// WithQuotedIdentifiers specifies whether table and column names are quoted,
+// e.g. "order" for Postgres and `order` for MySQL. Default is false.
+func (sqlc *SQLCompiler) WithQuotedIdentifiers(quote bool) *SQLCompiler {
+	sqlc.QuoteIDs = quote
+	return sqlc
+}
+
+// WithBindArgs specifies whether compiled policies use placeholders ($1 for Postgres, @p1 for
+// SQL Server, ? otherwise) with bind arguments instead of inlined literals. Default is false.
+func (sqlc *SQLCompiler) WithBindArgs(bind bool) *SQLCompiler {
+	sqlc.BindArgs = bind
+	return sqlc
+}
+
 // WithTypeMapper adds TypeMapper to this compiler.
 // TypeMapper must be name-unique within compiler.
 // When adding multiple TypeMapper with the same name, the most recent add wins.
@@ -64,9 +95,35 @@ func (sqlc *SQLCompiler) WithTypeMapper(tmpr *TypeMapper) *SQLCompiler {
 	return sqlc
 }
 
+// WithDataProvider specifies the provider resolving external data references (`$name["key"]`).
+// Default is none, conditions with data references fail to compile.
+func (sqlc *SQLCompiler) WithDataProvider(dp provider.Provider) *SQLCompiler {
+	sqlc.DataProvider = dp
+	return sqlc
+}
+
 // CompileCondition compiles the given SEAL annotated condition string into an SQL condition string.
 // Internally calls ReplaceIdentifier to perform type and property SQL mapping on SEAL identifiers.
 func (sqlc *SQLCompiler) CompileCondition(annotatedCondition string) (string, error) {
+	return sqlc.compileCondition(annotatedCondition)
+}
+
+// CompileConditionArgs compiles the given SEAL annotated condition string into an SQL condition string
+// with placeholders, e.g. `(pet.name = $1)` for Postgres and `(pet.name = ?)` for MySQL,
+// and returns the bind arguments in placeholder order, ready to be passed to database/sql.
+func (sqlc *SQLCompiler) CompileConditionArgs(annotatedCondition string) (string, []interface{}, error) {
+	sqlc.bind, sqlc.args = true, nil
+	defer func() { sqlc.bind, sqlc.args = false, nil }()
+
+	where, err := sqlc.compileCondition(annotatedCondition)
+	if err != nil {
+		return "", nil, err
+	}
+	where, args := sqlc.bindPlaceholders(where)
+	return where, args, nil
+}
+
+func (sqlc *SQLCompiler) compileCondition(annotatedCondition string) (string, error) {
 	logger := sqlc.Logger.WithField("method", "CompileCondition")
 
 	// Extract type annotation and SEAL condition string
@@ -111,8 +168,8 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 			//   Escape any single-quotes
 			//   Replace begin/end double-quotes with single-quotes
 			if strings.HasPrefix(literal, `"`) && strings.HasSuffix(literal, `"`) {
-				literal = SQLStringLiteralReplacer.Replace(literal[1 : len(literal)-1])
-				literal = `'` + literal + `'`
+				value := literal[1 : len(literal)-1]
+				literal = sqlc.literal(value, sqlc.Dialect.StringLiteral(value))
 			} else {
 				// Unquoted string literals should never happen as they are invalid SEAL,
 				// but SQL doesn't support unquoted literals, so we'll return error
@@ -123,13 +180,18 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 			return literal, nil
 		}
 
+		if sqlc.policyMode && strings.HasPrefix(s.Token.Literal, types.SUBJECT+".") {
+			return sqlc.subjectClaimToSQL(strings.TrimPrefix(s.Token.Literal, types.SUBJECT+"."))
+		}
+
 		// Map type/property of identifier into SQL table/column
 		id, err := sqlc.ReplaceIdentifier(swtype, s.Token.Literal)
 		if err != nil {
 			return "", err
 		}
 
-		if lexer.IsIndexedIdentifier(id) {
+		// mapped identifiers may contain JSON paths, only unmapped identifiers cannot be indexed
+		if id == s.Token.Literal && lexer.IsIndexedIdentifier(id) {
 			return "", fmt.Errorf("Do not know how to SQL-convert indexed-identifier: %s", id)
 		}
 
@@ -137,12 +199,14 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 		return id, nil
 
 	case *ast.IntegerLiteral:
-		literal := s.Token.Literal
-		return literal, nil
+		return sqlc.literal(s.Value, s.Token.Literal), nil
 
 	case *ast.ArrayLiteral:
 		return sqlc.astArrayLiteralToSQL(s)
 
+	case *ast.DataReference:
+		return sqlc.astDataReferenceToSQL(s)
+
 	case *ast.PrefixCondition:
 		rhs, err := sqlc.astConditionToSQL(lvl+1, swtype, s.Right)
 		if err != nil {
@@ -151,6 +215,9 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 
 		switch s.Token.Type {
 		case token.NOT:
+			if in, ok := s.Right.(*ast.InfixCondition); ok && in.Token.Type == token.OP_IN {
+				return sqlc.astInToSQL(lvl+1, swtype, in, true)
+			}
 			return fmt.Sprintf("(NOT %s)", rhs), nil
 		}
 
@@ -158,6 +225,10 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 		return fmt.Sprintf("(%s %s)", s.Token.Literal, rhs), nil
 
 	case *ast.InfixCondition:
+		if s.Token.Type == token.OP_IN {
+			return sqlc.astInToSQL(lvl, swtype, s, false)
+		}
+
 		lhs, err := sqlc.astConditionToSQL(lvl+1, swtype, s.Left)
 		if err != nil {
 			return "", err
@@ -177,18 +248,8 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 		case token.OP_EQUAL_TO:
 			result = fmt.Sprintf("(%s = %s)", lhs, rhs)
 		case token.OP_MATCH:
-			if sqlc.Dialect != DialectPostgres {
-				return "", fmt.Errorf("SQL dialect %s does not know how to convert regexp-match: %s %s %s",
-					sqlc.Dialect, s.Left, token.OP_MATCH, s.Right)
-			}
-			result = fmt.Sprintf("(%s ~ %s)", lhs, rhs)
-		case token.OP_IN:
-			if _, ok := s.Right.(*ast.ArrayLiteral); ok {
-				result = fmt.Sprintf("(%s IN %s)", lhs, rhs)
-			} else {
-				// TODO: maybe seal: `"boss" in subject.groups`
-				// would compile into sql: `('boss' IN (SELECT groups FROM subject))`
-				return "", fmt.Errorf("SQL-conversion of IN operator not supported yet: %s", o)
+			if result, err = sqlc.Dialect.RegexpMatch(lhs, rhs); err != nil {
+				return "", fmt.Errorf("%s: %s %s %s", err, s.Left, token.OP_MATCH, s.Right)
 			}
 		default:
 			result = fmt.Sprintf("%s %s %s", lhs, s.Token.Literal, rhs)
@@ -202,6 +263,91 @@ func (sqlc *SQLCompiler) astConditionToSQL(lvl int, swtype string, o ast.Conditi
 	}
 }
 
+// astInToSQL compiles `lhs in rhs` (`lhs NOT IN rhs` if negated, for `not lhs in rhs`).
+// rhs is an array literal, a data reference, a subject claim (e.g. subject.groups) resolved from
+// the subject of the compiler or bound to a parameter, or an array column (`= ANY(column)` for Postgres).
+func (sqlc *SQLCompiler) astInToSQL(lvl int, swtype string, in *ast.InfixCondition, negate bool) (string, error) {
+	lhs, err := sqlc.astConditionToSQL(lvl+1, swtype, in.Left)
+	if err != nil {
+		return "", err
+	}
+
+	switch rhs := in.Right.(type) {
+	case *ast.ArrayLiteral:
+		list, err := sqlc.astArrayLiteralToSQL(rhs)
+		if err != nil {
+			return "", err
+		}
+		return sqlInList(lhs, list, negate), nil
+
+	case *ast.DataReference:
+		value, err := sqlc.resolveDataReference(rhs)
+		if err != nil {
+			return "", err
+		}
+		list, isList, err := sqlc.valueToSQLList(value, rhs.String())
+		if err != nil {
+			return "", err
+		} else if !isList {
+			return "", fmt.Errorf("Cannot SQL-convert IN operator, %s is not a list: %s", rhs, in)
+		}
+		return sqlInList(lhs, list, negate), nil
+
+	case *ast.Identifier:
+		if rhs.Token.Type == token.LITERAL {
+			break
+		}
+
+		var list string
+		if strings.HasPrefix(rhs.Token.Literal, types.SUBJECT+".") {
+			claim := strings.TrimPrefix(rhs.Token.Literal, types.SUBJECT+".")
+			if sqlc.Subject != nil {
+				value, found := subjectClaim(sqlc.Subject, claim)
+				if !found {
+					value = []interface{}{} // a missing claim contains nothing
+				}
+				list, isList, err := sqlc.valueToSQLList(value, rhs.Token.Literal)
+				if err != nil {
+					return "", err
+				} else if !isList {
+					return "", fmt.Errorf("Cannot SQL-convert IN operator, %s is not a list: %s", rhs, in)
+				}
+				return sqlInList(lhs, list, negate), nil
+			}
+			if list, err = sqlc.subjectParameter(claim, true); err != nil {
+				return "", err
+			}
+		} else if list, err = sqlc.astConditionToSQL(lvl+1, swtype, rhs); err != nil {
+			return "", err
+		}
+
+		result, err := sqlc.Dialect.ListContains(list, lhs)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", err, in)
+		}
+		if negate {
+			result = fmt.Sprintf("(NOT %s)", result)
+		}
+		return result, nil
+	}
+
+	return "", fmt.Errorf("SQL-conversion of IN operator not supported: %s", in)
+}
+
+// sqlInList returns the IN (or NOT IN) condition of the SQL list of literals
+func sqlInList(lhs, list string, negate bool) string {
+	switch {
+	case list == "()" && negate:
+		return "(1 = 1)"
+	case list == "()":
+		// SQL does not allow empty IN-lists, nothing is in an empty list
+		return "(1 = 0)"
+	case negate:
+		return fmt.Sprintf("(%s NOT IN %s)", lhs, list)
+	}
+	return fmt.Sprintf("(%s IN %s)", lhs, list)
+}
+
 func (sqlc *SQLCompiler) astArrayLiteralToSQL(arrLit *ast.ArrayLiteral) (string, error) {
 	//logger := sqlc.Logger.WithField("method", "astArrayLiteralToSQL").WithField("arrLit", arrLit.String())
 	var bldr strings.Builder
@@ -213,8 +359,10 @@ func (sqlc *SQLCompiler) astArrayLiteralToSQL(arrLit *ast.ArrayLiteral) (string,
 		}
 		literal := it.String()
 		if strings.HasPrefix(literal, `"`) && strings.HasSuffix(literal, `"`) {
-			literal = SQLStringLiteralReplacer.Replace(literal[1 : len(literal)-1])
-			literal = `'` + literal + `'`
+			value := literal[1 : len(literal)-1]
+			literal = sqlc.literal(value, sqlc.Dialect.StringLiteral(value))
+		} else if il, ok := it.(*ast.IntegerLiteral); ok {
+			literal = sqlc.literal(il.Value, literal)
 		}
 		bldr.WriteString(literal)
 		notEmpty = true
@@ -223,6 +371,75 @@ func (sqlc *SQLCompiler) astArrayLiteralToSQL(arrLit *ast.ArrayLiteral) (string,
 	return bldr.String(), nil
 }
 
+// astDataReferenceToSQL resolves the data reference with the DataProvider and converts the value
+// into an SQL literal, or an SQL list of literals if the value is a list
+func (sqlc *SQLCompiler) astDataReferenceToSQL(ref *ast.DataReference) (string, error) {
+	value, err := sqlc.resolveDataReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	list, isList, err := sqlc.valueToSQLList(value, ref.String())
+	if err != nil || isList {
+		return list, err
+	}
+	return sqlc.valueToLiteral(value, ref.String())
+}
+
+// resolveDataReference resolves the value of the data reference with the DataProvider
+func (sqlc *SQLCompiler) resolveDataReference(ref *ast.DataReference) (interface{}, error) {
+	if sqlc.DataProvider == nil {
+		return nil, fmt.Errorf("No data provider to resolve data reference: %s", ref)
+	}
+
+	value, err := sqlc.DataProvider.Resolve(ref.Name, ref.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot resolve data reference %s: %s", ref, err)
+	}
+	return value, nil
+}
+
+// valueToSQLList converts a resolved list value into an SQL list of literals,
+// it returns false if the value is not a list
+func (sqlc *SQLCompiler) valueToSQLList(value interface{}, source string) (string, bool, error) {
+	items, isList := value.([]interface{})
+	if !isList {
+		if strs, ok := value.([]string); ok {
+			isList = true
+			for _, str := range strs {
+				items = append(items, str)
+			}
+		}
+	}
+	if !isList {
+		return "", false, nil
+	}
+
+	literals := make([]string, 0, len(items))
+	for _, it := range items {
+		literal, err := sqlc.valueToLiteral(it, source)
+		if err != nil {
+			return "", true, err
+		}
+		literals = append(literals, literal)
+	}
+	return "(" + strings.Join(literals, ",") + ")", true, nil
+}
+
+// valueToLiteral converts a resolved scalar value into an SQL literal,
+// source names the value in error messages
+func (sqlc *SQLCompiler) valueToLiteral(value interface{}, source string) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return sqlc.literal(v, sqlc.Dialect.StringLiteral(v)), nil
+	case bool:
+		return sqlc.literal(v, sqlc.Dialect.BoolLiteral(v)), nil
+	case int, int32, int64, uint, uint32, uint64, float32, float64:
+		return sqlc.literal(v, fmt.Sprintf("%v", v)), nil
+	}
+	return "", fmt.Errorf("Cannot SQL-convert value %#v of %s", value, source)
+}
+
 // ReplaceIdentifier performs type and property SQL mapping on the given SEAL identifier "id".
 // "swtype" is the swagger type for this identifier.
 // If there is no mapping that matches "swtype" or "id", then original "id" is returned with nil error.
@@ -234,12 +451,7 @@ func (sqlc *SQLCompiler) astArrayLiteralToSQL(arrLit *ast.ArrayLiteral) (string,
 // For example, PropertyMapper("tags") will match id "ctx.tags".
 // PropertyMapper("*") will also match id "ctx.tags" if there is no PropertyMapper("tags").
 func (sqlc *SQLCompiler) ReplaceIdentifier(swtype, id string) (string, error) {
-	tmpr, foundType := sqlc.TypeMappers[swtype]
-	if !foundType {
-		swParts := lexer.SplitSwaggerType(swtype)
-		swParts.Type = "*"
-		tmpr, foundType = sqlc.TypeMappers[swParts.String()]
-	}
+	tmpr, foundType := sqlc.typeMapper(swtype)
 
 	newID := id
 	var err error
@@ -252,3 +464,22 @@ func (sqlc *SQLCompiler) ReplaceIdentifier(swtype, id string) (string, error) {
 
 	return newID, nil
 }
+
+// typeMapper returns the TypeMapper matching swtype. While compiling policies, types without
+// a TypeMapper are mapped to the table named after the type, e.g. petstore.pet to pet,
+// and properties to the columns of the same name.
+func (sqlc *SQLCompiler) typeMapper(swtype string) (*TypeMapper, bool) {
+	tmpr, foundType := sqlc.TypeMappers[swtype]
+	if !foundType {
+		swParts := lexer.SplitSwaggerType(swtype)
+		swParts.Type = "*"
+		tmpr, foundType = sqlc.TypeMappers[swParts.String()]
+	}
+	if !foundType && sqlc.policyMode {
+		tmpr = NewTypeMapper(swtype).ToSQLTable("*").
+			WithPropertyMapper(NewPropertyMapper("*").ToSQLColumn("*"))
+		tmpr.SQLCompiler = sqlc
+		foundType = true
+	}
+	return tmpr, foundType
+}
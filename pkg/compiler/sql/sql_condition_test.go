@@ -1,9 +1,13 @@
 package sqlcompiler
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/infobloxopen/seal/pkg/provider"
 )
 
 func TestCompileCondition(t *testing.T) {
@@ -14,6 +18,7 @@ func TestCompileCondition(t *testing.T) {
 		dialect   SQLDialectEnum
 		jsonbOp   string
 		intFlag   bool
+		subject   *Subject
 		input     string
 		expected  string
 		shouldErr bool
@@ -151,13 +156,186 @@ func TestCompileCondition(t *testing.T) {
 			input:     `type:petstore.pet; "boss" in subject.groups`,
 			jsonbOp:   JSONBObjectOperator,
 			intFlag:   false,
-			expected:  ``, // TODO UNSUPPORTED; may be expected should be ('boss' IN (SELECT groups FROM subject))
+			shouldErr: true, // neither subject nor bind arguments
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; not ctx.group in subject.groups`,
+			subject:   &Subject{Groups: []string{"admins", "it's"}},
+			expected:  `(profile.group NOT IN ('admins','it''s'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.group in subject.teams`,
+			subject:   &Subject{Claims: map[string]interface{}{"teams": []interface{}{"red", "blue"}}},
+			expected:  `(profile.group IN ('red','blue'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.group in subject.teams`, // missing claim contains nothing
+			subject:   &Subject{},
+			expected:  `(1 = 0)`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.group in subject.email`,
+			subject:   &Subject{Claims: map[string]interface{}{"email": "bob@acme.com"}},
+			expected:  ``,
+			shouldErr: true,
+		},
+		{
+			dialect:   DialectMySQL,
+			input:     `type:contacts.profile; not "boss" in subject.groups`,
+			subject:   &Subject{Groups: []string{"admins"}},
+			expected:  `('boss' NOT IN ('admins'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; "admin" in ctx.roles and not ctx.id in ["a", 1]`,
+			expected:  `(('admin' = ANY(profile.roles)) AND (profile.id NOT IN ('a',1)))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectSQLite,
+			input:     `type:contacts.profile; not "admin" in ctx.roles or not ctx.id in $threat.feed["none"]`,
+			expected:  `((NOT ('admin' IN (SELECT value FROM json_each(profile.roles)))) OR (1 = 1))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.id in $threat.feed["over_21_skus"]`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  `(profile.id IN ('sku1','it''s',21))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.id in $threat.feed["none"]`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  `(1 = 0)`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.email == $list["name=customer_support"]`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  `(profile.email = 'support@acme.com')`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.id in $company.list["name=endangered"]`, // unresolvable data reference
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  ``,
+			shouldErr: true,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.email in $list["name=customer_support"]`, // data reference to a scalar
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  ``,
+			shouldErr: true,
+		},
+		{
+			dialect:   DialectMySQL,
+			input:     `type:contacts.profile; ctx.name =~ ".*goofy.*" and ctx.tags["endangered"] == "it's \\ true"`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  `((profile.name REGEXP '.*goofy.*') AND (JSON_EXTRACT(profile.tagz, '$."endangered"') = 'it''s \\\\ true'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectMySQL,
+			input:     `type:contacts.profile; ctx.tags["endangered"] == "true"`,
+			jsonbOp:   JSONBTextOperator,
+			intFlag:   false,
+			expected:  `(JSON_UNQUOTE(JSON_EXTRACT(profile.tagz, '$."endangered"')) = 'true')`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectSQLite,
+			input:     `type:contacts.profile; ctx.name =~ ".*goofy.*" and ctx.tags["0"] == "true"`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   true,
+			expected:  `((profile.name REGEXP '.*goofy.*') AND (json_extract(profile.tagz, '$[0]') = 'true'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectSQLServer,
+			input:     `type:contacts.profile; ctx.name =~ ".*goofy.*" and ctx.tags["endangered"] == "true"`,
+			jsonbOp:   JSONBTextOperator,
+			intFlag:   false,
+			expected:  `(REGEXP_LIKE(profile.name, '.*goofy.*') AND (JSON_VALUE(profile.tagz, '$."endangered"') = 'true'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.tags["labels"]["team"] == "red" and ctx.spec.owner.email == "bob@acme.com"`,
+			jsonbOp:   JSONBTextOperator,
+			intFlag:   false,
+			expected:  `((profile.tagz->'labels'->>'team' = 'red') AND (profile.spec->'owner'->>'email' = 'bob@acme.com'))`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.tags["0"]["1"] == "red"`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   true,
+			expected:  `(profile.tagz->0->1 = 'red')`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectPostgres,
+			input:     `type:contacts.profile; ctx.tags["0"]["team"] == "red"`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   true,
+			expected:  ``,
 			shouldErr: true,
 		},
+		{
+			dialect:   DialectMySQL,
+			input:     `type:contacts.profile; ctx.spec.owner["email"] == "bob@acme.com"`,
+			jsonbOp:   JSONBTextOperator,
+			intFlag:   false,
+			expected:  `(JSON_UNQUOTE(JSON_EXTRACT(profile.spec, '$."owner"."email"')) = 'bob@acme.com')`,
+			shouldErr: false,
+		},
+		{
+			dialect:   DialectSQLServer,
+			input:     `type:contacts.profile; ctx.active == $flags["active"]`,
+			jsonbOp:   JSONBObjectOperator,
+			intFlag:   false,
+			expected:  `(profile.active = (1 = 1))`,
+			shouldErr: false,
+		},
+	}
+
+	dataProvider := provider.Document{
+		"threat": map[string]interface{}{
+			"feed": map[string]interface{}{
+				"over_21_skus": []interface{}{"sku1", "it's", 21},
+				"none":         []interface{}{},
+			},
+		},
+		"list": map[string]interface{}{
+			"name=customer_support": "support@acme.com",
+		},
+		"flags": map[string]interface{}{
+			"active": true,
+		},
 	}
 
 	for idx, tst := range tests {
-		sqlc := NewSQLCompiler().WithDialect(tst.dialect).
+		sqlc := NewSQLCompiler().WithDialect(tst.dialect).WithDataProvider(dataProvider).
 			WithTypeMapper(NewTypeMapper("contacts.*").ToSQLTable("*").
 				WithPropertyMapper(NewPropertyMapper("tags").ToSQLColumn("labels").
 					UseJSONBOperator(tst.jsonbOp).
@@ -180,7 +358,7 @@ func TestCompileCondition(t *testing.T) {
 					UseJSONBIntKeyFlag(tst.intFlag),
 				),
 			)
-		where, err := sqlc.CompileCondition(tst.input)
+		where, err := sqlc.WithSubject(tst.subject).CompileCondition(tst.input)
 		if err != nil && !tst.shouldErr {
 			t.Errorf("Test#%d: failure: unexpected err=%s for input=%s\n",
 				idx, err, tst.input)
@@ -197,3 +375,72 @@ func TestCompileCondition(t *testing.T) {
 		}
 	}
 }
+
+func TestCompileConditionArgs(t *testing.T) {
+	tests := []struct {
+		dialect  SQLDialectEnum
+		input    string
+		expected string
+		args     []interface{}
+	}{
+		{
+			dialect:  DialectPostgres,
+			input:    `type:contacts.profile; ctx.name == "it's" and ctx.age > 18`,
+			expected: `((profile.name = $1) AND profile.age > $2)`,
+			args:     []interface{}{"it's", int64(18)},
+		},
+		{
+			dialect:  DialectMySQL,
+			input:    `type:contacts.profile; ctx.name =~ ".*goofy.*" or ctx.tags["endangered"] == "true"`,
+			expected: `((profile.name REGEXP ?) OR (JSON_EXTRACT(profile.tagz, '$."endangered"') = ?))`,
+			args:     []interface{}{".*goofy.*", "true"},
+		},
+		{
+			dialect:  DialectSQLServer,
+			input:    `type:contacts.profile; ctx.sku in ["a", 2] and ctx.email == $list["name=customer_support"]`,
+			expected: `((profile.sku IN (@p1,@p2)) AND (profile.email = @p3))`,
+			args:     []interface{}{"a", int64(2), "support@acme.com"},
+		},
+		{
+			dialect:  DialectSQLite,
+			input:    `type:contacts.profile; ctx.sku in $threat.feed["over_21_skus"]`,
+			expected: `(profile.sku IN (?,?,?))`,
+			args:     []interface{}{"sku1", "it's", 21},
+		},
+	}
+
+	dataProvider := provider.Document{
+		"threat": map[string]interface{}{
+			"feed": map[string]interface{}{
+				"over_21_skus": []interface{}{"sku1", "it's", 21},
+			},
+		},
+		"list": map[string]interface{}{
+			"name=customer_support": "support@acme.com",
+		},
+	}
+
+	for idx, tst := range tests {
+		sqlc := NewSQLCompiler().WithDialect(tst.dialect).WithDataProvider(dataProvider).
+			WithTypeMapper(NewTypeMapper("contacts.profile").ToSQLTable("profile").
+				WithPropertyMapper(NewPropertyMapper("*").ToSQLColumn("*")).
+				WithPropertyMapper(NewPropertyMapper("tags").ToSQLColumn("tagz").
+					UseJSONBOperator(JSONBObjectOperator),
+				),
+			)
+		where, args, err := sqlc.CompileConditionArgs(tst.input)
+		if err != nil {
+			t.Errorf("Test#%d: unexpected err=%s for input=%s", idx, err, tst.input)
+			continue
+		}
+		if where != tst.expected || !reflect.DeepEqual(args, tst.args) {
+			t.Errorf("Test#%d: input=%s\nexpected=%s %#v\nactual=%s %#v",
+				idx, tst.input, tst.expected, tst.args, where, args)
+		}
+
+		// compiling without bind arguments is not affected
+		if where, err = sqlc.CompileCondition(tst.input); err != nil || strings.Contains(where, "\x00") {
+			t.Errorf("Test#%d: inlined condition: where=%q err=%v", idx, where, err)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package sqlcompiler
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 // SQLDialectEnum enumerates SQL dialects
 type SQLDialectEnum int
 
@@ -7,11 +13,28 @@ type SQLDialectEnum int
 const (
 	DialectUnknown SQLDialectEnum = iota
 	DialectPostgres
+	DialectMySQL
+	DialectSQLite
+	DialectSQLServer
 )
 
 var dialectNames = []string{
 	"DialectUnknown",
 	"DialectPostgres",
+	"DialectMySQL",
+	"DialectSQLite",
+	"DialectSQLServer",
+}
+
+// dialectAliases are the names accepted by ParseDialect
+var dialectAliases = map[string]SQLDialectEnum{
+	"postgres":   DialectPostgres,
+	"postgresql": DialectPostgres,
+	"mysql":      DialectMySQL,
+	"sqlite":     DialectSQLite,
+	"sqlite3":    DialectSQLite,
+	"sqlserver":  DialectSQLServer,
+	"mssql":      DialectSQLServer,
 }
 
 // String satisfies fmt.Stringer interface
@@ -22,3 +45,153 @@ func (dia SQLDialectEnum) String() string {
 	}
 	return dialectNames[dint]
 }
+
+// ParseDialect returns the dialect named name, e.g. postgres, mysql, sqlite or sqlserver
+func ParseDialect(name string) (SQLDialectEnum, error) {
+	dia, ok := dialectAliases[strings.ToLower(name)]
+	if !ok {
+		return DialectUnknown, fmt.Errorf("unknown SQL dialect: %s", name)
+	}
+	return dia, nil
+}
+
+// QuoteIdentifier quotes the table or column name
+func (dia SQLDialectEnum) QuoteIdentifier(name string) string {
+	switch dia {
+	case DialectMySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case DialectSQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// StringLiteral converts s into a quoted SQL string literal
+func (dia SQLDialectEnum) StringLiteral(s string) string {
+	if dia == DialectMySQL {
+		// backslash is an escape character in MySQL string literals
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return `'` + SQLStringLiteralReplacer.Replace(s) + `'`
+}
+
+// BoolLiteral converts b into an SQL boolean, SQL Server has no boolean literals
+func (dia SQLDialectEnum) BoolLiteral(b bool) string {
+	switch {
+	case dia == DialectSQLServer && b:
+		return "(1 = 1)"
+	case dia == DialectSQLServer:
+		return "(1 = 0)"
+	case b:
+		return SQLTrue
+	}
+	return SQLFalse
+}
+
+// NotTrue returns the condition holding if pred is false or unknown (NULL), e.g. when pred compares
+// a NULL column: as a rule of the rego backend, a deny rule never fires on undefined properties.
+// SQL Server has no IS [NOT] TRUE.
+func (dia SQLDialectEnum) NotTrue(pred string) string {
+	if dia == DialectSQLServer {
+		return fmt.Sprintf("(CASE WHEN %s THEN 0 ELSE 1 END = 1)", pred)
+	}
+	return fmt.Sprintf("(%s IS NOT TRUE)", pred)
+}
+
+// RegexpMatch returns the condition matching lhs against the regular expression rhs.
+// SQLite only provides the REGEXP operator if a regexp function is registered, see SQLiteRegexp.
+// SQL Server provides REGEXP_LIKE since SQL Server 2025.
+func (dia SQLDialectEnum) RegexpMatch(lhs, rhs string) (string, error) {
+	switch dia {
+	case DialectPostgres:
+		return fmt.Sprintf("(%s ~ %s)", lhs, rhs), nil
+	case DialectMySQL, DialectSQLite:
+		return fmt.Sprintf("(%s REGEXP %s)", lhs, rhs), nil
+	case DialectSQLServer:
+		return fmt.Sprintf("REGEXP_LIKE(%s, %s)", lhs, rhs), nil
+	}
+	return "", fmt.Errorf("SQL dialect %s does not know how to convert regexp-match", dia)
+}
+
+// JSONAccess returns the expression accessing the path of keys (e.g. ["labels", "team"]) of the JSON column.
+// operator is the JSONB operator of Postgres returning the last key: JSONBObjectOperator or JSONBTextOperator,
+// keys above the last are chained with JSONBObjectOperator. Other dialects return the value as SQL scalar
+// for JSONBTextOperator. intKeys is true if the keys are array indexes.
+func (dia SQLDialectEnum) JSONAccess(column, operator string, keys []string, intKeys bool) (string, error) {
+	if dia == DialectPostgres {
+		for i, key := range keys {
+			op := JSONBObjectOperator
+			if i == len(keys)-1 {
+				op = operator
+			}
+			if !intKeys {
+				key = dia.StringLiteral(key)
+			}
+			column += op + key
+		}
+		return column, nil
+	}
+
+	path := `$`
+	for _, key := range keys {
+		if intKeys {
+			path += `[` + key + `]`
+		} else {
+			path += `."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+		}
+	}
+	path = dia.StringLiteral(path)
+
+	switch dia {
+	case DialectMySQL:
+		if operator == JSONBTextOperator {
+			return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s))", column, path), nil
+		}
+		return fmt.Sprintf("JSON_EXTRACT(%s, %s)", column, path), nil
+	case DialectSQLite:
+		return fmt.Sprintf("json_extract(%s, %s)", column, path), nil
+	case DialectSQLServer:
+		return fmt.Sprintf("JSON_VALUE(%s, %s)", column, path), nil
+	}
+	return "", fmt.Errorf("SQL dialect %s does not support JSONB conversion", dia)
+}
+
+// ListContains returns the condition testing that the list contains elem, list is a bound parameter
+// or an array column. Postgres lists are arrays, lists of other dialects are JSON arrays.
+func (dia SQLDialectEnum) ListContains(list, elem string) (string, error) {
+	switch dia {
+	case DialectPostgres:
+		return fmt.Sprintf("(%s = ANY(%s))", elem, list), nil
+	case DialectMySQL:
+		return fmt.Sprintf("JSON_CONTAINS(%s, JSON_QUOTE(%s))", list, elem), nil
+	case DialectSQLite:
+		return fmt.Sprintf("(%s IN (SELECT value FROM json_each(%s)))", elem, list), nil
+	case DialectSQLServer:
+		return fmt.Sprintf("(%s IN (SELECT value FROM OPENJSON(%s)))", elem, list), nil
+	}
+	return "", fmt.Errorf("SQL dialect %s does not know how to bind lists", dia)
+}
+
+// Placeholder returns the placeholder of the n-th (starting at 1) bind argument,
+// e.g. $1 for Postgres, @p1 for SQL Server and ? for the other dialects
+func (dia SQLDialectEnum) Placeholder(n int) string {
+	switch dia {
+	case DialectPostgres:
+		return fmt.Sprintf("$%d", n)
+	case DialectSQLServer:
+		return fmt.Sprintf("@p%d", n)
+	}
+	return "?"
+}
+
+// SQLiteRegexp implements the regexp function SQLite calls for `X REGEXP Y`,
+// it has to be registered by the application, e.g. with mattn/go-sqlite3:
+//
+//	sql.Register("sqlite3_seal", &sqlite3.SQLiteDriver{
+//		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+//			return conn.RegisterFunc("regexp", sqlcompiler.SQLiteRegexp, true)
+//		},
+//	})
+func SQLiteRegexp(re, s string) (bool, error) {
+	return regexp.MatchString(re, s)
+}
@@ -0,0 +1,76 @@
+package sqlcompiler
+
+import (
+	"testing"
+)
+
+func TestDialect(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialect    SQLDialectEnum
+		quoted     string
+		literal    string
+		jsonAccess string
+		jsonPath   string // access of ["a", "b"]
+	}{
+		{
+			name:       "postgres",
+			dialect:    DialectPostgres,
+			quoted:     `"my""table"`,
+			literal:    `'it''s \'`,
+			jsonAccess: `t.c->>'k"ey'`,
+			jsonPath:   `t.c->'a'->>'b'`,
+		},
+		{
+			name:       "mysql",
+			dialect:    DialectMySQL,
+			quoted:     "`my\"table`",
+			literal:    `'it''s \\'`,
+			jsonAccess: `JSON_UNQUOTE(JSON_EXTRACT(t.c, '$."k\\"ey"'))`,
+			jsonPath:   `JSON_UNQUOTE(JSON_EXTRACT(t.c, '$."a"."b"'))`,
+		},
+		{
+			name:       "sqlite",
+			dialect:    DialectSQLite,
+			quoted:     `"my""table"`,
+			literal:    `'it''s \'`,
+			jsonAccess: `json_extract(t.c, '$."k\"ey"')`,
+			jsonPath:   `json_extract(t.c, '$."a"."b"')`,
+		},
+		{
+			name:       "sqlserver",
+			dialect:    DialectSQLServer,
+			quoted:     `[my"table]`,
+			literal:    `'it''s \'`,
+			jsonAccess: `JSON_VALUE(t.c, '$."k\"ey"')`,
+			jsonPath:   `JSON_VALUE(t.c, '$."a"."b"')`,
+		},
+	}
+
+	for _, tst := range tests {
+		dia, err := ParseDialect(tst.name)
+		if err != nil || dia != tst.dialect {
+			t.Errorf("%s: expected dialect %s, got %s (err=%v)", tst.name, tst.dialect, dia, err)
+		}
+		if actual := tst.dialect.QuoteIdentifier(`my"table`); actual != tst.quoted {
+			t.Errorf("%s: expected quoted identifier %s, got %s", tst.name, tst.quoted, actual)
+		}
+		if actual := tst.dialect.StringLiteral(`it's \`); actual != tst.literal {
+			t.Errorf("%s: expected string literal %s, got %s", tst.name, tst.literal, actual)
+		}
+		if actual, err := tst.dialect.JSONAccess("t.c", JSONBTextOperator, []string{`k"ey`}, false); err != nil || actual != tst.jsonAccess {
+			t.Errorf("%s: expected JSON access %s, got %s (err=%v)", tst.name, tst.jsonAccess, actual, err)
+		}
+		if actual, err := tst.dialect.JSONAccess("t.c", JSONBTextOperator, []string{"a", "b"}, false); err != nil || actual != tst.jsonPath {
+			t.Errorf("%s: expected JSON access %s, got %s (err=%v)", tst.name, tst.jsonPath, actual, err)
+		}
+	}
+
+	if _, err := ParseDialect("oracle"); err == nil {
+		t.Errorf("expected error for unknown dialect")
+	}
+
+	if m, err := SQLiteRegexp(`@acme\.com$`, "bob@acme.com"); err != nil || !m {
+		t.Errorf("expected SQLiteRegexp to match, got %v (err=%v)", m, err)
+	}
+}
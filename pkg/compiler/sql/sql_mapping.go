@@ -0,0 +1,106 @@
+package sqlcompiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+)
+
+// Mapping is the YAML or JSON configuration of the SQL mapping of swagger types, e.g.
+//
+//	dialect: postgres
+//	quote_identifiers: true
+//	types:
+//	  contacts.profile:
+//	    table: profile
+//	    properties:
+//	      tags:
+//	        column: tagz
+//	        jsonb_operator: "->>"
+//	        jsonb_int_key: false
+type Mapping struct {
+	Dialect          string                  `json:"dialect,omitempty"`           // see ParseDialect
+	QuoteIdentifiers *bool                   `json:"quote_identifiers,omitempty"` // the quoting of the compiler if unset
+	Types            map[string]*TypeMapping `json:"types,omitempty"`             // by swagger type, e.g. contacts.* or contacts.profile
+}
+
+// TypeMapping maps a swagger type to a table, see TypeMapper.
+// Properties without mapping map to the column of the same name, unless a "*" property mapping is given.
+type TypeMapping struct {
+	Table      string                      `json:"table,omitempty"` // "*" (the default) for the type name
+	Properties map[string]*PropertyMapping `json:"properties,omitempty"`
+}
+
+// PropertyMapping maps a property to a column, see PropertyMapper
+type PropertyMapping struct {
+	Column        string `json:"column,omitempty"`         // "*" (the default) for the property name
+	JSONBOperator string `json:"jsonb_operator,omitempty"` // JSONBObjectOperator (the default) or JSONBTextOperator
+	JSONBIntKey   bool   `json:"jsonb_int_key,omitempty"`
+}
+
+// ParseMapping parses the YAML or JSON mapping configuration, unknown fields are errors
+func ParseMapping(data []byte) (*Mapping, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse SQL mapping: %s", err)
+	}
+
+	mpg := &Mapping{}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(mpg); err != nil {
+		return nil, fmt.Errorf("could not parse SQL mapping: %s", err)
+	}
+	return mpg, nil
+}
+
+// WithMapping configures the dialect, identifier quoting and type mappers of the mapping configuration.
+// The dialect and quoting of the compiler are kept if the mapping does not specify them.
+func (sqlc *SQLCompiler) WithMapping(mpg *Mapping) (*SQLCompiler, error) {
+	if mpg.Dialect != "" {
+		dia, err := ParseDialect(mpg.Dialect)
+		if err != nil {
+			return nil, err
+		}
+		sqlc.WithDialect(dia)
+	}
+	if mpg.QuoteIdentifiers != nil {
+		sqlc.WithQuotedIdentifiers(*mpg.QuoteIdentifiers)
+	}
+
+	for swtype, tm := range mpg.Types {
+		if tm == nil {
+			tm = &TypeMapping{}
+		}
+		tmpr := NewTypeMapper(swtype).ToSQLTable(orStar(tm.Table))
+		for ppty, pm := range tm.Properties {
+			if pm == nil {
+				pm = &PropertyMapping{}
+			}
+			pmpr := NewPropertyMapper(ppty).ToSQLColumn(orStar(pm.Column)).UseJSONBIntKeyFlag(pm.JSONBIntKey)
+			switch pm.JSONBOperator {
+			case "":
+			case JSONBObjectOperator, JSONBTextOperator:
+				pmpr.UseJSONBOperator(pm.JSONBOperator)
+			default:
+				return nil, fmt.Errorf("invalid JSONB operator %q of %s property %s, expected %q or %q",
+					pm.JSONBOperator, swtype, ppty, JSONBObjectOperator, JSONBTextOperator)
+			}
+			tmpr.WithPropertyMapper(pmpr)
+		}
+		if _, found := tm.Properties["*"]; !found {
+			tmpr.WithPropertyMapper(NewPropertyMapper("*").ToSQLColumn("*"))
+		}
+		sqlc.WithTypeMapper(tmpr)
+	}
+	return sqlc, nil
+}
+
+func orStar(name string) string {
+	if name == "" {
+		return "*"
+	}
+	return name
+}
@@ -0,0 +1,98 @@
+package sqlcompiler
+
+import (
+	"testing"
+)
+
+func TestMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapping   string
+		quoted    bool // compiler quoting before the mapping
+		input     string
+		expected  string
+		shouldErr bool
+	}{
+		{
+			name: "yaml",
+			mapping: `
+dialect: mysql
+quote_identifiers: true
+types:
+  contacts.profile:
+    table: profiles
+    properties:
+      tags:
+        column: tagz
+        jsonb_operator: "->>"
+`,
+			input:    `type:contacts.profile; ctx.name == "bob" and ctx.tags["color"] == "red"`,
+			expected: "((`profiles`.`name` = 'bob') AND (JSON_UNQUOTE(JSON_EXTRACT(`profiles`.`tagz`, '$.\"color\"')) = 'red'))",
+		},
+		{
+			name:     "json",
+			mapping:  `{"types": {"contacts.*": {"properties": {"tags": {"column": "labels", "jsonb_int_key": true}}}}}`,
+			input:    `type:contacts.address; ctx.tags["0"] == "red"`,
+			expected: `(address.labels->0 = 'red')`,
+		},
+		{
+			name:     "star property",
+			mapping:  `{"types": {"contacts.profile": {"properties": {"*": {"column": "data", "jsonb_operator": "->>"}}}}}`,
+			input:    `type:contacts.profile; ctx.name == "bob"`,
+			expected: `(profile.data = 'bob')`,
+		},
+		{
+			name:     "unquoted identifiers",
+			mapping:  "quote_identifiers: false\ntypes:\n  contacts.order: {}",
+			quoted:   true,
+			input:    `type:contacts.order; ctx.status == "open"`,
+			expected: `(order.status = 'open')`,
+		},
+		{
+			name:     "quoting of the compiler",
+			mapping:  "dialect: postgres\ntypes:\n  contacts.order: {}",
+			quoted:   true,
+			input:    `type:contacts.order; ctx.status == "open"`,
+			expected: `("order"."status" = 'open')`,
+		},
+		{
+			name:      "unknown field",
+			mapping:   `{"types": {"contacts.profile": {"tabel": "profiles"}}}`,
+			shouldErr: true,
+		},
+		{
+			name:      "unknown dialect",
+			mapping:   `dialect: oracle`,
+			shouldErr: true,
+		},
+		{
+			name:      "invalid operator",
+			mapping:   `{"types": {"contacts.profile": {"properties": {"tags": {"jsonb_operator": "?"}}}}}`,
+			shouldErr: true,
+		},
+	}
+
+	for _, tst := range tests {
+		sqlc := NewSQLCompiler().WithDialect(DialectPostgres).WithQuotedIdentifiers(tst.quoted)
+		mpg, err := ParseMapping([]byte(tst.mapping))
+		if err == nil {
+			_, err = sqlc.WithMapping(mpg)
+		}
+		if tst.shouldErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tst.name)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %s", tst.name, err)
+			continue
+		}
+
+		actual, err := sqlc.CompileCondition(tst.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tst.name, err)
+		} else if actual != tst.expected {
+			t.Errorf("%s:\nexpected: %s\nactual:   %s", tst.name, tst.expected, actual)
+		}
+	}
+}
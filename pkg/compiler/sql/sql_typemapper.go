@@ -7,7 +7,6 @@ package sqlcompiler
 import (
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/infobloxopen/seal/pkg/lexer"
 )
@@ -64,7 +63,8 @@ func (pmpr *PropertyMapper) ToSQLColumn(name string) *PropertyMapper {
 
 // UseJSONBOperator specifies the JSONB operator to use for converting indexed properties.
 // The default is JSONBObjectOperator (ie: "->").
-// Only the DialectPostgres SQL dialect supports JSONB conversion.
+// Dialects other than DialectPostgres extract the JSON value with their JSON functions instead,
+// see SQLDialectEnum.JSONAccess.
 func (pmpr *PropertyMapper) UseJSONBOperator(op string) *PropertyMapper {
 	pmpr.JSONBOperator = op
 	return pmpr
@@ -72,7 +72,6 @@ func (pmpr *PropertyMapper) UseJSONBOperator(op string) *PropertyMapper {
 
 // UseJSONBIntKeyFlag specifies the JSONB integer index flag to use for converting indexed properties.
 // The default is false.
-// Only the DialectPostgres SQL dialect supports JSONB conversion.
 func (pmpr *PropertyMapper) UseJSONBIntKeyFlag(flag bool) *PropertyMapper {
 	pmpr.JSONBIntKeyFlag = flag
 	return pmpr
@@ -158,47 +157,41 @@ func (tmpr *TypeMapper) ReplaceIdentifier(swtype string, id string) (string, err
 		}
 	}
 
+	keys := idParts.Path
 	if len(idParts.Key) > 0 {
-		if tmpr.SQLCompiler.Dialect != DialectPostgres {
-			return id, fmt.Errorf("SQL dialect %s does not support JSONB conversion of type/id: %s/%s",
-				tmpr.SQLCompiler.Dialect, swtype, id)
-		} else if pmpr.JSONBIntKeyFlag {
-			_, err := strconv.ParseUint(idParts.Key, 10, 0)
-			if err != nil {
+		keys = append([]string{idParts.Key}, keys...)
+	}
+	if pmpr.JSONBIntKeyFlag {
+		for _, key := range keys {
+			if _, err := strconv.ParseUint(key, 10, 0); err != nil {
 				return id, fmt.Errorf("JSONB index key is not unsigned-integer for type/id: %s/%s", swtype, id)
 			}
 		}
 	}
 
-	var newID strings.Builder
-
-	if tmpr.SQLTable == `*` {
-		newID.WriteString(swParts.Type)
-	} else {
-		newID.WriteString(tmpr.SQLTable)
+	table := tmpr.SQLTable
+	if table == `*` {
+		table = swParts.Type
 	}
-
-	newID.WriteString(`.`)
-
-	if pmpr.SQLColumn == `*` {
-		newID.WriteString(idParts.Field)
-	} else {
-		newID.WriteString(pmpr.SQLColumn)
+	column := pmpr.SQLColumn
+	if column == `*` {
+		column = idParts.Field
 	}
 
-	if len(idParts.Key) > 0 {
-		newID.WriteString(pmpr.JSONBOperator)
-
-		if !pmpr.JSONBIntKeyFlag {
-			newID.WriteString(`'`)
-		}
-
-		newID.WriteString(idParts.Key)
+	dialect := tmpr.SQLCompiler.Dialect
+	if tmpr.SQLCompiler.QuoteIDs {
+		table = dialect.QuoteIdentifier(table)
+		column = dialect.QuoteIdentifier(column)
+	}
+	newID := table + `.` + column
 
-		if !pmpr.JSONBIntKeyFlag {
-			newID.WriteString(`'`)
+	if len(keys) > 0 {
+		jsonID, err := dialect.JSONAccess(newID, pmpr.JSONBOperator, keys, pmpr.JSONBIntKeyFlag)
+		if err != nil {
+			return id, fmt.Errorf("%s of type/id: %s/%s", err, swtype, id)
 		}
+		return jsonID, nil
 	}
 
-	return newID.String(), nil
+	return newID, nil
 }
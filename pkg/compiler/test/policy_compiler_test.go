@@ -51,68 +51,286 @@ func TestBackend(gt *testing.T) {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow;`,
-			compilerError:  errors.New("expected next token to be type 'to', got type ';'/literal ';' instead"),
+			compilerError:  errors.New("1:6: expected next token to be type 'to', got type ';'/literal ';' instead"),
 		},
 		"missing-verb-errors": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow to;`,
-			compilerError:  errors.New("expected next token to be type 'IDENT', got type ';'/literal ';' instead"),
+			compilerError:  errors.New("1:9: expected next token to be type 'IDENT', got type ';'/literal ';' instead"),
 		},
 		"missing-resource-errors": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow to inspect;`,
-			compilerError:  errors.New("expected next token to be type 'TYPE_PATTERN', got type ';'/literal ';' instead"),
+			compilerError:  errors.New("1:17: expected next token to be type 'TYPE_PATTERN', got type ';'/literal ';' instead"),
 		},
 		"invalid-resource-format-without-using-family.type-errors": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow to inspect fake;`,
-			compilerError: errors.New(
-				`expected next token to be type 'TYPE_PATTERN', got type 'IDENT'/literal 'fake' instead
-expected next token to be type 'to', got type ';'/literal ';' instead`),
+			compilerError:  errors.New(`1:18: expected next token to be type 'TYPE_PATTERN', got type 'IDENT'/literal 'fake' instead`),
 		},
 		"invalid-resource-not-registered": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow to inspect fake.fake;`,
-			compilerError:  errors.New(`type pattern fake.fake did not match any registered types`),
+			compilerError:  errors.New(`1:18: type pattern fake.fake did not match any registered types`),
 		},
 		"invalid-nonwildcarded-resource-property": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `allow to inspect products.inventory where ctx.ame == "foo";`,
-			compilerError:  errors.New(`property ctx.ame is not valid for type products.inventory in where clause 'where (ctx.ame == "foo")'`),
+			compilerError:  errors.New(`1:43: property ctx.ame is not valid for type products.inventory in where clause 'where (ctx.ame == "foo")'`),
 		},
 		"invalid-nonwildcarded-resource-property-in-context": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `context { where ctx.ame == "foo"; } to inspect { allow products.inventory; }`,
-			compilerError:  errors.New(`property ctx.ame is not valid for type products.inventory in where clause 'where (ctx.ame == "foo")'`),
+			compilerError:  errors.New(`1:17: property ctx.ame is not valid for type products.inventory in where clause 'where (ctx.ame == "foo")'`),
 		},
 		"invalid-nonwildcarded-resource-property-with-subject": {
 			packageName:    "products.errors",
 			swaggerContent: []string{"company"},
 			policyString:   `context { where ctx.id == "guid"; } { allow subject group everyone to inspect products.inventory where ctx.ame == "foo"; }`,
-			compilerError:  errors.New(`could not compile package products.errors: compiler_rego: at #0 context { where (ctx.id == "guid") ; } { allow subject group everyone to inspect products.inventory where (ctx.ame == "foo") ; } due to error: Unknown property 'ame' of type 'products.inventory'`),
+			compilerError:  errors.New(`could not compile package products.errors: compiler_rego: at 1:1 context { where (ctx.id == "guid") ; } { allow subject group everyone to inspect products.inventory where (ctx.ame == "foo") ; } due to error: Unknown property 'ame' of type 'products.inventory'`),
 			// TODO: why is this error not caught in front-end parser,
 			//       but only caught in back-end compiler?
 			//       Compare to two previous test cases.
 		},
 		"support-for-or-operator-simple": {
-			packageName:    "products.errors",
+			packageName:    "products.inventory",
 			swaggerContent: []string{"company"},
 			policyString:   `allow subject group everyone to inspect products.inventory where ctx.id == "guid" or ctx.name == "foo";`,
-			compilerError:  errors.New(`OR-operator not supported yet for condition '((ctx.id == "guid") or (ctx.name == "foo"))'`),
-			// TODO GH-42
+			result: `
+package products.inventory
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "company.personnel": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "operate": [
+            "turn-on",
+            "turn-off",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "products.inventory": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
+allow {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["id"] == "guid"
+}
+
+allow {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["name"] == "foo"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
 		},
 		"support-for-or-operator-context": {
-			packageName:    "products.errors",
+			packageName:    "products.inventory",
 			swaggerContent: []string{"company"},
 			policyString:   `context { where ctx.id == "guid" or ctx.name == "foo" } { allow subject group everyone to inspect products.inventory; }`,
-			compilerError:  errors.New(`OR-operator not supported yet for condition '((ctx.id == "guid") or (ctx.name == "foo"))'`),
-			// TODO GH-42
+			result: `
+package products.inventory
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "company.personnel": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "operate": [
+            "turn-on",
+            "turn-off",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "products.inventory": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
+allow {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["id"] == "guid"
+}
+
+allow {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["name"] == "foo"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"action-properties-invalid-property": {
+			packageName:    "products.errors",
+			swaggerContent: []string{"company"},
+			policyString:   `allow (notify="true") subject group everyone to inspect products.inventory;`,
+			compilerError:  errors.New(`1:8: property notify is not valid for action allow of type products.inventory`),
+		},
+		"action-properties-invalid-value": {
+			packageName:    "products.errors",
+			swaggerContent: []string{"company"},
+			policyString:   `allow (log="sometimes") subject group everyone to inspect products.inventory;`,
+			compilerError:  errors.New(`1:12: value "sometimes" is not a valid boolean for property log of action allow`),
+		},
+		"action-properties-implicit-action": {
+			packageName:    "products.errors",
+			swaggerContent: []string{"company"},
+			policyString:   `deny (log="true") subject group everyone to inspect products.inventory;`,
+			compilerError:  errors.New(`1:7: property log is not valid for action deny of type products.inventory`),
+		},
+		"action-properties": {
+			packageName:    "products.inventory",
+			swaggerContent: []string{"company"},
+			policyString:   `allow (log="true") subject group everyone to inspect products.inventory where ctx.id == "guid";`,
+			result: `
+package products.inventory
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "company.personnel": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "operate": [
+            "turn-on",
+            "turn-off",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "products.inventory": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
+allow {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["id"] == "guid"
+}
+
+allow_properties[{"log": true}] {
+    seal_list_contains(seal_subject.groups, 'everyone')
+    seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+    re_match('products.inventory', input.type)
+
+    some i
+    input.ctx[i]["id"] == "guid"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
 		},
 		/* TODO: subject should be optional and not required
 		"simplest statement": {
@@ -166,6 +384,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -221,6 +444,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -280,6 +508,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -344,6 +577,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -411,6 +649,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -473,6 +716,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -551,6 +799,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'everyone')
     seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
@@ -624,6 +877,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "company.personnel": "deny",
+    "products.inventory": "deny",
+}
+
 allow {
     seal_list_contains(seal_subject.groups, 'manager')
     seal_list_contains(base_verbs[input.type]['operate'], input.verb)
@@ -667,6 +925,10 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "petstore.pet": "deny",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'patissiers')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -711,6 +973,10 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "petstore.pet": "deny",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'patissiers')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -755,6 +1021,10 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "petstore.pet": "deny",
+}
+
 allow {
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
 	re_match('petstore.*', input.type)
@@ -798,6 +1068,10 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "petstore.pet": "deny",
+}
+
 allow {
 	seal_list_contains(base_verbs[input.type]['use'], input.verb)
 	re_match('petstore.*', input.type)
@@ -852,12 +1126,422 @@ base_verbs := {
             "create",
             "delete",
         ],
-        "use": [
-            "update",
-            "get",
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "products.inventory": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "company.personnel": "deny",
+    "petstore.pet": "deny",
+    "products.inventory": "deny",
+}
+
+allow {
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('petstore.*', input.type)
+	seal_subject.sub == "name"
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('products.*', input.type)
+	seal_subject.sub == "name"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"context-nested": {
+			packageName:    "petstore",
+			swaggerContent: []string{"global", "company", "sw1"},
+			policyString: `
+context { 
+	where subject.sub=="name"; 
+} to use { 
+	context {} petstore.* {allow to manage;}
+	context {where subject.sub=="name2";} to inspect products.* {deny;}
+}`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "company.personnel": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "operate": [
+            "turn-on",
+            "turn-off",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "petstore.pet": {
+        "emptyvrb1": [
+        ],
+        "emptyvrb2": [
+        ],
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+    "products.inventory": {
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "company.personnel": "deny",
+    "petstore.pet": "deny",
+    "products.inventory": "deny",
+}
+
+allow {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.*', input.type)
+}
+
+allow {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.*', input.type)
+	seal_subject.sub == "name"
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+	re_match('products.*', input.type)
+	seal_subject.sub == "name2"
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
+	re_match('products.*', input.type)
+	seal_subject.sub == "name"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"nested-properties": {
+			packageName:    "petstore",
+			swaggerContent: []string{"petstore-orders"},
+			policyString: `
+allow subject group everyone to manage petstore.order
+where ctx.buyer.email == subject.email and ctx.buyer.address.city == subject.address.city and ctx.buyer.tags["vip"] == "true";
+`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "petstore.order": {
+        "manage": [
+            "create",
+            "delete",
+        ],
+    },
+}
+
+default_actions := {
+    "petstore.order": "deny",
+}
+
+allow {
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.order', input.type)
+
+	some i
+	input.ctx[i]["buyer"]["email"] == seal_subject.email
+	input.ctx[i]["buyer"]["address"]["city"] == seal_subject.address.city
+	input.ctx[i]["buyer"]["tags"]["vip"] == "true"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"nested-tags": {
+			packageName:    "petstore",
+			swaggerContent: []string{"petstore-orders"},
+			policyString:   `allow subject group everyone to manage petstore.order where ctx.buyer.tags["labels"]["team"] == "red";`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "petstore.order": {
+        "manage": [
+            "create",
+            "delete",
+        ],
+    },
+}
+
+default_actions := {
+    "petstore.order": "deny",
+}
+
+allow {
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.order', input.type)
+
+	some i
+	input.ctx[i]["buyer"]["tags"]["labels"]["team"] == "red"
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"nested-properties-invalid-ctx": {
+			packageName:    "petstore",
+			swaggerContent: []string{"petstore-orders"},
+			policyString:   `allow to manage petstore.order where ctx.buyer.phone == subject.email;`,
+			compilerError:  errors.New(`1:38: property ctx.buyer.phone is not valid for type petstore.order in where clause 'where (ctx.buyer.phone == subject.email)'`),
+		},
+		"nested-properties-invalid-subject": {
+			packageName:    "petstore",
+			swaggerContent: []string{"petstore-orders"},
+			policyString:   `allow to manage petstore.order where ctx.buyer.address.city == subject.address.zip;`,
+			compilerError:  errors.New(`1:64: property subject.address.zip is not valid for type petstore.order in where clause 'where (ctx.buyer.address.city == subject.address.zip)'`),
+		},
+		"custom-actions": {
+			packageName:    "support",
+			swaggerContent: []string{"custom-actions"},
+			policyString: `
+redirect (to="911") subject group everyone to use support.ticket where ctx.severity == "critical";
+audit to manage support.ticket;
+allow subject group support to use support.ticket;
+`,
+			result: `
+package support
+
+default allow = false
+default deny = false
+default audit = false
+default redirect = false
+
+base_verbs := {
+    "support.ticket": {
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "support.ticket": "deny",
+}
+
+redirect {
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('support.ticket', input.type)
+
+	some i
+	input.ctx[i]["severity"] == "critical"
+}
+
+redirect_properties[{"to": "911"}] {
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('support.ticket', input.type)
+
+	some i
+	input.ctx[i]["severity"] == "critical"
+}
+
+audit {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('support.ticket', input.type)
+}
+
+allow {
+	seal_list_contains(seal_subject.groups, 'support')
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('support.ticket', input.type)
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"custom-actions-undeclared": {
+			packageName:    "support",
+			swaggerContent: []string{"custom-actions"},
+			policyString:   `drop to manage support.ticket;`,
+			compilerError:  errors.New(`1:16: type pattern support.ticket did not match any registered types`),
+		},
+		"in-operator": {
+			packageName:    "petstore",
+			swaggerContent: []string{"global", "sw1"},
+			policyString:   `deny to manage petstore.pet where "banned" in subject.sub;`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "petstore.pet": {
+        "emptyvrb1": [
+        ],
+        "emptyvrb2": [
+        ],
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "petstore.pet": "deny",
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.pet', input.type)
+	seal_list_contains(seal_subject.sub, "banned")
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"not-in-operator": {
+			packageName:    "petstore",
+			swaggerContent: []string{"global", "sw1"},
+			policyString:   `deny to manage petstore.pet where not "banned" in subject.sub;`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "petstore.pet": {
+        "emptyvrb1": [
+        ],
+        "emptyvrb2": [
+        ],
+        "inspect": [
+            "list",
+            "watch",
+        ],
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
+        ],
+    },
+}
+
+default_actions := {
+    "petstore.pet": "deny",
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.pet', input.type)
+	not line1_not1_cnd
+}
+
+line1_not1_cnd {
+	seal_list_contains(seal_subject.sub, "banned")
+}
+
+obligations := {
+}
+` + compiler_rego.CompiledRegoHelpers,
+		},
+		"data-reference": {
+			packageName:    "petstore",
+			swaggerContent: []string{"global", "sw1"},
+			policyString:   `deny to manage petstore.pet where ctx.id in $threat.feed["banned_pets"] or subject.sub == $list["name=owner"];`,
+			result: `
+package petstore
+
+default allow = false
+default deny = false
+
+base_verbs := {
+    "petstore.pet": {
+        "emptyvrb1": [
+        ],
+        "emptyvrb2": [
         ],
-    },
-    "products.inventory": {
         "inspect": [
             "list",
             "watch",
@@ -873,62 +1557,43 @@ base_verbs := {
     },
 }
 
-allow {
-	seal_list_contains(base_verbs[input.type]['use'], input.verb)
-	re_match('petstore.*', input.type)
-	seal_subject.sub == "name"
+default_actions := {
+    "petstore.pet": "deny",
 }
 
 deny {
-	seal_list_contains(base_verbs[input.type]['use'], input.verb)
-	re_match('products.*', input.type)
-	seal_subject.sub == "name"
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.pet', input.type)
+
+	some i
+	seal_list_contains(data.threat.feed["banned_pets"], input.ctx[i]["id"])
+}
+
+deny {
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('petstore.pet', input.type)
+	seal_subject.sub == data.list["name=owner"]
 }
 
 obligations := {
 }
 ` + compiler_rego.CompiledRegoHelpers,
 		},
-		"context-nested": {
-			packageName:    "petstore",
-			swaggerContent: []string{"global", "company", "sw1"},
+		"obligations-simple": {
+			packageName:    "acme-obligations",
+			swaggerContent: []string{"tags", "acme-obligations"},
 			policyString: `
-context { 
-	where subject.sub=="name"; 
-} to use { 
-	context {} petstore.* {allow to manage;}
-	context {where subject.sub=="name2";} to inspect products.* {deny;}
-}`,
+allow subject group everyone to manage acme.gadget
+where ctx.id=="123" and ctx.color != "blue" and ctx.tags["age"] == 101;
+`,
 			result: `
-package petstore
+package acme-obligations
 
 default allow = false
 default deny = false
 
 base_verbs := {
-    "company.personnel": {
-        "inspect": [
-            "list",
-            "watch",
-        ],
-        "manage": [
-            "create",
-            "delete",
-        ],
-        "operate": [
-            "turn-on",
-            "turn-off",
-        ],
-        "use": [
-            "update",
-            "get",
-        ],
-    },
-    "petstore.pet": {
-        "emptyvrb1": [
-        ],
-        "emptyvrb2": [
-        ],
+    "acme.gadget": {
         "inspect": [
             "list",
             "watch",
@@ -942,7 +1607,7 @@ base_verbs := {
             "get",
         ],
     },
-    "products.inventory": {
+    "acme.widget": {
         "inspect": [
             "list",
             "watch",
@@ -958,49 +1623,57 @@ base_verbs := {
     },
 }
 
-allow {
-	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
-	re_match('petstore.*', input.type)
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
 }
 
 allow {
+	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
-	re_match('petstore.*', input.type)
-	seal_subject.sub == "name"
-}
-
-deny {
-	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
-	re_match('products.*', input.type)
-	seal_subject.sub == "name2"
-}
+	re_match('acme.gadget', input.type)
 
-deny {
-	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
-	re_match('products.*', input.type)
-	seal_subject.sub == "name"
+	some i
+	input.ctx[i]["id"] == "123"
+	input.ctx[i]["tags"]["age"] == 101
 }
 
 obligations := {
+	'stmt0': [
+		'type:acme.gadget; (ctx.color != "blue")',
+	],
 }
 ` + compiler_rego.CompiledRegoHelpers,
 		},
-		"in-operator": {
-			packageName:    "petstore",
-			swaggerContent: []string{"global", "sw1"},
-			policyString:   `deny to manage petstore.pet where "banned" in subject.sub;`,
+		"obligations-or-operator-distribution": {
+			packageName:    "acme-obligations",
+			swaggerContent: []string{"tags", "acme-obligations"},
+			policyString: `
+allow subject group everyone to manage acme.gadget
+where (ctx.id == "123" or ctx.name == "foo") and ctx.tags["age"] == 101;
+`,
 			result: `
-package petstore
+package acme-obligations
 
 default allow = false
 default deny = false
 
 base_verbs := {
-    "petstore.pet": {
-        "emptyvrb1": [
+    "acme.gadget": {
+        "inspect": [
+            "list",
+            "watch",
         ],
-        "emptyvrb2": [
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
         ],
+    },
+    "acme.widget": {
         "inspect": [
             "list",
             "watch",
@@ -1016,32 +1689,64 @@ base_verbs := {
     },
 }
 
-deny {
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
+allow {
+	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
-	re_match('petstore.pet', input.type)
-	seal_list_contains(seal_subject.sub, "banned")
+	re_match('acme.gadget', input.type)
+
+	some i
+	input.ctx[i]["id"] == "123"
+	input.ctx[i]["tags"]["age"] == 101
+}
+
+allow {
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
+	re_match('acme.gadget', input.type)
+
+	some i
+	input.ctx[i]["name"] == "foo"
+	input.ctx[i]["tags"]["age"] == 101
 }
 
 obligations := {
 }
 ` + compiler_rego.CompiledRegoHelpers,
 		},
-		"not-in-operator": {
-			packageName:    "petstore",
-			swaggerContent: []string{"global", "sw1"},
-			policyString:   `deny to manage petstore.pet where not "banned" in subject.sub;`,
+		"obligations-or-operator-negated": {
+			packageName:    "acme-obligations",
+			swaggerContent: []string{"tags", "acme-obligations"},
+			policyString: `
+deny subject group everyone to use acme.gadget
+where not (ctx.id == "123" or ctx.name == "foo");
+`,
 			result: `
-package petstore
+package acme-obligations
 
 default allow = false
 default deny = false
 
 base_verbs := {
-    "petstore.pet": {
-        "emptyvrb1": [
+    "acme.gadget": {
+        "inspect": [
+            "list",
+            "watch",
         ],
-        "emptyvrb2": [
+        "manage": [
+            "create",
+            "delete",
+        ],
+        "use": [
+            "update",
+            "get",
         ],
+    },
+    "acme.widget": {
         "inspect": [
             "list",
             "watch",
@@ -1057,26 +1762,39 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 deny {
-	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
-	re_match('petstore.pet', input.type)
+	seal_list_contains(seal_subject.groups, 'everyone')
+	seal_list_contains(base_verbs[input.type]['use'], input.verb)
+	re_match('acme.gadget', input.type)
 	not line1_not1_cnd
+	not line1_not2_cnd
 }
 
 line1_not1_cnd {
-	seal_list_contains(seal_subject.sub, "banned")
+	some i
+	input.ctx[i]["id"] == "123"
+}
+
+line1_not2_cnd {
+	some i
+	input.ctx[i]["name"] == "foo"
 }
 
 obligations := {
 }
 ` + compiler_rego.CompiledRegoHelpers,
 		},
-		"obligations-simple": {
+		"obligations-or-operator-deferred": {
 			packageName:    "acme-obligations",
 			swaggerContent: []string{"tags", "acme-obligations"},
 			policyString: `
 allow subject group everyone to manage acme.gadget
-where ctx.id=="123" and ctx.color != "blue" and ctx.tags["age"] == 101;
+where ctx.name == "foo" or (ctx.id == "123" and ctx.color == "red");
 `,
 			result: `
 package acme-obligations
@@ -1115,19 +1833,20 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
 	re_match('acme.gadget', input.type)
-
-	some i
-	input.ctx[i]["id"] == "123"
-	input.ctx[i]["tags"]["age"] == 101
 }
 
 obligations := {
 	'stmt0': [
-		'type:acme.gadget; (ctx.color != "blue")',
+		'type:acme.gadget; ((ctx.name == "foo") or ((ctx.id == "123") and (ctx.color == "red")))',
 	],
 }
 ` + compiler_rego.CompiledRegoHelpers,
@@ -1176,6 +1895,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -1240,6 +1964,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'managers')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -1314,6 +2043,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -1378,6 +2112,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(seal_subject.groups, 'everyone')
 	seal_list_contains(base_verbs[input.type]['manage'], input.verb)
@@ -1436,6 +2175,10 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acm3.g4dget": "allow",
+}
+
 allow {
 	seal_subject.sub == 'us3r'
 	seal_list_contains(base_verbs[input.type]['m4nage'], input.verb)
@@ -1498,6 +2241,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
 	re_match('acme.widget', input.type)
@@ -1559,6 +2307,11 @@ base_verbs := {
     },
 }
 
+default_actions := {
+    "acme.gadget": "allow",
+    "acme.widget": "allow",
+}
+
 allow {
 	seal_list_contains(base_verbs[input.type]['inspect'], input.verb)
 	re_match('acme.widget', input.type)
@@ -2003,4 +2756,89 @@ components:
 					type: integer
 					x-seal-obligation: true
 `,
+	"petstore-orders": `
+openapi: "3.0.0"
+components:
+	schemas:
+		subject:
+			type: object
+			properties:
+				email:
+					type: string
+				address:
+					$ref: '#/components/schemas/address'
+			x-seal-type: none
+		address:
+			type: object
+			properties:
+				city:
+					type: string
+			x-seal-type: none
+		petstore.user:
+			type: object
+			properties:
+				email:
+					type: string
+				address:
+					$ref: '#/components/schemas/address'
+				tags:
+					type: object
+					additionalProperties: true
+			x-seal-type: none
+		petstore.order:
+			type: object
+			x-seal-actions:
+			- allow
+			- deny
+			x-seal-verbs:
+				manage:    [ "create", "delete" ]
+			x-seal-default-action: deny
+			properties:
+				id:
+					type: string
+				buyer:
+					$ref: '#/components/schemas/petstore.user'
+`,
+	"custom-actions": `
+openapi: "3.0.0"
+components:
+	schemas:
+		redirect:
+			type: object
+			properties:
+				to:
+					type: string
+			x-seal-type: action
+		support.ticket:
+			type: object
+			x-seal-actions:
+			- allow
+			- deny
+			- redirect
+			- audit
+			x-seal-verbs:
+				use:       [ "update", "get" ]
+				manage:    [ "create", "delete" ]
+			x-seal-default-action: deny
+			properties:
+				id:
+					type: string
+				severity:
+					type: string
+`,
+}
+
+func TestCompileFile(t *testing.T) {
+	cmplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, strings.ReplaceAll(swaggers["company"], "	", "  "))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	policy := `allow subject group everyone to inspect products.inventory;
+
+allow subject group everyone to inspect products.inventroy;
+`
+	_, err = cmplr.CompileFile("products.seal", "products", policy)
+	expected := errors.New(`products.seal:3:41: type pattern products.inventroy did not match any registered types`)
+	checkError(t, err, expected)
 }
@@ -0,0 +1,366 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	compiler_semantic "github.com/infobloxopen/seal/pkg/compiler/semantic"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/provider"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// compileWhereClause returns the bodies of the where clause and the conditions deferred to obligations,
+// as the rego backend does
+func (e *Evaluator) compileWhereClause(swtype types.Type, cnd ast.Condition) ([]ast.Condition, []string, error) {
+	bodies, obligations, err := compiler_semantic.WhereClause(swtype, cnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, body := range bodies {
+		if err := e.prepare(body); err != nil {
+			return nil, nil, err
+		}
+	}
+	return bodies, obligations, nil
+}
+
+// prepare checks the identifiers of the body and compiles its regular expressions
+func (e *Evaluator) prepare(cnd ast.Condition) error {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type == token.LITERAL || strings.HasPrefix(s.Value, "ctx.") || strings.HasPrefix(s.Value, types.SUBJECT+".") {
+			return nil
+		}
+		return fmt.Errorf("unsupported identifier '%s'", s.Value)
+
+	case *ast.PrefixCondition:
+		return e.prepare(s.Right)
+
+	case *ast.InfixCondition:
+		if lit, ok := s.Right.(*ast.Identifier); ok && s.Token.Type == token.OP_MATCH && lit.Token.Type == token.LITERAL {
+			if err := e.regexps.add(lit.Value); err != nil {
+				return err
+			}
+		}
+		if err := e.prepare(s.Left); err != nil {
+			return err
+		}
+		return e.prepare(s.Right)
+	}
+	return nil
+}
+
+// holds returns true if the body holds for one of the ctx objects of the request.
+// As in the rego backend, all the ctx properties of a body refer to the same object,
+// while a negation holds if its condition holds for none of the objects.
+// A body without ctx property is evaluated once, even if the request has no ctx object.
+func (e *Evaluator) holds(body ast.Condition, req *Request) (bool, error) {
+	if types.IsNilInterface(body) {
+		return true, nil
+	}
+	if !refersToCtx(body) {
+		return e.test(body, req, nil)
+	}
+	for _, obj := range req.Ctx {
+		ok, err := e.test(body, req, obj)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// refersToCtx returns true if the condition refers to a ctx property outside of negations
+func refersToCtx(cnd ast.Condition) bool {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		return s.Token.Type != token.LITERAL && strings.HasPrefix(s.Value, "ctx.")
+	case *ast.InfixCondition:
+		return (!types.IsNilInterface(s.Left) && refersToCtx(s.Left)) ||
+			(!types.IsNilInterface(s.Right) && refersToCtx(s.Right))
+	}
+	return false
+}
+
+// test returns true if the condition holds for the ctx object.
+// Conditions on undefined values (e.g. missing properties) do not hold, as in rego.
+func (e *Evaluator) test(cnd ast.Condition, req *Request, obj map[string]interface{}) (bool, error) {
+	switch s := cnd.(type) {
+	case *ast.PrefixCondition:
+		ok, err := e.holds(s.Right, req)
+		return !ok, err
+
+	case *ast.InfixCondition:
+		if s.Token.Type == token.AND {
+			ok, err := e.test(s.Left, req, obj)
+			if err != nil || !ok {
+				return false, err
+			}
+			return e.test(s.Right, req, obj)
+		}
+
+		lhs, lhsDefined, err := e.value(s.Left, req, obj)
+		if err != nil || !lhsDefined {
+			return false, err
+		}
+		rhs, rhsDefined, err := e.value(s.Right, req, obj)
+		if err != nil || !rhsDefined {
+			return false, err
+		}
+		return e.compare(s.Token.Type, lhs, rhs)
+	}
+
+	// a single value holds if it is defined and not false
+	value, defined, err := e.value(cnd, req, obj)
+	if err != nil || !defined {
+		return false, err
+	}
+	return value != false, nil
+}
+
+// compare applies the comparison operator, values of different types are never ordered
+func (e *Evaluator) compare(op token.TokenType, lhs, rhs interface{}) (bool, error) {
+	switch op {
+	case token.OP_EQUAL_TO:
+		return equal(lhs, rhs), nil
+	case token.OP_NOT_EQUAL:
+		return !equal(lhs, rhs), nil
+	case token.OP_IN:
+		return contains(rhs, lhs), nil
+	case token.OP_MATCH:
+		str, ok := lhs.(string)
+		pattern, isPattern := rhs.(string)
+		if !ok || !isPattern {
+			return false, nil
+		}
+		re, err := e.regexps.compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(str), nil
+	}
+
+	var cmp int
+	switch l := lhs.(type) {
+	case float64:
+		r, ok := rhs.(float64)
+		if !ok {
+			return false, nil
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := rhs.(string)
+		if !ok {
+			return false, nil
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, nil
+	}
+
+	switch op {
+	case token.OP_LESS_THAN:
+		return cmp < 0, nil
+	case token.OP_GREATER_THAN:
+		return cmp > 0, nil
+	case token.OP_LESS_EQUAL:
+		return cmp <= 0, nil
+	case token.OP_GREATER_EQUAL:
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unsupported operator '%s'", op)
+}
+
+// value returns the normalized value of the operand and whether it is defined
+func (e *Evaluator) value(cnd ast.Condition, req *Request, obj map[string]interface{}) (interface{}, bool, error) {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type == token.LITERAL {
+			return s.Value, true, nil
+		}
+
+		// property path, e.g. ["tags", "labels", "team"] for ctx.tags["labels"]["team"]
+		idParts := lexer.SplitIdentifier(s.Value)
+		path := []string{idParts.Field}
+		if idParts.Key != "" {
+			path = append(append(path, idParts.Key), idParts.Path...)
+		}
+		switch idParts.Table {
+		case "ctx":
+			return lookup(obj, path)
+		case types.SUBJECT:
+			return lookup(req.Subject, path)
+		}
+		return nil, false, fmt.Errorf("unsupported identifier '%s'", s.Value)
+
+	case *ast.IntegerLiteral:
+		return float64(s.Value), true, nil
+
+	case *ast.ArrayLiteral:
+		items := []interface{}{}
+		for _, it := range s.Items {
+			item, defined, err := e.value(it, req, obj)
+			if err != nil || !defined {
+				return nil, false, err
+			}
+			items = append(items, item)
+		}
+		return items, true, nil
+
+	case *ast.DataReference:
+		return e.resolve(s)
+	}
+
+	return nil, false, compiler_error.ErrUnknownCondition
+}
+
+// resolve returns the normalized value of the data reference, references not found are undefined
+func (e *Evaluator) resolve(ref *ast.DataReference) (interface{}, bool, error) {
+	if e.provider == nil {
+		return nil, false, nil
+	}
+	value, err := e.provider.Resolve(ref.Name, ref.Key)
+	if errors.Is(err, provider.ErrNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return normalize(value), true, nil
+}
+
+// lookup walks the objects along the path
+func lookup(obj map[string]interface{}, path []string) (interface{}, bool, error) {
+	var cur interface{} = obj
+	for _, elem := range path {
+		m, ok := normalize(cur).(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		if cur, ok = m[elem]; !ok {
+			return nil, false, nil
+		}
+	}
+	return normalize(cur), true, nil
+}
+
+// normalize converts the numbers to float64 and the slices and maps to their JSON-like counterparts,
+// so that values built in Go compare as the same values decoded from JSON
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64, []interface{}, map[string]interface{}:
+		return value
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		out := make([]interface{}, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			out[k] = s
+		}
+		return out
+	}
+
+	if n, ok := value.(interface{ Float64() (float64, error) }); ok { // e.g. json.Number
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// equal returns true if the values are deeply equal once normalized
+func equal(lhs, rhs interface{}) bool {
+	lhs, rhs = normalize(lhs), normalize(rhs)
+	switch l := lhs.(type) {
+	case []interface{}:
+		r, ok := rhs.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !equal(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		r, ok := rhs.(map[string]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for k, v := range l {
+			if rv, found := r[k]; !found || !equal(v, rv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(lhs, rhs)
+}
+
+// contains returns true if the elem is an item of the list, or a value of the object
+func contains(list, elem interface{}) bool {
+	switch l := normalize(list).(type) {
+	case []interface{}:
+		for _, it := range l {
+			if equal(it, elem) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, it := range l {
+			if equal(it, elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// regexpCache holds the compiled literal patterns of the `=~` operator, filled by New.
+// Patterns from values of the request are compiled at decision time and not cached.
+type regexpCache map[string]*regexp.Regexp
+
+// compile returns the compiled pattern, patterns are not anchored as in the rego re_match builtin
+func (c regexpCache) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %s", pattern, err)
+	}
+	return re, nil
+}
+
+// add compiles and caches the literal pattern
+func (c regexpCache) add(pattern string) error {
+	re, err := c.compile(pattern)
+	if err == nil {
+		c[pattern] = re
+	}
+	return err
+}
@@ -0,0 +1,317 @@
+// Package eval decides requests in process, evaluating the policies with the same semantics
+// as the rego backend, so that seal policies can be enforced by Go services without OPA.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	compiler_semantic "github.com/infobloxopen/seal/pkg/compiler/semantic"
+	"github.com/infobloxopen/seal/pkg/provider"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// Actions decided by the evaluator, custom actions match rules but never decide a request
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// Request is the request to decide, with the same layout as the input document of the rego backend
+// except for the subject claims, which the rego backend decodes from the jwt of the input
+type Request struct {
+	Type    string                   `json:"type"`              // swagger type, e.g. petstore.pet
+	Verb    string                   `json:"verb"`              // base verb, e.g. get
+	Ctx     []map[string]interface{} `json:"ctx,omitempty"`     // properties of the objects, a rule matches if one object satisfies its where clause
+	Subject map[string]interface{}   `json:"subject,omitempty"` // claims of the subject, e.g. sub and groups
+}
+
+// Match is a rule matching the request
+type Match struct {
+	Stmt        int                    `json:"stmt"`   // index of the statement in the policies
+	Pos         token.Position         `json:"-"`      // position of the statement in the seal source, if known
+	Action      string                 `json:"action"` // allow, deny or a custom action
+	Rule        string                 `json:"rule"`   // the rule, context statements are linearized into one rule per action
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Obligations []string               `json:"obligations,omitempty"` // conditions deferred to the caller, e.g. "type:petstore.pet; ctx.age > 2"
+}
+
+// Decision is the decision for a request: deny overrides allow,
+// requests matching neither are decided by the default action of the type
+type Decision struct {
+	Action      string   `json:"action"`                // allow, deny, or empty if the type has no default action
+	Matches     []*Match `json:"matches"`               // rules matching the request in policy order, including custom actions
+	Obligations []string `json:"obligations,omitempty"` // obligations of the matching rules of the decided action
+}
+
+// Allow returns true if the request is allowed
+func (d *Decision) Allow() bool {
+	return d.Action == ActionAllow
+}
+
+// Evaluator evaluates policies, it is safe for concurrent use once configured
+type Evaluator struct {
+	swaggerTypes []types.Type
+	swaggerMap   map[string]types.Type // by-name convenience map into swaggerTypes slice
+	rules        []*rule
+	provider     provider.Provider // resolves data references, unresolved references are undefined
+	regexps      regexpCache
+}
+
+// rule is a linearized statement with its where clause prepared for evaluation
+type rule struct {
+	stmt        int
+	st          *ast.ActionStatement
+	typeRe      *regexp.Regexp // matches the types of the type pattern as the rego backend does
+	action      string
+	properties  []*property
+	bodies      []ast.Condition // the rule matches if one body holds, a nil body always holds
+	obligations []string
+}
+
+// property is an action property, values of data references are resolved at decision time
+type property struct {
+	name  string
+	value interface{}
+	ref   *ast.DataReference
+}
+
+// New returns an evaluator of the policies for the swagger types.
+// Policies are checked the same way the rego backend compiles them, e.g. for unknown properties.
+func New(pols *ast.Policies, swaggerTypes []types.Type) (*Evaluator, error) {
+	if pols == nil {
+		return nil, compiler_error.ErrEmptyPolicies
+	}
+
+	e := &Evaluator{
+		swaggerTypes: swaggerTypes,
+		swaggerMap:   map[string]types.Type{},
+		regexps:      regexpCache{},
+	}
+	for _, swt := range swaggerTypes {
+		e.swaggerMap[swt.String()] = swt
+	}
+
+	for idx, stmt := range pols.Statements {
+		var stmts []*ast.ActionStatement
+		switch s := stmt.(type) {
+		case *ast.ActionStatement:
+			stmts = []*ast.ActionStatement{s}
+		case *ast.ContextStatement:
+			stmts = ast.LinearizeContext(s)
+		}
+
+		for _, st := range stmts {
+			r, err := e.compileRule(idx, st)
+			if err != nil {
+				return nil, compiler_error.New(err, idx, stmt.String()).WithPos(stmt.Pos())
+			}
+			e.rules = append(e.rules, r)
+		}
+	}
+
+	return e, nil
+}
+
+// WithDataProvider sets the provider resolving data references, e.g. `$threat.feed["over_21_skus"]`
+func (e *Evaluator) WithDataProvider(p provider.Provider) *Evaluator {
+	e.provider = p
+	return e
+}
+
+// Eval decides the request
+func (e *Evaluator) Eval(req *Request) (*Decision, error) {
+	dcsn := &Decision{Matches: []*Match{}}
+	swt, ok := e.swaggerMap[req.Type]
+	if !ok {
+		return dcsn, nil
+	}
+
+	allowed, denied := false, false
+	for _, r := range e.rules {
+		m, err := e.match(swt, r, req)
+		if err != nil {
+			return nil, compiler_error.New(err, r.stmt, r.st.String()).WithPos(r.st.Pos())
+		}
+		if m == nil {
+			continue
+		}
+
+		dcsn.Matches = append(dcsn.Matches, m)
+		switch m.Action {
+		case ActionAllow:
+			allowed = true
+		case ActionDeny:
+			denied = true
+		}
+	}
+
+	switch {
+	case denied:
+		dcsn.Action = ActionDeny
+	case allowed:
+		dcsn.Action = ActionAllow
+	default:
+		dcsn.Action = swt.DefaultAction()
+	}
+
+	for _, m := range dcsn.Matches {
+		if m.Action == dcsn.Action {
+			dcsn.Obligations = append(dcsn.Obligations, m.Obligations...)
+		}
+	}
+	return dcsn, nil
+}
+
+// match returns the match of the rule, or nil if the rule does not match the request
+func (e *Evaluator) match(swt types.Type, r *rule, req *Request) (*Match, error) {
+	if parts, err := e.mismatches(swt, r, req, false); err != nil || len(parts) > 0 {
+		return nil, err
+	}
+
+	m := &Match{
+		Stmt:        r.stmt,
+		Pos:         r.st.Pos(),
+		Action:      r.action,
+		Rule:        r.st.String(),
+		Obligations: r.obligations,
+	}
+	if len(r.properties) > 0 {
+		m.Properties = map[string]interface{}{}
+		for _, prop := range r.properties {
+			if prop.ref == nil {
+				m.Properties[prop.name] = prop.value
+				continue
+			}
+			value, defined, err := e.resolve(prop.ref)
+			if err != nil {
+				return nil, err
+			}
+			if defined {
+				m.Properties[prop.name] = value
+			}
+		}
+	}
+	return m, nil
+}
+
+// mismatches returns the parts of the rule not matching the request, in the order they are checked:
+// verb, type, subject and where clause. Unless all is set, it stops at the first mismatch.
+func (e *Evaluator) mismatches(swt types.Type, r *rule, req *Request, all bool) ([]string, error) {
+	parts := []string{}
+	if !matchVerb(swt, r.st.Verb.Value, req.Verb) {
+		if parts = append(parts, PartVerb); !all {
+			return parts, nil
+		}
+	}
+	if !r.typeRe.MatchString(req.Type) {
+		if parts = append(parts, PartType); !all {
+			return parts, nil
+		}
+	}
+	if !types.IsNilInterface(r.st.Subject) && !matchSubject(r.st.Subject, req.Subject) {
+		if parts = append(parts, PartSubject); !all {
+			return parts, nil
+		}
+	}
+
+	for _, body := range r.bodies {
+		ok, err := e.holds(body, req)
+		if err != nil || ok {
+			return parts, err
+		}
+	}
+	return append(parts, PartWhere), nil
+}
+
+// matchVerb returns true if the seal verb of the statement is a verb of the type including the base verb of the request
+func matchVerb(swt types.Type, sealVerb, baseVerb string) bool {
+	for _, vrb := range swt.GetVerbs() {
+		if vrb.GetName() != sealVerb {
+			continue
+		}
+		for _, bv := range vrb.GetBaseVerbs() {
+			if bv == baseVerb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSubject returns true if the claims are those of the subject group or user of the statement
+func matchSubject(sub ast.Subject, claims map[string]interface{}) bool {
+	switch s := sub.(type) {
+	case *ast.SubjectGroup:
+		return contains(normalize(claims["groups"]), s.Group)
+	case *ast.SubjectUser:
+		return equal(normalize(claims["sub"]), s.User)
+	}
+	return false
+}
+
+// compileRule prepares the statement for evaluation
+func (e *Evaluator) compileRule(idx int, st *ast.ActionStatement) (*rule, error) {
+	if st.Verb == nil {
+		return nil, compiler_error.ErrEmptyVerb
+	}
+	if st.TypePattern == nil {
+		return nil, compiler_error.ErrEmptyTypePattern
+	}
+	switch st.Subject.(type) {
+	case nil, *ast.SubjectGroup, *ast.SubjectUser:
+	default:
+		return nil, compiler_error.ErrInvalidSubject
+	}
+
+	r := &rule{stmt: idx, st: st, action: st.Token.Literal}
+	typeRe, err := e.regexps.compile(compiler_semantic.TypePattern(st.TypePattern.Value))
+	if err != nil {
+		return nil, err
+	}
+	r.typeRe = typeRe
+
+	props, err := e.compileActionProperties(r.action, st.TypePattern.Value, st.Properties)
+	if err != nil {
+		return nil, err
+	}
+	r.properties = props
+
+	// obligations are only deferred for type patterns naming a single type, as in the rego backend
+	var swtype types.Type
+	if swt, ok := e.swaggerMap[st.TypePattern.Value]; ok {
+		swtype = swt
+	}
+	if r.bodies, r.obligations, err = e.compileWhereClause(swtype, st.WhereClause); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// compileActionProperties types the values of the action properties as the rego backend does
+func (e *Evaluator) compileActionProperties(action, typePattern string, props []*ast.ActionProperty) ([]*property, error) {
+	out := []*property{}
+	for _, prop := range props {
+		value, err := compiler_semantic.ActionPropertyValue(e.swaggerTypes, typePattern, action, prop)
+		if err != nil {
+			return nil, err
+		}
+
+		p := &property{name: prop.Name, value: value}
+		switch v := value.(type) {
+		case json.Number:
+			// numbers are decoded as float64, as the properties decided by the rego backend
+			if p.value, err = v.Float64(); err != nil {
+				return nil, fmt.Errorf("Bad number value '%s' for property '%s' of action '%s'", v, prop.Name, action)
+			}
+		case *ast.DataReference:
+			p.value, p.ref = nil, v
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
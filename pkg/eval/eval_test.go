@@ -0,0 +1,414 @@
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/internal/fixture"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/parser"
+	"github.com/infobloxopen/seal/pkg/provider"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+func newEvaluator(t *testing.T, policies string, swaggers ...string) *Evaluator {
+	t.Helper()
+	pols, swaggerTypes := fixture.Parse(t, "", policies, swaggers...)
+	e, err := New(pols, swaggerTypes)
+	if err != nil {
+		t.Fatalf("could not create evaluator: %s", err)
+	}
+	return e
+}
+
+// TestEvalPetstore mirrors cases of petstore.all.test.rego
+func TestEvalPetstore(t *testing.T) {
+	data := provider.Document{}
+	if err := json.Unmarshal([]byte(fixture.ReadFile(t, "petstore.all.mock.json")), &data); err != nil {
+		t.Fatalf("could not decode mock data: %s", err)
+	}
+	e := newEvaluator(t, fixture.ReadFile(t, "petstore.all.seal"), fixture.PetstoreSwaggers(t)...).WithDataProvider(data)
+
+	tests := []struct {
+		name        string
+		req         *Request
+		action      string
+		stmts       []int // statements of the matching rules
+		obligations []string
+	}{
+		{
+			name: "in subject groups",
+			req: &Request{Type: "petstore.order", Verb: "deliver",
+				Subject: map[string]interface{}{"iss": "not_petstore.swagger.io", "groups": []interface{}{"boss", "everyone"}}},
+			action: "deny",
+			stmts:  []int{0},
+		},
+		{
+			name: "in array literal",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io"},
+				Ctx:     []map[string]interface{}{{"breed": "mongrel"}}},
+			action: "allow",
+			stmts:  []int{1},
+		},
+		{
+			name: "in array literal negative",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io"},
+				Ctx:     []map[string]interface{}{{"breed": "snoopy"}}},
+			action: "deny",
+			stmts:  []int{},
+		},
+		{
+			name: "not operator precedence",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"groups": []string{"not_operator_precedence"}},
+				Ctx:     []map[string]interface{}{{"neutered": false, "potty_trained": true}}},
+			action: "allow",
+			stmts:  []int{11},
+		},
+		{
+			name: "not operator precedence negative",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"groups": []string{"not_operator_precedence"}},
+				Ctx:     []map[string]interface{}{{"neutered": true, "potty_trained": true}}},
+			action: "deny",
+			stmts:  []int{},
+		},
+		{
+			name: "negated conjunction",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"groups": []string{"fussy"}},
+				Ctx:     []map[string]interface{}{{"neutered": true, "potty_trained": false}}},
+			action: "allow",
+			stmts:  []int{10},
+		},
+		{
+			name: "negations of undefined properties",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"groups": []string{"fussy"}}},
+			action: "deny",
+			stmts:  []int{9, 10},
+		},
+		{
+			name: "regexp",
+			req: &Request{Type: "petstore.pet", Verb: "get",
+				Subject: map[string]interface{}{"iss": "not_petstore.swagger.io", "jti": "@petstore.swagger.io", "groups": []string{"regexp", "test"}}},
+			action: "deny",
+			stmts:  []int{4},
+		},
+		{
+			name: "regexp negative",
+			req: &Request{Type: "petstore.pet", Verb: "watch",
+				Subject: map[string]interface{}{"iss": "not_petstore.swagger.io", "jti": "just test regexp params", "groups": []string{"regexp", "test"}}},
+			action: "allow",
+			stmts:  []int{17},
+		},
+		{
+			name: "ctx properties of the same object",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io", "groups": []string{"everyone"}},
+				Ctx: []map[string]interface{}{
+					{"age": 1, "name": "NotSpecificPetName"},
+					{"age": 3, "name": "specificPetName"},
+				}},
+			action: "deny",
+			stmts:  []int{},
+		},
+		{
+			name: "ctx properties of one of the objects",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io", "groups": []string{"everyone"}},
+				Ctx: []map[string]interface{}{
+					{"age": 3, "name": "specificPetName"},
+					{"age": 2, "name": "specificPetName"},
+				}},
+			action: "deny",
+			stmts:  []int{6},
+		},
+		{
+			name: "external data",
+			req: &Request{Type: "petstore.pet", Verb: "provision",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io", "groups": []string{"everyone"}},
+				Ctx:     []map[string]interface{}{{"category": map[string]interface{}{"name": "giant panda"}}}},
+			action: "deny",
+			stmts:  []int{12},
+		},
+		{
+			name: "external data negative",
+			req: &Request{Type: "petstore.pet", Verb: "provision",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io", "groups": []string{"everyone"}},
+				Ctx:     []map[string]interface{}{{"category": map[string]interface{}{"name": "dog"}}}},
+			action: "deny",
+			stmts:  []int{},
+		},
+		{
+			name: "tags",
+			req: &Request{Type: "petstore.pet", Verb: "buy",
+				Subject: map[string]interface{}{"iss": "petstore.swagger.io", "groups": []string{"everyone"}},
+				Ctx:     []map[string]interface{}{{"tags": map[string]string{"endangered": "true"}}}},
+			action: "deny",
+			stmts:  []int{13},
+		},
+		{
+			name: "base verb of a glob type pattern",
+			req: &Request{Type: "petstore.user", Verb: "update",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "groups": []string{"operators"}}},
+			action: "allow",
+			stmts:  []int{14},
+		},
+		{
+			name: "deny overrides allow",
+			req: &Request{Type: "petstore.user", Verb: "update",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "groups": []string{"operators", "banned"}}},
+			action: "deny",
+			stmts:  []int{7, 14},
+		},
+		{
+			name: "context statement",
+			req: &Request{Type: "petstore.order", Verb: "get",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "groups": []string{"managers"}},
+				Ctx:     []map[string]interface{}{{"id": "-1"}}},
+			action: "deny",
+			stmts:  []int{2, 15},
+		},
+		{
+			name: "subject user",
+			req: &Request{Type: "petstore.order", Verb: "create",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "sub": "cto@petstore.swagger.io"}},
+			action: "allow",
+			stmts:  []int{16},
+		},
+		{
+			name: "obligation",
+			req: &Request{Type: "petstore.order", Verb: "list",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "groups": []string{"employees"}},
+				Ctx:     []map[string]interface{}{{"status": "delivered"}}},
+			action:      "allow",
+			stmts:       []int{22},
+			obligations: []string{`type:petstore.order; (ctx.marketplace != "amazon")`},
+		},
+		{
+			name: "obligations of several conditions",
+			req: &Request{Type: "petstore.user", Verb: "get",
+				Subject: map[string]interface{}{"iss": "context.petstore.swagger.io", "groups": []string{"supervisors"}},
+				Ctx:     []map[string]interface{}{{"email": "road-runner@acme.com"}}},
+			action:      "allow",
+			stmts:       []int{23},
+			obligations: []string{`type:petstore.user; ((ctx.occupation != "unemployed") and (ctx.salary > 200000))`},
+		},
+		{
+			name: "unknown type",
+			req: &Request{Type: "petstore.unknown", Verb: "get",
+				Subject: map[string]interface{}{"groups": []string{"managers"}}},
+			action: "",
+			stmts:  []int{},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			dcsn, err := e.Eval(tst.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if dcsn.Action != tst.action {
+				t.Errorf("expected action %q, got %q", tst.action, dcsn.Action)
+			}
+			stmts := []int{}
+			for _, m := range dcsn.Matches {
+				stmts = append(stmts, m.Stmt)
+			}
+			if !reflect.DeepEqual(stmts, tst.stmts) {
+				t.Errorf("expected matching statements %v, got %v", tst.stmts, stmts)
+			}
+			if !reflect.DeepEqual(dcsn.Obligations, tst.obligations) {
+				t.Errorf("expected obligations %q, got %q", tst.obligations, dcsn.Obligations)
+			}
+		})
+	}
+}
+
+func TestEvalActions(t *testing.T) {
+	e := newEvaluator(t, `
+redirect (to="911", priority="1") subject group everyone to use support.ticket where ctx.severity == "critical";
+redirect (to=$support.oncall) to manage support.ticket where ctx.labels["team"]["name"] == "sre";
+deny to close support.ticket where ctx.severity == "low" or ctx.queue == "spam";
+deny to use support.ticket where ctx.severity in $support.closed and ctx.queue != "vip";
+`).WithDataProvider(provider.Document{
+		"support": map[string]interface{}{
+			"oncall": "sre-pager",
+			"closed": []interface{}{"resolved", "wontfix"},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		req         *Request
+		action      string
+		matches     []*Match
+		obligations []string
+	}{
+		{
+			name: "custom action properties",
+			req: &Request{Type: "support.ticket", Verb: "get",
+				Subject: map[string]interface{}{"groups": []string{"everyone"}},
+				Ctx:     []map[string]interface{}{{"severity": "critical"}}},
+			action: "allow",
+			matches: []*Match{{Stmt: 0, Action: "redirect",
+				Rule:       `redirect (to="911", priority="1") subject group everyone to use support.ticket where (ctx.severity == "critical");`,
+				Properties: map[string]interface{}{"to": "911", "priority": float64(1)}}},
+		},
+		{
+			name: "data reference of a nested tag",
+			req: &Request{Type: "support.ticket", Verb: "create",
+				Ctx: []map[string]interface{}{{"labels": map[string]interface{}{"team": map[string]interface{}{"name": "sre"}}}}},
+			action: "allow",
+			matches: []*Match{{Stmt: 1, Action: "redirect",
+				Rule:       `redirect (to=$support.oncall) to manage support.ticket where (ctx.labels["team"]["name"] == "sre");`,
+				Properties: map[string]interface{}{"to": "sre-pager"}}},
+		},
+		{
+			name: "data reference in condition",
+			req: &Request{Type: "support.ticket", Verb: "update",
+				Ctx: []map[string]interface{}{{"severity": "wontfix"}}},
+			action: "deny",
+			matches: []*Match{{Stmt: 3, Action: "deny",
+				Rule:        `deny to use support.ticket where ((ctx.severity in $support.closed) and (ctx.queue != "vip"));`,
+				Obligations: []string{`type:support.ticket; (ctx.queue != "vip")`}}},
+			obligations: []string{`type:support.ticket; (ctx.queue != "vip")`},
+		},
+		{
+			name:   "or deferred to obligation",
+			req:    &Request{Type: "support.ticket", Verb: "delete"},
+			action: "deny",
+			matches: []*Match{{Stmt: 2, Action: "deny",
+				Rule:        `deny to close support.ticket where ((ctx.severity == "low") or (ctx.queue == "spam"));`,
+				Obligations: []string{`type:support.ticket; ((ctx.severity == "low") or (ctx.queue == "spam"))`}}},
+			obligations: []string{`type:support.ticket; ((ctx.severity == "low") or (ctx.queue == "spam"))`},
+		},
+		{
+			name:    "default action",
+			req:     &Request{Type: "support.ticket", Verb: "get"},
+			action:  "allow",
+			matches: []*Match{},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			dcsn, err := e.Eval(tst.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if dcsn.Action != tst.action {
+				t.Errorf("expected action %q, got %q", tst.action, dcsn.Action)
+			}
+			for _, m := range dcsn.Matches {
+				m.Pos = token.Position{} // positions are not compared
+			}
+			if !reflect.DeepEqual(dcsn.Matches, tst.matches) {
+				t.Errorf("expected matches:\n%s\ngot:\n%s", toJSON(tst.matches), toJSON(dcsn.Matches))
+			}
+			if !reflect.DeepEqual(dcsn.Obligations, tst.obligations) {
+				t.Errorf("expected obligations %q, got %q", tst.obligations, dcsn.Obligations)
+			}
+		})
+	}
+}
+
+func TestEvalActionPropertyTypes(t *testing.T) {
+	// the types of separate swagger specs declare actions of the same name with different properties
+	pagerSwagger := `
+openapi: "3.0.0"
+components:
+  schemas:
+    redirect:
+      type: object
+      properties:
+        priority:
+          type: string
+      x-seal-type: action
+    pager.alert:
+      type: object
+      x-seal-actions: [ "allow", "redirect" ]
+      x-seal-verbs:
+        use: [ "get" ]
+      x-seal-default-action: allow
+      properties:
+        id:
+          type: string
+`
+	var swaggerTypes []types.Type
+	for _, spec := range []string{pagerSwagger, fixture.Swagger} {
+		tps, err := types.NewTypeFromOpenAPIv3([]byte(spec))
+		if err != nil {
+			t.Fatalf("could not load swagger: %s", err)
+		}
+		swaggerTypes = append(swaggerTypes, tps...)
+	}
+
+	p := parser.New(lexer.New(`
+redirect (priority="1") to use support.ticket;
+redirect (priority="1") to use pager.alert;
+`), swaggerTypes)
+	pols := p.ParsePolicies()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("could not parse policies: %v", p.Errors())
+	}
+	e, err := New(pols, swaggerTypes)
+	if err != nil {
+		t.Fatalf("could not create evaluator: %s", err)
+	}
+
+	// the properties are typed by the action of the type matching the statement
+	for typ, expected := range map[string]interface{}{
+		"support.ticket": float64(1),
+		"pager.alert":    "1",
+	} {
+		dcsn, err := e.Eval(&Request{Type: typ, Verb: "get"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(dcsn.Matches) != 1 || !reflect.DeepEqual(dcsn.Matches[0].Properties, map[string]interface{}{"priority": expected}) {
+			t.Errorf("%s: expected priority %#v, got matches:\n%s", typ, expected, toJSON(dcsn.Matches))
+		}
+	}
+}
+
+func toJSON(v interface{}) string {
+	js, _ := json.MarshalIndent(v, "", "  ")
+	return string(js)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		err      string
+	}{
+		{
+			name:     "invalid pattern",
+			policies: `allow to use support.ticket where ctx.id =~ "(";`,
+			err:      `compiler_rego: at 1:1 allow to use support.ticket where (ctx.id =~ "("); due to error: invalid pattern '(': error parsing regexp: missing closing ): ` + "`(`",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			pols, swaggerTypes := fixture.Parse(t, "", tst.policies)
+			_, err := New(pols, swaggerTypes)
+			if err == nil || err.Error() != tst.err {
+				t.Errorf("expected error %q, got %v", tst.err, err)
+			}
+		})
+	}
+
+	if _, err := New(nil, nil); !errors.Is(err, compiler_error.ErrEmptyPolicies) {
+		t.Errorf("expected empty policies error, got %v", err)
+	}
+}
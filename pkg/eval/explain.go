@@ -0,0 +1,90 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	compiler_error "github.com/infobloxopen/seal/pkg/compiler/error"
+	"github.com/infobloxopen/seal/pkg/token"
+)
+
+// Parts of a rule matched against a request
+const (
+	PartVerb    = "verb"
+	PartType    = "type"
+	PartSubject = "subject"
+	PartWhere   = "where"
+)
+
+// Miss is a near-miss rule: a rule not matching the request on a single part
+type Miss struct {
+	Stmt   int            `json:"stmt"`   // index of the statement in the policies
+	Pos    token.Position `json:"-"`      // position of the statement in the seal source, if known
+	Action string         `json:"action"` // allow, deny or a custom action
+	Rule   string         `json:"rule"`   // the rule, context statements are linearized into one rule per action
+	Part   string         `json:"part"`   // verb, type, subject or where
+	Reason string         `json:"reason"` // why the part does not match, e.g. "the subject is not in group customers"
+}
+
+// Explanation is the decision for a request with the near-miss rules
+type Explanation struct {
+	*Decision
+	NearMisses []*Miss `json:"near_misses"` // in policy order
+}
+
+// Explain decides the request, and returns the rules which would have matched it but for
+// a single part: the verb, the type pattern, the subject or the where clause
+func (e *Evaluator) Explain(req *Request) (*Explanation, error) {
+	dcsn, err := e.Eval(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expl := &Explanation{Decision: dcsn, NearMisses: []*Miss{}}
+	swt, ok := e.swaggerMap[req.Type]
+	if !ok {
+		return expl, nil
+	}
+
+	for _, r := range e.rules {
+		parts, err := e.mismatches(swt, r, req, true)
+		if err != nil {
+			return nil, compiler_error.New(err, r.stmt, r.st.String()).WithPos(r.st.Pos())
+		}
+		if len(parts) != 1 {
+			continue
+		}
+
+		expl.NearMisses = append(expl.NearMisses, &Miss{
+			Stmt:   r.stmt,
+			Pos:    r.st.Pos(),
+			Action: r.action,
+			Rule:   r.st.String(),
+			Part:   parts[0],
+			Reason: reason(r.st, parts[0], req),
+		})
+	}
+	return expl, nil
+}
+
+// reason describes why the part of the statement does not match the request
+func reason(st *ast.ActionStatement, part string, req *Request) string {
+	switch part {
+	case PartVerb:
+		return fmt.Sprintf("verb %s of %s does not include %s", st.Verb.Value, req.Type, req.Verb)
+	case PartType:
+		return fmt.Sprintf("type %s does not match %s", req.Type, st.TypePattern.Value)
+	case PartSubject:
+		switch s := st.Subject.(type) {
+		case *ast.SubjectGroup:
+			return fmt.Sprintf("the subject is not in group %s", s.Group)
+		case *ast.SubjectUser:
+			return fmt.Sprintf("the subject is not user %s", s.User)
+		}
+	case PartWhere:
+		if wc, ok := st.WhereClause.(*ast.WhereClause); ok {
+			return fmt.Sprintf("where clause %s does not hold", wc.Condition)
+		}
+	}
+	return part + " does not match"
+}
@@ -0,0 +1,87 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	e := newEvaluator(t, `
+allow subject group agents to use support.ticket where ctx.severity == "low";
+allow subject group agents to manage support.ticket;
+allow subject group admins to use support.ticket;
+deny subject group agents to close support.ticket where ctx.queue == "vip";
+allow subject group agents to use support.* where ctx.severity == "high";
+redirect (to="911") to use support.ticket where ctx.severity == "critical";
+`)
+
+	tests := []struct {
+		name       string
+		req        *Request
+		action     string
+		matches    []int
+		nearMisses []string
+	}{
+		{
+			name: "near misses on each part",
+			req: &Request{Type: "support.ticket", Verb: "get",
+				Subject: map[string]interface{}{"groups": []string{"agents"}},
+				Ctx:     []map[string]interface{}{{"severity": "high"}}},
+			action:  "allow",
+			matches: []int{1, 4},
+			nearMisses: []string{
+				"stmt0 where: where clause (ctx.severity == \"low\") does not hold",
+				"stmt2 subject: the subject is not in group admins",
+				"stmt3 verb: verb close of support.ticket does not include get",
+				"stmt5 where: where clause (ctx.severity == \"critical\") does not hold",
+			},
+		},
+		{
+			name: "rules missing on several parts are not near misses",
+			req: &Request{Type: "support.ticket", Verb: "delete",
+				Subject: map[string]interface{}{"groups": []string{"admins"}},
+				Ctx:     []map[string]interface{}{{"queue": "vip"}}},
+			action:  "allow",
+			matches: []int{},
+			nearMisses: []string{
+				"stmt1 subject: the subject is not in group agents",
+				"stmt2 verb: verb use of support.ticket does not include delete",
+				"stmt3 subject: the subject is not in group agents",
+			},
+		},
+		{
+			name:       "unknown type",
+			req:        &Request{Type: "support.queue", Verb: "get"},
+			action:     "",
+			matches:    []int{},
+			nearMisses: []string{},
+		},
+	}
+
+	for _, tst := range tests {
+		expl, err := e.Explain(tst.req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tst.name, err)
+		}
+		if expl.Action != tst.action {
+			t.Errorf("%s: expected action %q, got %q", tst.name, tst.action, expl.Action)
+		}
+
+		matches := []int{}
+		for _, m := range expl.Matches {
+			matches = append(matches, m.Stmt)
+		}
+		if !reflect.DeepEqual(matches, tst.matches) {
+			t.Errorf("%s: expected matches %v, got %v", tst.name, tst.matches, matches)
+		}
+
+		nearMisses := []string{}
+		for _, m := range expl.NearMisses {
+			nearMisses = append(nearMisses, fmt.Sprintf("stmt%d %s: %s", m.Stmt, m.Part, m.Reason))
+		}
+		if !reflect.DeepEqual(nearMisses, tst.nearMisses) {
+			t.Errorf("%s: expected near misses:\n%q\ngot:\n%q", tst.name, tst.nearMisses, nearMisses)
+		}
+	}
+}
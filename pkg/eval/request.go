@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// input is the layout of the rego input document, the subject claims are decoded from the jwt
+type input struct {
+	Request
+	JWT string `json:"jwt,omitempty"`
+}
+
+// ParseRequests parses a JSON or YAML request document, or a list of request documents,
+// with the layout of the rego input document, e.g.
+//
+//	{"type": "petstore.pet", "verb": "buy", "ctx": [{"status": "available"}], "jwt": "..."}
+//
+// Subject claims are decoded from the jwt without verifying its signature, as io.jwt.decode does,
+// and merged with the claims of the subject field, if any.
+func ParseRequests(data []byte) ([]*Request, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse request: %s", err)
+	}
+
+	inputs := []*input{}
+	js = bytes.TrimSpace(js)
+	if bytes.HasPrefix(js, []byte("[")) {
+		err = json.Unmarshal(js, &inputs)
+	} else {
+		in := &input{}
+		err = json.Unmarshal(js, in)
+		inputs = append(inputs, in)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse request: %s", err)
+	}
+
+	reqs := []*Request{}
+	for _, in := range inputs {
+		req := in.Request
+		if in.JWT != "" {
+			claims, err := DecodeJWT(in.JWT)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range req.Subject {
+				claims[k] = v
+			}
+			req.Subject = claims
+		}
+		reqs = append(reqs, &req)
+	}
+	return reqs, nil
+}
+
+// DecodeJWT returns the claims of the payload of the JSON web token, without verifying its signature
+func DecodeJWT(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid jwt: expected 3 parts, got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt payload: %s", err)
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid jwt payload: %s", err)
+	}
+	return claims, nil
+}
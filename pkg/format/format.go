@@ -0,0 +1,307 @@
+// Package format rewrites seal policy files in a canonical layout.
+//
+// The formatter works on the token stream rather than the AST so that comments and
+// the parentheses chosen by the author are kept. The canonical layout is:
+//
+//   - one statement per line, blocks indented by four spaces
+//   - single spaces between tokens, none inside brackets or around property assignments
+//   - a `where` the author put on its own line is indented by four spaces, and `and`/`or`
+//     continuation lines are right-aligned with the `where` keyword
+//   - trailing comments of consecutive lines are aligned
+//   - runs of blank lines are collapsed to a single blank line
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/token"
+)
+
+const indentWidth = 4
+
+// Source formats the seal source src. fileName is only used in error messages.
+func Source(fileName string, src []byte) ([]byte, error) {
+	toks, err := tokenize(fileName, string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &formatter{toks: toks, whereCol: -1, stmtStart: true}
+	for idx := range toks {
+		f.emit(idx)
+	}
+	f.flush()
+
+	return []byte(f.String()), nil
+}
+
+func tokenize(fileName, src string) ([]token.Token, error) {
+	lex := lexer.New(src).WithFile(fileName)
+	toks := []token.Token{}
+	for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
+		if tok.Type == token.ILLEGAL {
+			return nil, fmt.Errorf("%s", tok.Pos.Message(fmt.Sprintf("illegal token %q", tok.Literal)))
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+// line is a single output line
+type line struct {
+	indent  int
+	code    string
+	comment string
+	blank   bool
+}
+
+func (l *line) width() int {
+	return l.indent + len(l.code)
+}
+
+type formatter struct {
+	toks      []token.Token
+	lines     []*line
+	cur       *line       // line being written, nil if the next token starts a new line
+	depth     int         // block nesting
+	stmtStart bool        // the next token starts a new statement
+	whereCol  int         // column of the `where` keyword of the current statement, -1 if none
+	prev      token.Token // previously emitted token, including comments
+	prevCode  token.Token // previously emitted token, excluding comments
+}
+
+func (f *formatter) emit(idx int) {
+	tok := f.toks[idx]
+	if tok.Type == token.COMMENT {
+		f.emitComment(tok)
+	} else {
+		f.emitCode(idx)
+	}
+	f.prev = tok
+}
+
+func (f *formatter) emitComment(tok token.Token) {
+	text := "#" + strings.TrimRight(tok.Literal, " \t")
+
+	// trailing comment
+	if f.cur != nil && f.prev.Pos.Line == tok.Pos.Line {
+		f.cur.comment = text
+		f.flush()
+		return
+	}
+
+	f.flush()
+	indent := f.depth * indentWidth
+	if !f.stmtStart {
+		indent += indentWidth
+	}
+	f.blankLine(tok)
+	f.lines = append(f.lines, &line{indent: indent, comment: text})
+}
+
+func (f *formatter) emitCode(idx int) {
+	tok, next := f.toks[idx], f.peek(idx)
+	if tok.Type == token.CLOSE_BLOCK && f.depth > 0 {
+		f.depth--
+	}
+	if !f.stmtStart && startsStatement(f.prevCode, tok) {
+		// the previous statement was not ended with `;`
+		f.endStatement()
+	}
+
+	switch {
+	case f.cur != nil && tok.Type == token.DELIMETER:
+		// statement delimiters always stay on the line of the statement
+	case f.cur == nil || f.stmtStart:
+		f.blankLine(tok)
+		f.newLine(f.continuationIndent(tok))
+	case tok.Type == token.CLOSE_BLOCK:
+		f.newLine(f.depth * indentWidth)
+	case (tok.Type == token.WHERE || tok.Type == token.AND || tok.Type == token.OR) && tok.Pos.Line > f.prev.Pos.Line,
+		tok.Type == token.WHERE && f.isMultiLine(idx):
+		f.newLine(f.continuationIndent(tok))
+	default:
+		if needsSpace(f.prevCode, tok) {
+			f.cur.code += " "
+		}
+	}
+
+	if tok.Type == token.WHERE {
+		f.whereCol = f.cur.width()
+	}
+	f.cur.code += literal(tok)
+	f.prevCode = tok
+
+	switch tok.Type {
+	case token.DELIMETER:
+		f.endStatement()
+	case token.OPEN_BLOCK:
+		f.depth++
+		f.endStatement()
+	case token.CLOSE_BLOCK:
+		// a context statement continues with `to <verb> {` after its first block
+		if next.Type != token.TO && next.Type != token.DELIMETER {
+			f.endStatement()
+		} else {
+			f.stmtStart = false
+		}
+	default:
+		f.stmtStart = false
+	}
+}
+
+// peek returns the token following idx, or EOF
+func (f *formatter) peek(idx int) token.Token {
+	if idx+1 < len(f.toks) {
+		return f.toks[idx+1]
+	}
+	return token.Token{Type: token.EOF}
+}
+
+// isMultiLine returns true if the author broke the where clause starting at idx
+// before one of its `and`/`or` operators
+func (f *formatter) isMultiLine(idx int) bool {
+	prev := f.toks[idx]
+	for i := idx + 1; i < len(f.toks); i++ {
+		tok := f.toks[i]
+		if tok.Type == token.COMMENT {
+			continue
+		}
+		switch {
+		case tok.Type == token.DELIMETER, tok.Type == token.OPEN_BLOCK, tok.Type == token.CLOSE_BLOCK,
+			startsStatement(prev, tok):
+			return false
+		case (tok.Type == token.AND || tok.Type == token.OR) && tok.Pos.Line > prev.Pos.Line:
+			return true
+		}
+		prev = tok
+	}
+	return false
+}
+
+// startsStatement returns true if tok starts a statement following a statement not ended with `;`:
+// an action or a context on a new line after a token which can end a statement
+func startsStatement(prev, tok token.Token) bool {
+	if tok.Pos.Line <= prev.Pos.Line {
+		return false
+	}
+	switch tok.Type {
+	case token.CONTEXT:
+		return true
+	case token.IDENT:
+		switch prev.Type {
+		case token.IDENT, token.TYPE_PATTERN, token.LITERAL, token.INT, token.DATA_REF, token.CLOSE_PAREN, token.CLOSE_SQ:
+			return true
+		}
+	}
+	return false
+}
+
+// continuationIndent returns the indentation of a line starting with tok
+func (f *formatter) continuationIndent(tok token.Token) int {
+	base := f.depth * indentWidth
+	if f.stmtStart || tok.Type == token.CLOSE_BLOCK {
+		return base
+	}
+	switch tok.Type {
+	case token.AND, token.OR:
+		if f.whereCol >= 0 {
+			return f.whereCol + len(token.WHERE) - len(tok.Literal)
+		}
+	}
+	return base + indentWidth
+}
+
+func (f *formatter) endStatement() {
+	f.stmtStart = true
+	f.whereCol = -1
+}
+
+// blankLine keeps a single blank line before tok if the author separated it from the previous token
+func (f *formatter) blankLine(tok token.Token) {
+	if len(f.lines) == 0 && f.cur == nil || tok.Type == token.CLOSE_BLOCK {
+		return
+	}
+	if tok.Pos.Line-f.prev.Pos.Line < 2 {
+		return
+	}
+	f.flush()
+	if last := f.lines[len(f.lines)-1]; last.blank || strings.HasSuffix(last.code, token.OPEN_BLOCK) && last.comment == "" {
+		return
+	}
+	f.lines = append(f.lines, &line{blank: true})
+}
+
+func (f *formatter) newLine(indent int) {
+	f.flush()
+	f.cur = &line{indent: indent}
+}
+
+func (f *formatter) flush() {
+	if f.cur != nil {
+		f.lines = append(f.lines, f.cur)
+		f.cur = nil
+	}
+}
+
+// String renders the lines, aligning the trailing comments of consecutive lines
+func (f *formatter) String() string {
+	var b strings.Builder
+	for start := 0; start < len(f.lines); {
+		end := start + 1
+		if hasTrailingComment(f.lines[start]) {
+			for end < len(f.lines) && hasTrailingComment(f.lines[end]) {
+				end++
+			}
+		}
+
+		col := 0
+		for _, l := range f.lines[start:end] {
+			if l.width() > col {
+				col = l.width()
+			}
+		}
+
+		for _, l := range f.lines[start:end] {
+			if !l.blank {
+				b.WriteString(strings.Repeat(" ", l.indent))
+				b.WriteString(l.code)
+				if l.comment != "" {
+					if l.code != "" {
+						b.WriteString(strings.Repeat(" ", col-l.width()+1))
+					}
+					b.WriteString(l.comment)
+				}
+			}
+			b.WriteString("\n")
+		}
+		start = end
+	}
+	return b.String()
+}
+
+func hasTrailingComment(l *line) bool {
+	return l.code != "" && l.comment != ""
+}
+
+// needsSpace returns true if a space separates the tokens prev and tok on the same line
+func needsSpace(prev, tok token.Token) bool {
+	switch prev.Type {
+	case token.OPEN_PAREN, token.OPEN_SQ, token.ASSIGN:
+		return false
+	}
+	switch tok.Type {
+	case token.CLOSE_PAREN, token.CLOSE_SQ, token.COMMA, token.DELIMETER, token.ASSIGN:
+		return false
+	}
+	return true
+}
+
+func literal(tok token.Token) string {
+	if tok.Type == token.LITERAL {
+		return `"` + tok.Literal + `"`
+	}
+	return tok.Literal
+}
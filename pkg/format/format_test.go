@@ -0,0 +1,189 @@
+package format
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tcases := []struct {
+		name     string
+		input    string
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "spacing",
+			input:    `allow  (log = "true")subject group   x to  manage petstore.pet where ctx.a==1 and ctx.b in [ "a" , "b" ] ;`,
+			expected: "allow (log=\"true\") subject group x to manage petstore.pet where ctx.a == 1 and ctx.b in [\"a\", \"b\"];\n",
+		},
+		{
+			name: "statements and blank lines",
+			input: `
+
+allow to inspect petstore.pet; deny to buy petstore.pet;
+
+
+
+deny to sell petstore.pet
+;
+`,
+			expected: `allow to inspect petstore.pet;
+deny to buy petstore.pet;
+
+deny to sell petstore.pet;
+`,
+		},
+		{
+			name: "multi-line where",
+			input: `allow subject group employees to inspect petstore.order
+   where ctx.status == "delivered" and ctx.marketplace != "amazon";
+deny to buy petstore.pet where ctx.age <= 2
+  and (ctx.name == "a"
+  or ctx.name == "b");
+`,
+			expected: `allow subject group employees to inspect petstore.order
+    where ctx.status == "delivered" and ctx.marketplace != "amazon";
+deny to buy petstore.pet
+    where ctx.age <= 2
+      and (ctx.name == "a"
+       or ctx.name == "b");
+`,
+		},
+		{
+			name: "context",
+			input: `context {
+where ctx.id == "-1";
+      subject group x where subject.iss != "a"
+  and ctx.id == "1";
+}   to use {
+
+deny petstore.order;
+   deny petstore.user;}
+allow to inspect petstore.pet;
+`,
+			expected: `context {
+    where ctx.id == "-1";
+    subject group x
+        where subject.iss != "a"
+          and ctx.id == "1";
+} to use {
+    deny petstore.order;
+    deny petstore.user;
+}
+allow to inspect petstore.pet;
+`,
+		},
+		{
+			name: "comments",
+			input: `#header
+# second line
+
+
+allow to inspect petstore.pet;  # anyone
+allow subject group everyone to inspect petstore.pet;   # everyone
+
+context {
+  # inside
+  where ctx.id == "-1"; # trailing
+} to use {
+  deny petstore.order;
+}
+deny to buy petstore.pet
+  # before where
+  where ctx.age <= 2 # age
+  and ctx.name == "a";
+`,
+			expected: `#header
+# second line
+
+allow to inspect petstore.pet;                        # anyone
+allow subject group everyone to inspect petstore.pet; # everyone
+
+context {
+    # inside
+    where ctx.id == "-1"; # trailing
+} to use {
+    deny petstore.order;
+}
+deny to buy petstore.pet
+    # before where
+    where ctx.age <= 2 # age
+      and ctx.name == "a";
+`,
+		},
+		{
+			name: "statements without delimiter",
+			input: `allow subject group foo to buy petstore.pet where ctx.name == "bar"  # c
+allow to inspect petstore.pet
+deny to buy petstore.pet where ctx.age <= 2
+  and ctx.name == "a"
+context {
+  where ctx.id == "-1"
+} to use {
+  deny petstore.order
+  deny subject group x petstore.user
+}
+`,
+			expected: `allow subject group foo to buy petstore.pet where ctx.name == "bar" # c
+allow to inspect petstore.pet
+deny to buy petstore.pet
+    where ctx.age <= 2
+      and ctx.name == "a"
+context {
+    where ctx.id == "-1"
+} to use {
+    deny petstore.order
+    deny subject group x petstore.user
+}
+`,
+		},
+		{
+			name:     "illegal",
+			input:    "allow to inspect petstore.pet;\n  deny ! to buy petstore.pet;",
+			errorMsg: `test.seal:2:8: illegal token "!"`,
+		},
+	}
+
+	for _, tcase := range tcases {
+		actual, err := Source("test.seal", []byte(tcase.input))
+		if tcase.errorMsg != "" {
+			if err == nil || err.Error() != tcase.errorMsg {
+				t.Errorf("tcase %s: expected error %q, got: %v", tcase.name, tcase.errorMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tcase %s: unexpected error: %s", tcase.name, err)
+			continue
+		}
+		if string(actual) != tcase.expected {
+			t.Errorf("tcase %s: expected:\n%s\ngot:\n%s", tcase.name, tcase.expected, actual)
+			continue
+		}
+
+		again, err := Source("test.seal", actual)
+		if err != nil || string(again) != string(actual) {
+			t.Errorf("tcase %s: formatting is not idempotent, got:\n%s", tcase.name, again)
+		}
+	}
+}
+
+func TestSourceExamples(t *testing.T) {
+	for _, fil := range []string{
+		"../../docs/source/examples/petstore/petstore.all.seal",
+	} {
+		input, err := ioutil.ReadFile(fil)
+		if err != nil {
+			t.Fatalf("could not read %s: %s", fil, err)
+		}
+		actual, err := Source(fil, input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", fil, err)
+			continue
+		}
+		if string(actual) != string(input) {
+			t.Errorf("%s is not formatted, run `seal fmt -w %s`", fil, fil)
+		}
+	}
+}
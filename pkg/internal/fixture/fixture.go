@@ -0,0 +1,146 @@
+// Package fixture holds the swagger and the helpers shared by the tests of the packages
+// working on parsed policies: the evaluator, seal tests, test generation, lint and access.
+package fixture
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/compiler"
+	compiler_rego "github.com/infobloxopen/seal/pkg/compiler/rego"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// Swagger declares the shop and support types used by the tests
+const Swagger = `
+openapi: "3.0.0"
+components:
+  schemas:
+    redirect:
+      type: object
+      properties:
+        to:
+          type: string
+        priority:
+          type: integer
+      x-seal-type: action
+    shop.pet:
+      type: object
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        buy:    [ "buy" ]
+        read:   [ "get" ]
+        manage: [ "get", "update" ]
+      x-seal-default-action: deny
+      properties:
+        age:
+          type: integer
+        status:
+          type: string
+          enum:
+          - "available"
+          - "sold"
+        neutered:
+          type: boolean
+        email:
+          type: string
+    shop.order:
+      type: object
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        manage: [ "get", "delete" ]
+        ship:   [ "ship" ]
+      x-seal-default-action: allow
+      properties:
+        id:
+          type: string
+    support.ticket:
+      type: object
+      x-seal-actions:
+      - allow
+      - deny
+      - redirect
+      x-seal-verbs:
+        use:    [ "update", "get" ]
+        manage: [ "create", "delete", "update", "get" ]
+        close:  [ "delete" ]
+      x-seal-default-action: allow
+      properties:
+        id:
+          type: string
+        severity:
+          type: string
+        queue:
+          type: string
+          x-seal-obligation: true
+        labels:
+          $ref: '#/components/schemas/tag'
+    tag:
+      type: object
+      additionalProperties: true
+      x-seal-type: none
+    subject:
+      type: object
+      properties:
+        sub:
+          type: string
+        groups:
+          type: array
+          items:
+            type: string
+        age:
+          type: integer
+        status:
+          type: string
+      x-seal-type: none
+`
+
+// Parse parses the policies of the file against the swaggers, Swagger if none is given
+func Parse(t *testing.T, file, policies string, swaggers ...string) (*ast.Policies, []types.Type) {
+	t.Helper()
+	if len(swaggers) == 0 {
+		swaggers = []string{Swagger}
+	}
+	cplr, err := compiler.NewPolicyCompiler(compiler_rego.Language, swaggers...)
+	if err != nil {
+		t.Fatalf("could not create policy compiler: %s", err)
+	}
+	pols, err := cplr.Parse(file, policies)
+	if err != nil {
+		t.Fatalf("could not parse policies: %s", err)
+	}
+	return pols, cplr.SwaggerTypes()
+}
+
+// PetstoreDir returns the directory of the petstore example
+func PetstoreDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "docs", "source", "examples", "petstore")
+}
+
+// ReadFile returns the content of the file, relative to the directory of the petstore example
+func ReadFile(t *testing.T, name string) string {
+	t.Helper()
+	content, err := ioutil.ReadFile(filepath.Join(PetstoreDir(), name))
+	if err != nil {
+		t.Fatalf("could not read %s: %s", name, err)
+	}
+	return string(content)
+}
+
+// PetstoreSwaggers returns the swaggers of the petstore example
+func PetstoreSwaggers(t *testing.T) []string {
+	t.Helper()
+	return []string{
+		ReadFile(t, "petstore.jwt.swagger"),
+		ReadFile(t, "petstore.tags.swagger"),
+		ReadFile(t, "petstore.all.swagger"),
+	}
+}
@@ -10,18 +10,33 @@ import (
 
 type Lexer struct {
 	input        string
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	file         string // name of the input file, used for token positions
+	position     int    // current position in input (points to current char)
+	readPosition int    // current reading position in input (after current char)
+	ch           byte   // current char under examination
+	line         int    // line of current char
+	column       int    // column of current char
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
+// WithFile sets the file name reported in the positions of the tokens
+func (l *Lexer) WithFile(file string) *Lexer {
+	l.file = file
+	return l
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -38,6 +53,15 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) NextToken() token.Token {
+	l.skipWhitespace()
+	pos := token.Position{File: l.file, Line: l.line, Column: l.column}
+
+	tok := l.nextToken()
+	tok.Pos = pos
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
@@ -67,6 +91,10 @@ func (l *Lexer) NextToken() token.Token {
 	case '"':
 		tok.Literal = l.readLiteral()
 		tok.Type = token.LITERAL
+	case '$':
+		tok.Literal = l.readDataReference()
+		tok.Type = token.DATA_REF
+		return tok
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -125,6 +153,36 @@ func (l *Lexer) readLiteral() string {
 	return l.input[start:l.position]
 }
 
+// readDataReference reads an external data reference: $name.path["key"]
+func (l *Lexer) readDataReference() string {
+	start := l.position
+	l.readChar() // skip $
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '.' {
+		l.readChar()
+	}
+	if l.ch == '[' && l.peekChar() == '"' {
+		l.readChar() // skip [
+		l.readChar() // skip "
+		for l.ch != '"' && l.ch != 0 {
+			l.readChar()
+		}
+		if l.ch == '"' {
+			l.readChar()
+		}
+		if l.ch == ']' {
+			l.readChar()
+		}
+	}
+	return l.input[start:l.position]
+}
+
+func (l *Lexer) peekChar() byte {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition]
+}
+
 func (l *Lexer) readComment() string {
 	l.readChar()
 	start := l.position
@@ -165,10 +223,14 @@ func IsIndexedIdentifier(id string) bool {
 //   field["key"]
 //   field[key]
 //   field
+// Nested keys of multi-level identifiers are in Path:
+//   table.field["key"]["nested"]
+//   table.field.key.nested
 type IdentifierParts struct {
-	Table string // component before dot (empty if no dot)
-	Field string // component after dot
-	Key   string // index key (empty if no key)
+	Table string   // component before dot (empty if no dot)
+	Field string   // component after dot
+	Key   string   // index key (empty if no key)
+	Path  []string // keys nested below Key (nil if none), e.g. ["nested"] for field["key"]["nested"] or field.key.nested
 }
 
 // SplitIdentifier splits id into IdentifierParts
@@ -176,24 +238,78 @@ func SplitIdentifier(id string) *IdentifierParts {
 	idParts := IdentifierParts{}
 	splitID := strings.SplitN(id, `.`, 2)
 
-	idParts.Field = splitID[0]
-	if len(splitID) > 1 {
+	idParts.Field = id
+	if len(splitID) > 1 && !strings.ContainsAny(splitID[0], IndexedIdentifierChars) {
 		idParts.Table = splitID[0]
 		idParts.Field = splitID[1]
 	}
 
-	keyIdx := strings.IndexAny(idParts.Field, IndexedIdentifierChars)
+	keyIdx := strings.IndexAny(idParts.Field, `.`+IndexedIdentifierChars)
 	if keyIdx > 0 {
-		fieldAndKey := idParts.Field
-		idParts.Field = fieldAndKey[:keyIdx]
-		idParts.Key = fieldAndKey[keyIdx:]
-		idParts.Key = strings.TrimLeft(idParts.Key, IndexedIdentifierChars)
-		idParts.Key = strings.TrimRight(idParts.Key, IndexedIdentifierChars)
+		keys := splitKeys(idParts.Field[keyIdx:])
+		idParts.Field = idParts.Field[:keyIdx]
+		if len(keys) > 0 {
+			idParts.Key = keys[0]
+		}
+		if len(keys) > 1 {
+			idParts.Path = keys[1:]
+		}
 	}
 
 	return &idParts
 }
 
+// splitKeys splits the keys following a field: ["key"], [key] or .key, e.g. ["a"][0].b into [a 0 b]
+func splitKeys(keys string) []string {
+	out := []string{}
+	for keys != "" {
+		switch keys[0] {
+		case '[':
+			end := strings.IndexByte(keys, ']')
+			if end < 0 {
+				return append(out, strings.Trim(keys[1:], `"`))
+			}
+			out = append(out, strings.Trim(keys[1:end], `"`))
+			keys = keys[end+1:]
+			continue
+		case '.':
+			keys = keys[1:]
+		}
+		end := strings.IndexAny(keys, `.[`)
+		if end < 0 {
+			end = len(keys)
+		}
+		if end > 0 {
+			out = append(out, keys[:end])
+		}
+		keys = keys[end:]
+	}
+	return out
+}
+
+// DataReferenceParts holds components of splitted external data references:
+// Examples of unsplitted data references:
+//   $threat.feed["over_21_skus"]
+//   $list["name=customer_support"]
+//   $company.list
+type DataReferenceParts struct {
+	Name string // dotted name after $, e.g. threat.feed
+	Key  string // index key (empty if no key)
+}
+
+// SplitDataReference splits the data reference ref into DataReferenceParts,
+// returns false if ref is not a valid data reference
+func SplitDataReference(ref string) (*DataReferenceParts, bool) {
+	m := dataReferenceRegex.FindStringSubmatch(ref)
+	if m == nil {
+		return nil, false
+	}
+	return &DataReferenceParts{
+		Name: m[1],
+		Key:  m[3],
+	}, true
+}
+
 // SwaggerTypeParts holds components of splitted swagger-types:
 // Examples of unsplitted swagger-types:
 //   app.type
@@ -256,7 +372,7 @@ func isTypePattern(s string) bool {
 	if !isLetter(s[0]) {
 		return false
 	}
-	return typePatternRegex.MatchString(s)
+	return typePatternRegex.MatchString(s) || propertyPathRegex.MatchString(s)
 
 }
 
@@ -280,5 +396,8 @@ func newToken(tokenType token.TokenType, ch byte) token.Token {
 }
 
 var (
-	typePatternRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*\.([a-zA-Z_][a-zA-Z0-9_]*|[*]+)?(\[\"[a-zA-Z0-9_]*\"\])?$`)
+	dataReferenceRegex = regexp.MustCompile(`^\$([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)(\["([^"]*)"\])?$`)
+	// nested property paths of ctx and subject, e.g. ctx.buyer.email, ctx.buyer.tags["color"] or ctx.meta["labels"]["team"]
+	propertyPathRegex = regexp.MustCompile(`^(ctx|subject)(\.[a-zA-Z_][a-zA-Z0-9_]*)+(\[\"[a-zA-Z0-9_]*\"\](\.[a-zA-Z_][a-zA-Z0-9_]*)*)*$`)
+	typePatternRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*\.([a-zA-Z_][a-zA-Z0-9_]*|[*]+)?(\[\"[a-zA-Z0-9_]*\"\])?$`)
 )
@@ -407,6 +407,41 @@ func TestSplitIdentifier(t *testing.T) {
 				Key:   ``,
 			},
 		},
+		{
+			input:    `table.field["key"]["nested"][0]`,
+			expected: &IdentifierParts{
+				Table: `table`,
+				Field: `field`,
+				Key:   `key`,
+				Path:  []string{`nested`, `0`},
+			},
+		},
+		{
+			input:    `table.field.key.nested`,
+			expected: &IdentifierParts{
+				Table: `table`,
+				Field: `field`,
+				Key:   `key`,
+				Path:  []string{`nested`},
+			},
+		},
+		{
+			input:    `table.field["key.with.dots"].nested`,
+			expected: &IdentifierParts{
+				Table: `table`,
+				Field: `field`,
+				Key:   `key.with.dots`,
+				Path:  []string{`nested`},
+			},
+		},
+		{
+			input:    `field["key.with.dots"]`,
+			expected: &IdentifierParts{
+				Table: ``,
+				Field: `field`,
+				Key:   `key.with.dots`,
+			},
+		},
 	}
 
 	for idx, tst := range tests {
@@ -419,3 +454,144 @@ func TestSplitIdentifier(t *testing.T) {
 		}
 	}
 }
+
+func TestDataReference(t *testing.T) {
+	input := `ctx.sku in $threat.feed["over_21_skus"] and ctx.email == $list["name=customer_support"] or $company.list;`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TYPE_PATTERN, "ctx.sku"},
+		{token.OP_IN, "in"},
+		{token.DATA_REF, `$threat.feed["over_21_skus"]`},
+		{token.AND, "and"},
+		{token.TYPE_PATTERN, "ctx.email"},
+		{token.OP_EQUAL_TO, "=="},
+		{token.DATA_REF, `$list["name=customer_support"]`},
+		{token.OR, "or"},
+		{token.DATA_REF, `$company.list`},
+		{token.DELIMETER, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestSplitDataReference(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *DataReferenceParts
+	}{
+		{
+			input:    `$threat.feed["over_21_skus"]`,
+			expected: &DataReferenceParts{Name: `threat.feed`, Key: `over_21_skus`},
+		},
+		{
+			input:    `$list["name=customer_support"]`,
+			expected: &DataReferenceParts{Name: `list`, Key: `name=customer_support`},
+		},
+		{
+			input:    `$company.list`,
+			expected: &DataReferenceParts{Name: `company.list`},
+		},
+		{
+			input:    `$`,
+			expected: nil,
+		},
+		{
+			input:    `$company..list`,
+			expected: nil,
+		},
+		{
+			input:    `$list["unterminated`,
+			expected: nil,
+		},
+	}
+
+	for idx, tst := range tests {
+		actual, ok := SplitDataReference(tst.input)
+		if ok != (tst.expected != nil) || !reflect.DeepEqual(actual, tst.expected) {
+			t.Errorf("Test#%d: failure: input=%s expected=%+v actual=%+v\n",
+				idx, tst.input, tst.expected, actual)
+		}
+	}
+}
+
+func TestPropertyPath(t *testing.T) {
+	input := `ctx.buyer.email == subject.email and ctx.buyer.tags["vip"] == subject.address.city and ctx.meta["labels"]["team"].name and petstore.pet.id`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TYPE_PATTERN, "ctx.buyer.email"},
+		{token.OP_EQUAL_TO, "=="},
+		{token.TYPE_PATTERN, "subject.email"},
+		{token.AND, "and"},
+		{token.TYPE_PATTERN, `ctx.buyer.tags["vip"]`},
+		{token.OP_EQUAL_TO, "=="},
+		{token.TYPE_PATTERN, "subject.address.city"},
+		{token.AND, "and"},
+		{token.TYPE_PATTERN, `ctx.meta["labels"]["team"].name`},
+		{token.AND, "and"},
+		{token.IDENT, "petstore.pet.id"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPosition(t *testing.T) {
+	input := "allow to manage petstore.pet;\n\n  deny to buy petstore.pet\n\twhere ctx.age < 2;"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     string
+	}{
+		{"allow", "petstore.seal:1:1"},
+		{"to", "petstore.seal:1:7"},
+		{"manage", "petstore.seal:1:10"},
+		{"petstore.pet", "petstore.seal:1:17"},
+		{";", "petstore.seal:1:29"},
+		{"deny", "petstore.seal:3:3"},
+		{"to", "petstore.seal:3:8"},
+		{"buy", "petstore.seal:3:11"},
+		{"petstore.pet", "petstore.seal:3:15"},
+		{"where", "petstore.seal:4:2"},
+		{"ctx.age", "petstore.seal:4:8"},
+		{"<", "petstore.seal:4:16"},
+		{"2", "petstore.seal:4:18"},
+		{";", "petstore.seal:4:19"},
+		{"", "petstore.seal:4:20"},
+	}
+
+	l := New(input).WithFile("petstore.seal")
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.String() != tt.expectedPos {
+			t.Fatalf("tests[%d] - position of %q wrong. expected=%q, got=%q", i, tok.Literal, tt.expectedPos, tok.Pos)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package lint
+
+import (
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/token"
+)
+
+// never returns true if no disjunct of the condition can be true, considering the comparisons
+// of a property to literals and-ed in each disjunct, e.g. `ctx.age < 2 and ctx.age > 5`.
+// Other conditions, e.g. comparisons of properties to properties or data, are assumed satisfiable.
+func never(cnd ast.Condition) bool {
+	for _, disjunct := range ast.DisjunctiveNormalForm(cnd) {
+		if satisfiable(disjunct) {
+			return false
+		}
+	}
+	return true
+}
+
+// constraint is what the conditions of a disjunct require from the value of a property
+type constraint struct {
+	eq     []interface{}   // values the property is equal to
+	ne     []interface{}   // values the property is not equal to
+	in     [][]interface{} // lists including the value of the property
+	lo, hi *bound          // bounds of numeric values
+	truthy []bool          // tested as a boolean, or its negation
+}
+
+// bound is a lower or upper bound of a numeric value, excluded if strict
+type bound struct {
+	value  float64
+	strict bool
+}
+
+// satisfiable returns false if the constraints on a property of the conjunction contradict each other
+func satisfiable(cnd ast.Condition) bool {
+	constraints := map[string]*constraint{}
+	get := func(id string) *constraint {
+		c, ok := constraints[id]
+		if !ok {
+			c = &constraint{}
+			constraints[id] = c
+		}
+		return c
+	}
+
+	for _, atom := range conjuncts(cnd) {
+		switch s := atom.(type) {
+		case *ast.Identifier:
+			if s.Token.Type != token.LITERAL {
+				get(s.Value).truthy = append(get(s.Value).truthy, true)
+			}
+		case *ast.PrefixCondition:
+			if id, ok := s.Right.(*ast.Identifier); ok && id.Token.Type != token.LITERAL {
+				get(id.Value).truthy = append(get(id.Value).truthy, false)
+			}
+		case *ast.InfixCondition:
+			id, op, lit, ok := comparison(s)
+			if !ok {
+				continue
+			}
+			if _, isList := lit.([]interface{}); isList != (op == token.OP_IN) {
+				continue
+			}
+			c := get(id)
+			switch op {
+			case token.OP_EQUAL_TO:
+				c.eq = append(c.eq, lit)
+			case token.OP_NOT_EQUAL:
+				c.ne = append(c.ne, lit)
+			case token.OP_IN:
+				c.in = append(c.in, lit.([]interface{}))
+			case token.OP_LESS_THAN, token.OP_LESS_EQUAL:
+				if f, ok := lit.(float64); ok {
+					c.hi = tighter(c.hi, &bound{value: f, strict: op == token.OP_LESS_THAN}, true)
+				}
+			case token.OP_GREATER_THAN, token.OP_GREATER_EQUAL:
+				if f, ok := lit.(float64); ok {
+					c.lo = tighter(c.lo, &bound{value: f, strict: op == token.OP_GREATER_THAN}, false)
+				}
+			}
+		}
+	}
+
+	for _, c := range constraints {
+		if !c.satisfiable() {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfiable returns true if a value satisfies the constraint
+func (c *constraint) satisfiable() bool {
+	for _, t := range c.truthy {
+		if t != c.truthy[0] {
+			return false
+		}
+	}
+
+	if c.lo != nil && c.hi != nil &&
+		(c.lo.value > c.hi.value || c.lo.value == c.hi.value && (c.lo.strict || c.hi.strict)) {
+		return false
+	}
+
+	// lists are intersected, as the value must be in all of them
+	var candidates []interface{}
+	switch {
+	case len(c.eq) > 0:
+		candidates = c.eq[:1]
+		for _, v := range c.eq[1:] {
+			if v != c.eq[0] {
+				return false
+			}
+		}
+	case len(c.in) > 0:
+		candidates = c.in[0]
+	default:
+		return true
+	}
+
+	for _, v := range candidates {
+		if c.accepts(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts returns true if the value satisfies the not equal, in and bound constraints
+func (c *constraint) accepts(v interface{}) bool {
+	for _, ne := range c.ne {
+		if v == ne {
+			return false
+		}
+	}
+	for _, list := range c.in {
+		found := false
+		for _, it := range list {
+			if it == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f, ok := v.(float64); ok {
+		if c.lo != nil && (f < c.lo.value || f == c.lo.value && c.lo.strict) {
+			return false
+		}
+		if c.hi != nil && (f > c.hi.value || f == c.hi.value && c.hi.strict) {
+			return false
+		}
+	} else if c.lo != nil || c.hi != nil {
+		return false // rego never orders values of different types
+	}
+	return true
+}
+
+// tighter returns the tighter bound, the lowest upper bound or the highest lower bound
+func tighter(cur, b *bound, upper bool) *bound {
+	switch {
+	case cur == nil:
+		return b
+	case cur.value == b.value:
+		return &bound{value: b.value, strict: cur.strict || b.strict}
+	case (b.value < cur.value) == upper:
+		return b
+	}
+	return cur
+}
+
+// conjuncts returns the operands of the top-level `and` of the condition
+func conjuncts(cnd ast.Condition) []ast.Condition {
+	if s, ok := cnd.(*ast.InfixCondition); ok && s.Token.Type == token.AND {
+		return append(conjuncts(s.Left), conjuncts(s.Right)...)
+	}
+	return []ast.Condition{cnd}
+}
+
+// comparison returns the property, the operator and the literal of a comparison of a property to a literal,
+// the operator is reversed if the literal is the left operand, e.g. `2 < ctx.age` is `ctx.age > 2`
+func comparison(s *ast.InfixCondition) (string, token.TokenType, interface{}, bool) {
+	if id, ok := s.Left.(*ast.Identifier); ok && id.Token.Type != token.LITERAL {
+		lit, ok := literal(s.Right)
+		return id.Value, s.Token.Type, lit, ok
+	}
+
+	id, ok := s.Right.(*ast.Identifier)
+	if !ok || id.Token.Type == token.LITERAL {
+		return "", "", nil, false
+	}
+	lit, ok := literal(s.Left)
+	if !ok {
+		return "", "", nil, false
+	}
+	switch op := s.Token.Type; op {
+	case token.OP_EQUAL_TO, token.OP_NOT_EQUAL:
+		return id.Value, op, lit, true
+	case token.OP_LESS_THAN:
+		return id.Value, token.OP_GREATER_THAN, lit, true
+	case token.OP_LESS_EQUAL:
+		return id.Value, token.OP_GREATER_EQUAL, lit, true
+	case token.OP_GREATER_THAN:
+		return id.Value, token.OP_LESS_THAN, lit, true
+	case token.OP_GREATER_EQUAL:
+		return id.Value, token.OP_LESS_EQUAL, lit, true
+	}
+	return "", "", nil, false
+}
+
+// literal returns the value of a literal operand: a string, a float64 or a list of them
+func literal(cnd ast.Condition) (interface{}, bool) {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type == token.LITERAL {
+			return s.Value, true
+		}
+	case *ast.IntegerLiteral:
+		return float64(s.Value), true
+	case *ast.ArrayLiteral:
+		items := []interface{}{}
+		for _, it := range s.Items {
+			item, ok := literal(it)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+	return nil, false
+}
@@ -0,0 +1,122 @@
+// Package lint finds rules of the policies which are probably mistakes: duplicates,
+// rules shadowed by a broader deny, allow and deny rules overlapping on the same permissions,
+// and where clauses which can never be true.
+package lint
+
+import (
+	"fmt"
+
+	"github.com/infobloxopen/seal/pkg/access"
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+)
+
+// Kinds of warnings
+const (
+	KindDuplicate     = "duplicate"
+	KindShadowed      = "shadowed"
+	KindOverlap       = "overlap"
+	KindUnsatisfiable = "unsatisfiable"
+)
+
+// Warning is a problem found in a rule
+type Warning struct {
+	Pos     token.Position `json:"-"` // position of the statement of the rule
+	Stmt    int            `json:"stmt"`
+	Rule    string         `json:"rule"`
+	Kind    string         `json:"kind"`
+	Message string         `json:"message"`
+}
+
+// String returns the warning prefixed with the position of the rule
+func (w *Warning) String() string {
+	return w.Pos.Message("warning: " + w.Message)
+}
+
+// Lint returns the warnings of the rules of the policies, in policy order
+func Lint(pols *ast.Policies, swaggerTypes []types.Type) ([]*Warning, error) {
+	rules, err := access.Expand(pols, swaggerTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []*Warning{}
+	reported := map[string]bool{} // the rules linearized from a context statement share their positions
+	warn := func(r *access.Rule, kind, format string, args ...interface{}) {
+		w := &Warning{
+			Pos:     r.Pos,
+			Stmt:    r.Stmt,
+			Rule:    r.Statement.String(),
+			Kind:    kind,
+			Message: fmt.Sprintf(format, args...),
+		}
+		if key := w.String() + "\n" + w.Rule; !reported[key] {
+			reported[key] = true
+			warnings = append(warnings, w)
+		}
+	}
+
+	first := map[string]*access.Rule{} // first rule of each duplicate rule
+	for i, r := range rules {
+		key := r.Statement.String()
+		if dup, ok := first[key]; ok {
+			warn(r, KindDuplicate, "duplicate of the rule at %s", location(dup))
+			continue
+		}
+		first[key] = r
+
+		if r.Conditional() && never(r.Condition) {
+			warn(r, KindUnsatisfiable, "where clause %s can never be true", r.Condition)
+		}
+		if r.Action != eval.ActionAllow && r.Action != eval.ActionDeny || len(r.Permissions) == 0 {
+			continue
+		}
+
+		// shadowing unconditional denies, any rule can be shadowed whatever the order of the rules
+		shadowed := map[*access.Rule]bool{}
+		for j, d := range rules {
+			if i == j || !shadows(d, r) || d.Statement.String() == key {
+				continue
+			}
+			if j > i && shadows(r, d) { // equivalent denies, only the later one is redundant
+				continue
+			}
+			shadowed[d] = true
+			if r.Action == eval.ActionAllow {
+				warn(r, KindShadowed, "shadowed by the broader deny at %s: it never allows", location(d))
+			} else {
+				warn(r, KindShadowed, "shadowed by the broader deny at %s: it is redundant", location(d))
+			}
+			break
+		}
+
+		if r.Action != eval.ActionAllow {
+			continue
+		}
+		for _, d := range rules {
+			if d.Action != eval.ActionDeny || shadowed[d] || d.Statement.String() == key || !d.Subject.Covers(r.Subject) {
+				continue
+			}
+			if common := access.Intersect(r.Permissions, d.Permissions); len(common) > 0 {
+				warn(r, KindOverlap, "overlaps the deny at %s on %s: deny overrides allow", location(d), access.FormatPermissions(common))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// shadows returns true if the rule is an unconditional deny of every request of the other rule
+func shadows(d, r *access.Rule) bool {
+	return d.Action == eval.ActionDeny && !d.Conditional() &&
+		d.Subject.Covers(r.Subject) && access.Contains(d.Permissions, r.Permissions)
+}
+
+// location returns the position of the rule, or its statement if the position is unknown
+func location(r *access.Rule) string {
+	if r.Pos.IsValid() {
+		return r.Pos.String()
+	}
+	return fmt.Sprintf("stmt%d", r.Stmt)
+}
@@ -0,0 +1,95 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/internal/fixture"
+)
+
+func TestLint(t *testing.T) {
+	tests := map[string]struct {
+		policies string
+		expected []string
+	}{
+		"allow shadowed by a broader deny": {
+			policies: `deny subject group banned to manage shop.*;
+allow subject group banned to read shop.pet;`,
+			expected: []string{"shop.seal:2:1: warning: shadowed by the broader deny at shop.seal:1:1: it never allows"},
+		},
+		"deny shadowed by a broader deny": {
+			policies: `deny subject group banned to manage shop.*;
+deny subject group banned to manage shop.order where ctx.id == "1";`,
+			expected: []string{"shop.seal:2:1: warning: shadowed by the broader deny at shop.seal:1:1: it is redundant"},
+		},
+		"where clause never true": {
+			policies: `allow subject group customers to buy shop.pet where ctx.age < 2 and ctx.age > 5;`,
+			expected: []string{"shop.seal:1:1: warning: where clause ((ctx.age < 2) and (ctx.age > 5)) can never be true"},
+		},
+		"allow overlapping a deny": {
+			policies: `allow subject group customers to read shop.pet;
+deny subject group customers to manage shop.pet where ctx.status == "sold";`,
+			expected: []string{"shop.seal:1:1: warning: overlaps the deny at shop.seal:2:1 on shop.pet (get): deny overrides allow"},
+		},
+		"duplicate": {
+			policies: `allow subject group customers to read shop.pet;
+allow subject group customers to read shop.pet;`,
+			expected: []string{"shop.seal:2:1: warning: duplicate of the rule at shop.seal:1:1"},
+		},
+		"disjunction": {
+			policies: `allow subject group customers to buy shop.pet where ctx.status == "available" or ctx.neutered;`,
+			expected: []string{},
+		},
+	}
+
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			warnings, err := Lint(fixture.Parse(t, "shop.seal", tst.policies))
+			if err != nil {
+				t.Fatalf("could not lint policies: %s", err)
+			}
+
+			actual := []string{}
+			for _, w := range warnings {
+				actual = append(actual, w.String())
+			}
+			if strings.Join(actual, "\n") != strings.Join(tst.expected, "\n") {
+				t.Errorf("expected warnings:\n%s\ngot:\n%s", strings.Join(tst.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+func TestNever(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{`ctx.age < 2 and ctx.age > 5`, true},
+		{`ctx.age < 2 and ctx.age >= 2`, true},
+		{`ctx.age <= 2 and ctx.age >= 2`, false},
+		{`ctx.age > 2 and 2 > ctx.age`, true},
+		{`ctx.age < 2 or ctx.age > 5`, false},
+		{`ctx.age < 2 and ctx.age > 5 or ctx.status == "sold"`, false},
+		{`ctx.status == "sold" and ctx.status == "available"`, true},
+		{`ctx.status == "sold" and ctx.status != "sold"`, true},
+		{`ctx.status == "sold" and ctx.status != "available"`, false},
+		{`ctx.status in ["sold", "available"] and ctx.status != "sold"`, false},
+		{`ctx.status in ["sold", "available"] and ctx.status == "pending"`, true},
+		{`ctx.status in ["sold", "available"] and ctx.status in ["pending"]`, true},
+		{`ctx.age == 3 and ctx.age > 5`, true},
+		{`ctx.age == "3" and ctx.age < 5`, true},
+		{`ctx.neutered and not ctx.neutered`, true},
+		{`not ctx.neutered and not ctx.neutered`, false},
+		{`ctx.age < 2 and subject.age > 5`, false},
+		{`ctx.status == subject.status and ctx.status == "sold"`, false},
+	}
+	for idx, tst := range tests {
+		pols, _ := fixture.Parse(t, "shop.seal", "allow to buy shop.pet where "+tst.condition+";")
+		cnd := pols.Statements[0].(*ast.ActionStatement).WhereClause.(*ast.WhereClause).Condition
+		if actual := never(cnd); actual != tst.expected {
+			t.Errorf("tst #%d: %s: expected %v, got %v", idx, tst.condition, tst.expected, actual)
+		}
+	}
+}
@@ -28,6 +28,7 @@ func (p *Parser) registerPrefixConditionParseFns() {
 	p.registerPrefixCondition(token.TYPE_PATTERN, p.parseIdentifier)
 	p.registerPrefixCondition(token.LITERAL, p.parseIdentifier)
 	p.registerPrefixCondition(token.INT, p.parseIntegerLiteral)
+	p.registerPrefixCondition(token.DATA_REF, p.parseDataReference)
 
 	p.registerPrefixCondition(token.NOT, p.parsePrefixCondition)
 	p.registerPrefixCondition(token.OPEN_PAREN, p.parseGroupedCondition)
@@ -71,7 +72,7 @@ func (p *Parser) parseIntegerLiteral() ast.Condition {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -80,6 +81,17 @@ func (p *Parser) parseIntegerLiteral() ast.Condition {
 	return lit
 }
 
+func (p *Parser) parseDataReference() ast.Condition {
+	parts, ok := lexer.SplitDataReference(p.curToken.Literal)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as data reference", p.curToken.Literal)
+		p.errorAt(p.curToken.Pos, msg)
+		return nil
+	}
+
+	return &ast.DataReference{Token: p.curToken, Name: parts.Name, Key: parts.Key}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Condition {
 	arrLit := &ast.ArrayLiteral{Token: p.curToken}
 
@@ -88,7 +100,7 @@ func (p *Parser) parseArrayLiteral() ast.Condition {
 		if p.curTokenIs(token.DELIMETER) {
 			msg := fmt.Sprintf("unexpected end of array literal %q",
 				p.curToken.Literal)
-			p.errors = append(p.errors, msg)
+			p.errorAt(p.curToken.Pos, msg)
 		} else if p.curTokenIs(token.INT) {
 			itemLit := p.parseIntegerLiteral()
 			arrLit.Items = append(arrLit.Items, itemLit)
@@ -98,7 +110,7 @@ func (p *Parser) parseArrayLiteral() ast.Condition {
 		} else {
 			msg := fmt.Sprintf("unexpected %q in array literal, only integer or string literals currently supported",
 				p.curToken.Literal)
-			p.errors = append(p.errors, msg)
+			p.errorAt(p.curToken.Pos, msg)
 			return nil
 		}
 
@@ -132,7 +144,7 @@ func (p *Parser) parseCondition(precedence int) ast.Condition {
 	prefix := p.prefixConditionParseFns[p.curToken.Type]
 	if prefix == nil {
 		msg := fmt.Sprintf("no prefix condition parse function for %s found", p.curToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Pos, msg)
 		logger.WithField("error_msg", msg).Trace("parse_condition_error")
 		return nil
 	}
@@ -187,13 +199,6 @@ func (p *Parser) parseInfixCondition(left ast.Condition) ast.Condition {
 	condition.Right = p.parseCondition(precedence)
 	logger.WithField("condition", fmt.Sprintf("%#v", condition)).Trace("infix-condition")
 
-	// TODO GH-42
-	if condition.Token.Type == token.OR {
-		msg := fmt.Sprintf("OR-operator not supported yet for condition '%s'", condition)
-		p.errors = append(p.errors, msg)
-		return nil
-	}
-
 	return condition
 }
 
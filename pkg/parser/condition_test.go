@@ -46,6 +46,14 @@ components:
           format: int32
         is_healthy:
           type: bool
+        owner:
+          $ref: '#/components/schemas/iam.user'
+    subject:
+      type: object
+      properties:
+        email:
+          type: string
+      x-seal-type: none
     iam.user:
       type: object
       x-seal-actions:
@@ -122,6 +130,36 @@ components:
 			rules:    `allow subject group customers to buy petstore.pet where not ( (not (ctx.status == "available" and ctx.is_healthy == "true")) and (not (ctx.id == "foo" and ctx.name == "bar")) );`,
 			expected: `allow subject group customers to buy petstore.pet where (not((not((ctx.status == "available") and (ctx.is_healthy == "true"))) and (not((ctx.id == "foo") and (ctx.name == "bar")))));`,
 		},
+		{
+			name:     "where clause or binds weaker than and",
+			rules:    `allow subject group customers to buy petstore.pet where ctx.status == "available" or ctx.is_healthy == "true" and ctx.name == "fido";`,
+			expected: `allow subject group customers to buy petstore.pet where ((ctx.status == "available") or ((ctx.is_healthy == "true") and (ctx.name == "fido")));`,
+		},
+		{
+			name:     "where clause grouped or",
+			rules:    `allow subject group customers to buy petstore.pet where (ctx.status == "available" or ctx.is_healthy == "true") and ctx.name == "fido";`,
+			expected: `allow subject group customers to buy petstore.pet where (((ctx.status == "available") or (ctx.is_healthy == "true")) and (ctx.name == "fido"));`,
+		},
+		{
+			name:     "action properties",
+			rules:    `allow (log="true") subject group customers to buy petstore.pet where ctx.status == "available";`,
+			expected: `allow (log="true") subject group customers to buy petstore.pet where (ctx.status == "available");`,
+		},
+		{
+			name:     "in-operator data reference",
+			rules:    `allow to manage petstore.pet where ctx.status in $store.feed["statuses"];`,
+			expected: `allow to manage petstore.pet where (ctx.status in $store.feed["statuses"]);`,
+		},
+		{
+			name:     "action properties data reference",
+			rules:    `allow (log=$store.audit["log"]) subject group customers to buy petstore.pet;`,
+			expected: `allow (log=$store.audit["log"]) subject group customers to buy petstore.pet;`,
+		},
+		{
+			name:     "nested property compared to subject",
+			rules:    `allow to manage petstore.pet where ctx.owner.email == subject.email;`,
+			expected: `allow to manage petstore.pet where (ctx.owner.email == subject.email);`,
+		},
 		{
 			name:     "in-operator array literal",
 			rules:    `allow to manage petstore.pet where ctx.status in [ "available", 2 ]`,
@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/infobloxopen/seal/pkg/ast"
 	"github.com/infobloxopen/seal/pkg/lexer"
@@ -19,6 +20,9 @@ type Parser struct {
 	peekToken   token.Token
 	domainTypes map[string]types.Type
 	errors      []string
+	syntaxErrs  int  // number of syntax errors, including duplicates, statements are only skipped after syntax errors
+	failed      bool // an error, possibly a duplicate, was recorded while parsing the current statement
+	depth       int  // nesting depth of { } blocks at curToken, used for error recovery
 
 	prefixConditionParseFns map[token.TokenType]prefixConditionParseFn
 	infixConditionParseFns  map[token.TokenType]infixConditionParseFn
@@ -42,13 +46,13 @@ func New(l *lexer.Lexer, domainTypes []types.Type) *Parser {
 			plogger := ilogger.WithField("property_name", pname)
 			x_seal_type, ok, err := pprop.GetExtensionProp("x-seal-type")
 			if err != nil {
-				p.errors = append(p.errors, err.Error())
+				p.addError(err.Error())
 			} else if ok {
 				plogger.WithField("x_seal_type", x_seal_type).Trace("x_seal_type")
 			}
 			x_seal_obligation, ok, err := pprop.GetExtensionProp("x-seal-obligation")
 			if err != nil {
-				p.errors = append(p.errors, err.Error())
+				p.addError(err.Error())
 			} else if ok {
 				plogger.WithField("x_seal_obligation", x_seal_obligation).Trace("x_seal_obligation")
 			}
@@ -81,20 +85,56 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be type '%s', got type '%s'/literal '%s' instead",
 		t, p.peekToken.Type, p.peekToken.Literal)
+	p.errorAt(p.peekToken.Pos, msg)
+}
+
+// errorAt records a syntax error at the position in the seal source
+func (p *Parser) errorAt(pos token.Position, msg string) {
+	p.syntaxErrs++
+	p.addError(pos.Message(msg))
+}
+
+// addError records a parse error, unless the same error was already recorded,
+// and fails the current statement in any case
+func (p *Parser) addError(msg string) {
+	p.failed = true
+	for _, e := range p.errors {
+		if e == msg {
+			return
+		}
+	}
 	p.errors = append(p.errors, msg)
 }
 
+// errorfAt returns an error at the position in the seal source
+func errorfAt(pos token.Position, format string, a ...interface{}) error {
+	return errors.New(pos.Message(fmt.Sprintf(format, a...)))
+}
+
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.OPEN_BLOCK:
+		p.depth += 1
+	case token.CLOSE_BLOCK:
+		p.depth -= 1
+	}
 }
 
 func (p *Parser) ParsePolicies() *ast.Policies {
 	policies := &ast.Policies{}
 	policies.Statements = []ast.Statement{}
 	for p.curToken.Type != token.EOF {
+		nSyntaxErrs := p.syntaxErrs
+		p.failed = false
 		stmt := p.parseStatement()
-		if !types.IsNilInterface(stmt) {
+		if p.syntaxErrs > nSyntaxErrs {
+			// skip the rest of the failed statement to avoid follow-on errors,
+			// statements failing validation were fully parsed and need no recovery
+			p.synchronize()
+		} else if !p.failed && !types.IsNilInterface(stmt) {
 			policies.Statements = append(policies.Statements, stmt)
 		}
 		p.nextToken()
@@ -102,6 +142,25 @@ func (p *Parser) ParsePolicies() *ast.Policies {
 	return policies
 }
 
+// synchronize advances to the end of the current top-level statement:
+// a `;` outside of blocks or the `}` closing the last block of a context stanza
+func (p *Parser) synchronize() {
+	for !p.curTokenIs(token.EOF) {
+		switch {
+		case p.depth <= 0 && p.curTokenIs(token.DELIMETER):
+			p.depth = 0
+			return
+		case p.depth <= 0 && p.curTokenIs(token.CLOSE_BLOCK):
+			// the conditions block of a context stanza is followed by [to <verb>] [<resource>] { ... }
+			if !p.peekTokenIs(token.TO) && !p.peekTokenIs(token.TYPE_PATTERN) && !p.peekTokenIs(token.OPEN_BLOCK) {
+				p.depth = 0
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	logger := logrus.WithField("method", "parseStatement")
 	logger.WithField("curToken", p.curToken).Trace("parse_stmt")
@@ -142,7 +201,7 @@ func (p *Parser) parseSubject() ast.Subject {
 		}
 	default:
 		msg := fmt.Sprintf("expected next token to be user or group, got %s instead", p.curToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Pos, msg)
 		return nil
 	}
 	return subject
@@ -155,9 +214,14 @@ func (p *Parser) validateActionStatement(stmt *ast.ActionStatement) error {
 	}
 
 	if stmt.Verb == nil {
-		return fmt.Errorf("verb must be specified for type %s", stmt.TypePattern.Value)
+		return errorfAt(stmt.Pos(), "verb must be specified for type %s", stmt.TypePattern.Value)
 	}
-	for s, t := range p.domainTypes {
+
+	// a type pattern is valid if at least one of the matched types validates,
+	// types are visited in sorted order so the reported error is deterministic
+	var typeErr error
+	for _, s := range p.sortedDomainTypeNames() {
+		t := p.domainTypes[s]
 		m, err := glob.Match(stmt.TypePattern.Value, s)
 		if err != nil {
 			return err
@@ -174,24 +238,92 @@ func (p *Parser) validateActionStatement(stmt *ast.ActionStatement) error {
 			logrus.Debugf("action '%s' is not valid for type '%s'", stmt.Action, stmt.TypePattern.Value)
 			continue
 		}
+		if err := validateActionProperties(s, t, stmt.Action.Value, stmt.Properties); err != nil {
+			if typeErr == nil {
+				typeErr = err
+			}
+			continue
+		}
 
-		if !types.IsNilInterface(stmt.WhereClause) {
-			typs := stmt.WhereClause.GetTypes()
-			logrus.WithField("types", typs).Trace("where clause types")
-			for _, l := range typs {
-				v := !types.IsValidProperty(t, l.Value)                // v == true for invalid property
-				v = v && !types.IsValidSubject(p.domainTypes, l.Value) // v == true for invalid subject too (mean jwt)
-				v = v && !types.IsValidTag(t, l.Value)                 // v == true for invalid property + subject + tag
-				if v {
-					return fmt.Errorf("property %s is not valid for type %s in where clause '%s'", l.Value, s, stmt.WhereClause)
-				}
+		if err := p.validateWhereClause(s, t, stmt.WhereClause); err != nil {
+			if typeErr == nil {
+				typeErr = err
 			}
+			continue
 		}
 
 		// if we got here, then we found at least one match
 		return nil
 	}
-	return fmt.Errorf("type pattern %v did not match any registered types", stmt.TypePattern.TokenLiteral())
+	if typeErr != nil {
+		return typeErr
+	}
+	return errorfAt(stmt.TypePattern.Pos(), "type pattern %v did not match any registered types", stmt.TypePattern.TokenLiteral())
+}
+
+// validateWhereClause checks that the where clause only refers to properties of type t,
+// subject claims or tags
+func (p *Parser) validateWhereClause(typeName string, t types.Type, where ast.Condition) error {
+	if types.IsNilInterface(where) {
+		return nil
+	}
+
+	typs := where.GetTypes()
+	logrus.WithField("types", typs).Trace("where clause types")
+	for _, l := range typs {
+		v := !types.IsValidProperty(t, l.Value)                // v == true for invalid property
+		v = v && !types.IsValidSubject(p.domainTypes, l.Value) // v == true for invalid subject too (mean jwt)
+		v = v && !types.IsValidTag(t, l.Value)                 // v == true for invalid property + subject + tag
+		if v {
+			return errorfAt(l.Pos(), "property %s is not valid for type %s in where clause '%s'", l.Value, typeName, where)
+		}
+	}
+	return nil
+}
+
+// sortedDomainTypeNames returns the names of the registered types in sorted order
+func (p *Parser) sortedDomainTypeNames() []string {
+	names := make([]string, 0, len(p.domainTypes))
+	for s := range p.domainTypes {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateActionProperties type-checks the action properties against the properties of the action schema
+func validateActionProperties(typeName string, t types.Type, action string, props []*ast.ActionProperty) error {
+	seen := map[string]bool{}
+	for _, prop := range props {
+		if seen[prop.Name] {
+			return errorfAt(prop.Pos(), "duplicate property %s for action %s", prop.Name, action)
+		}
+		seen[prop.Name] = true
+
+		aprop, ok := types.GetActionProperty(t, action, prop.Name)
+		if !ok {
+			return errorfAt(prop.Pos(), "property %s is not valid for action %s of type %s", prop.Name, action, typeName)
+		}
+
+		var value string
+		var isString bool
+		switch v := prop.Value.(type) {
+		case *ast.Identifier:
+			value, isString = v.Value, v.Token.Type == token.LITERAL
+		case *ast.IntegerLiteral:
+			value = v.Token.Literal
+		case *ast.DataReference:
+			// external data can only be type-checked when it is resolved
+			continue
+		default:
+			return errorfAt(prop.Value.Pos(), "value %s is not valid for property %s of action %s", prop.Value, prop.Name, action)
+		}
+		if !types.IsValidActionPropertyValue(aprop, value, isString) {
+			return errorfAt(prop.Value.Pos(), "value %s is not a valid %s for property %s of action %s",
+				prop.Value, aprop.GetType(), prop.Name, action)
+		}
+	}
+	return nil
 }
 
 func (p *Parser) validateContextStatement(stmt *ast.ContextStatement) error {
@@ -203,7 +335,7 @@ func (p *Parser) validateContextStatement(stmt *ast.ContextStatement) error {
 	if stmt.Verb == nil { // allowed only in case context as an action
 		for _, act := range stmt.ActionRules {
 			if act.Context == nil && act.Verb == nil {
-				return errors.New("verb must be specified for context or for action")
+				return errorfAt(act.Action.Pos(), "verb must be specified for context or for action")
 			}
 		}
 	}
@@ -213,7 +345,8 @@ func (p *Parser) validateContextStatement(stmt *ast.ContextStatement) error {
 			continue
 		}
 		for _, cond := range stmt.Conditions {
-			for s, t := range p.domainTypes {
+			for _, s := range p.sortedDomainTypeNames() {
+				t := p.domainTypes[s]
 				var m bool
 				var err error
 
@@ -224,28 +357,31 @@ func (p *Parser) validateContextStatement(stmt *ast.ContextStatement) error {
 					tPattern = stmt.TypePattern
 					m, err = glob.Match(stmt.TypePattern.Value, s)
 				} else {
-					err = errors.New("Type pattern must be specified for context or for action")
+					err = errorfAt(act.Action.Pos(), "Type pattern must be specified for context or for action")
 				}
 				if err != nil {
 					return err
 				}
 				if !m {
-					glErr = fmt.Errorf("type pattern %v did not match any registered types", tPattern.TokenLiteral())
+					glErr = errorfAt(tPattern.Pos(), "type pattern %v did not match any registered types", tPattern.TokenLiteral())
 					continue
 				}
 
 				glErr = nil
 				if act.Verb != nil {
 					if v := types.IsValidVerb(t, act.Verb.Value); !v {
-						return fmt.Errorf("verb %s is not valid for type %s", act.Verb, act.TypePattern.Value)
+						return errorfAt(act.Verb.Pos(), "verb %s is not valid for type %s", act.Verb, tPattern.Value)
 					}
 				} else if stmt.Verb != nil {
 					if v := types.IsValidVerb(t, stmt.Verb.Value); !v {
-						return fmt.Errorf("verb %s is not valid for type %s", stmt.Verb, act.TypePattern.Value)
+						return errorfAt(stmt.Verb.Pos(), "verb %s is not valid for type %s", stmt.Verb, tPattern.Value)
 					}
 				}
 				if v := types.IsValidAction(t, act.Action.Value); !v {
-					return fmt.Errorf("action %s is not valid for type %s", act.Action, act.TypePattern.Value)
+					return errorfAt(act.Action.Pos(), "action %s is not valid for type %s", act.Action, tPattern.Value)
+				}
+				if err := validateActionProperties(s, t, act.Action.Value, act.Properties); err != nil {
+					return err
 				}
 
 				if !types.IsNilInterface(cond.Where) {
@@ -257,7 +393,7 @@ func (p *Parser) validateContextStatement(stmt *ast.ContextStatement) error {
 						v = v && !types.IsValidSubject(p.domainTypes, l.Value) // v == true for invalid subject too (mean jwt)
 						v = v && !types.IsValidTag(t, l.Value)                 // v == true for invalid property + subject + tag
 						if v {
-							return fmt.Errorf("property %s is not valid for type %s in where clause '%s'", l.Value, s, cond.Where)
+							return errorfAt(l.Pos(), "property %s is not valid for type %s in where clause '%s'", l.Value, s, cond.Where)
 						}
 					}
 				}
@@ -280,9 +416,17 @@ func (p *Parser) parseContextStatement() (stmt *ast.ContextStatement) {
 		ActionRules: []*ast.ContextActionRule{},
 	}
 
+	// a nested context statement fails its enclosing statement too
+	outerFailed := p.failed
+	p.failed = false
 	defer func() {
-		if err := p.validateContextStatement(stmt); err != nil {
-			p.errors = append(p.errors, err.Error())
+		failed := p.failed
+		p.failed = outerFailed || failed
+		if failed {
+			// do not validate partially parsed statements
+			stmt = nil
+		} else if err := p.validateContextStatement(stmt); err != nil {
+			p.addError(err.Error())
 			stmt = nil
 		}
 	}()
@@ -312,10 +456,7 @@ func (p *Parser) parseContextStatement() (stmt *ast.ContextStatement) {
 		if cond.Subject != nil || cond.Where != nil {
 			stmt.Conditions = append(stmt.Conditions, cond)
 		} else {
-			p.errors = append(
-				p.errors,
-				fmt.Sprintf("Expected SUBJECT or WHERE, got token type: %s", p.curToken.Type),
-			)
+			p.errorAt(p.curToken.Pos, fmt.Sprintf("Expected SUBJECT or WHERE, got token type: %s", p.curToken.Type))
 			return nil
 		}
 	}
@@ -368,6 +509,13 @@ func (p *Parser) parseContextStatement() (stmt *ast.ContextStatement) {
 				Value: p.curToken.Literal,
 			}
 
+			if p.peekTokenIs(token.OPEN_PAREN) {
+				act.Properties = p.parseActionProperties()
+				if act.Properties == nil {
+					return nil
+				}
+			}
+
 			if p.peekToken.Type == token.SUBJECT {
 				p.nextToken()
 				act.Subject = p.parseSubject()
@@ -405,10 +553,7 @@ func (p *Parser) parseContextStatement() (stmt *ast.ContextStatement) {
 	}
 
 	if len(stmt.ActionRules) == 0 {
-		p.errors = append(
-			p.errors,
-			fmt.Sprintf("No actions in context at %s", p.curToken.Type),
-		)
+		p.errorAt(p.curToken.Pos, fmt.Sprintf("No actions in context at %s", p.curToken.Type))
 		return nil
 	}
 
@@ -419,10 +564,14 @@ func (p *Parser) parseContextStatement() (stmt *ast.ContextStatement) {
 func (p *Parser) parseActionStatement() (stmt *ast.ActionStatement) {
 	logger := logrus.WithField("method", "parseActionStatement")
 
+	p.failed = false
 	defer func() {
-		if err := p.validateActionStatement(stmt); err != nil {
+		if p.failed {
+			// do not validate partially parsed statements
+			stmt = nil
+		} else if err := p.validateActionStatement(stmt); err != nil {
 			logger.WithField("stmt", stmt.String()).Trace("fail_validate_action_stmt")
-			p.errors = append(p.errors, err.Error())
+			p.addError(err.Error())
 			stmt = nil
 		}
 	}()
@@ -437,6 +586,14 @@ func (p *Parser) parseActionStatement() (stmt *ast.ActionStatement) {
 		Value: p.curToken.Literal,
 	}
 
+	// action properties are optional
+	if p.peekTokenIs(token.OPEN_PAREN) {
+		stmt.Properties = p.parseActionProperties()
+		if stmt.Properties == nil {
+			return nil
+		}
+	}
+
 	// subject is optional
 	if p.peekToken.Type == token.SUBJECT {
 		p.nextToken()
@@ -477,6 +634,64 @@ func (p *Parser) parseActionStatement() (stmt *ast.ActionStatement) {
 	return stmt
 }
 
+// parseActionProperties parses the action properties clause `( name=value [, name=value]... )`
+func (p *Parser) parseActionProperties() []*ast.ActionProperty {
+	if !p.expectPeek(token.OPEN_PAREN) {
+		return nil
+	}
+
+	props := []*ast.ActionProperty{}
+	p.nextToken()
+	for !p.curTokenIs(token.CLOSE_PAREN) {
+		if p.curTokenIs(token.EOF) || p.curTokenIs(token.DELIMETER) {
+			p.errorAt(p.curToken.Pos, fmt.Sprintf("unexpected end of action properties %q", p.curToken.Literal))
+			return nil
+		}
+
+		// property names may collide with keywords, e.g. `redirect (to="911")`
+		if !p.curTokenIs(token.IDENT) && !token.IsKeyword(p.curToken.Literal) {
+			msg := fmt.Sprintf("expected action property name, got type '%s'/literal '%s' instead",
+				p.curToken.Type, p.curToken.Literal)
+			p.errorAt(p.curToken.Pos, msg)
+			return nil
+		}
+		prop := &ast.ActionProperty{
+			Token: p.curToken,
+			Name:  p.curToken.Literal,
+		}
+
+		if !p.expectPeek(token.ASSIGN) {
+			return nil
+		}
+		p.nextToken()
+
+		switch p.curToken.Type {
+		case token.LITERAL:
+			prop.Value = p.parseLiteral()
+		case token.INT:
+			prop.Value = p.parseIntegerLiteral()
+		case token.DATA_REF:
+			prop.Value = p.parseDataReference()
+		default:
+			msg := fmt.Sprintf("unexpected %q as value of action property %s, only integer, string literals or data references currently supported",
+				p.curToken.Literal, prop.Name)
+			p.errorAt(p.curToken.Pos, msg)
+			return nil
+		}
+		if types.IsNilInterface(prop.Value) {
+			return nil
+		}
+		props = append(props, prop)
+
+		p.nextToken()
+		if p.curTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	return props
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/infobloxopen/seal/pkg/lexer"
@@ -77,12 +78,18 @@ func (s simpleAction) GetProperty(name string) (types.ActionProperty, bool) {
 	// FIXME, get real property
 	return nil, false
 }
+func (s simpleAction) GetProperties() map[string]types.ActionProperty {
+	return map[string]types.ActionProperty{}
+}
 
 type simpleProperty string
 
 func (s simpleProperty) GetName() string {
 	return string(s)
 }
+func (s simpleProperty) GetType() string {
+	return "string"
+}
 func (s simpleProperty) String() string {
 	return string(s)
 }
@@ -91,7 +98,7 @@ func (s simpleProperty) HasAdditionalProperties() bool {
 	return false
 }
 
-func (s simpleProperty) GetProperty(name string) (types.SwaggerProperty, bool) {
+func (s simpleProperty) GetProperty(name string) (types.Property, bool) {
 	// FIXME, get real property
 	return nil, false
 }
@@ -151,3 +158,92 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	}
 	t.FailNow()
 }
+
+func TestErrorRecovery(t *testing.T) {
+	input := `
+allow subject group foo to buy petstore.pet where ctx.name == "bar";
+allow to inspect fake;
+allow subject group bar to use iam.*;
+allow to;
+context {
+    where ctx.id == ;
+} to manage petstore.pet {
+    allow subject group foo;
+    deny subject group bar;
+};
+allow to buy petstore.pett;
+allow to buy petstore.pett;
+deny subject user foo to manage iam.*;
+`
+	l := lexer.New(input)
+	tTypes := []types.Type{iamRangeT, petstoreRequestT}
+	p := New(l, tTypes)
+
+	policies := p.ParsePolicies()
+	if policies == nil {
+		t.Fatalf("ParsePolicies() returned nil")
+	}
+	if len(policies.Statements) != 3 {
+		t.Errorf("program.Statements does not contain 3 statements. got=%d",
+			len(policies.Statements))
+	}
+
+	expected := []string{
+		"3:18: expected next token to be type 'TYPE_PATTERN', got type 'IDENT'/literal 'fake' instead",
+		"5:9: expected next token to be type 'IDENT', got type ';'/literal ';' instead",
+		"7:21: no prefix condition parse function for ; found",
+		"12:14: type pattern petstore.pett did not match any registered types",
+		"13:14: type pattern petstore.pett did not match any registered types",
+	}
+	if !reflect.DeepEqual(p.Errors(), expected) {
+		t.Errorf("unexpected errors\nexpected: %q\nactual:   %q", expected, p.Errors())
+	}
+}
+
+func TestErrorRecoveryWithoutDelimiter(t *testing.T) {
+	input := `
+allow to buy petstore.pett
+allow to inspect petstore.user
+deny subject user foo to manage iam.*
+`
+	l := lexer.New(input)
+	tTypes := []types.Type{iamRangeT, petstoreRequestT}
+	p := New(l, tTypes)
+
+	policies := p.ParsePolicies()
+	if policies == nil {
+		t.Fatalf("ParsePolicies() returned nil")
+	}
+	if len(policies.Statements) != 1 {
+		t.Errorf("program.Statements does not contain 1 statement. got=%d",
+			len(policies.Statements))
+	}
+
+	expected := []string{
+		"2:14: type pattern petstore.pett did not match any registered types",
+		"3:18: type pattern petstore.user did not match any registered types",
+	}
+	if !reflect.DeepEqual(p.Errors(), expected) {
+		t.Errorf("unexpected errors\nexpected: %q\nactual:   %q", expected, p.Errors())
+	}
+}
+
+func TestDuplicateErrorFailsStatement(t *testing.T) {
+	p := New(lexer.New(`allow to buy petstore.pet;`), []types.Type{petstoreRequestT})
+	p.addError("2:14: type pattern petstore.pett did not match any registered types")
+
+	// a statement recording an error already recorded must fail though the errors do not grow
+	p.failed = false
+	p.addError("2:14: type pattern petstore.pett did not match any registered types")
+	if !p.failed {
+		t.Errorf("statement not failed by duplicate error")
+	}
+	if len(p.Errors()) != 1 {
+		t.Errorf("duplicate error recorded: %q", p.Errors())
+	}
+
+	// the next statement starts afresh
+	if stmt := p.parseActionStatement(); stmt == nil {
+		t.Errorf("valid statement failed after a failed one: %q", p.Errors())
+	}
+}
@@ -0,0 +1,88 @@
+// Package provider resolves external data references such as `$threat.feed["over_21_skus"]`
+// for backends that cannot look them up at decision time (rego backends use the data document instead).
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when a data reference cannot be resolved by a provider
+var ErrNotFound = errors.New("data reference not found")
+
+// Provider resolves external data references
+type Provider interface {
+	// Resolve returns the value of the data reference name[key], e.g. name="threat.feed" and key="over_21_skus".
+	// key is empty if the data reference is not indexed.
+	Resolve(name, key string) (interface{}, error)
+}
+
+// Func adapts an ordinary function to the Provider interface
+type Func func(name, key string) (interface{}, error)
+
+// Resolve calls f(name, key)
+func (f Func) Resolve(name, key string) (interface{}, error) {
+	return f(name, key)
+}
+
+// Document is a Provider backed by a nested JSON-like document with the same layout
+// as the rego data document, so that the same data can be used by all backends:
+//
+//	{"threat": {"feed": {"over_21_skus": ["sku1", "sku2"]}}}
+type Document map[string]interface{}
+
+// Resolve walks the document along the dotted name and then the key
+func (d Document) Resolve(name, key string) (interface{}, error) {
+	path := strings.Split(name, ".")
+	if key != "" {
+		path = append(path, key)
+	}
+
+	var cur interface{} = map[string]interface{}(d)
+	for _, elem := range path {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, reference(name, key))
+		}
+		if cur, ok = obj[elem]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, reference(name, key))
+		}
+	}
+	return cur, nil
+}
+
+// Mux dispatches data references to the provider registered for the first component of the name,
+// e.g. the provider registered for "threat" resolves `$threat.feed["over_21_skus"]`
+type Mux struct {
+	providers map[string]Provider
+}
+
+// NewMux returns an empty Mux
+func NewMux() *Mux {
+	return &Mux{providers: map[string]Provider{}}
+}
+
+// Handle registers the provider for data references starting with prefix.
+// When registering multiple providers with the same prefix, the most recent wins.
+func (m *Mux) Handle(prefix string, p Provider) *Mux {
+	m.providers[prefix] = p
+	return m
+}
+
+// Resolve resolves the data reference with the provider registered for its prefix
+func (m *Mux) Resolve(name, key string) (interface{}, error) {
+	prefix := strings.Split(name, ".")[0]
+	p, ok := m.providers[prefix]
+	if !ok {
+		return nil, fmt.Errorf("%w: no provider registered for %s", ErrNotFound, reference(name, key))
+	}
+	return p.Resolve(name, key)
+}
+
+func reference(name, key string) string {
+	if key == "" {
+		return "$" + name
+	}
+	return fmt.Sprintf(`$%s["%s"]`, name, key)
+}
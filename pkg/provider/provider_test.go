@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	doc := Document{
+		"threat": map[string]interface{}{
+			"feed": map[string]interface{}{
+				"over_21_skus": []interface{}{"sku1", "sku2"},
+			},
+		},
+		"list": map[string]interface{}{
+			"name=customer_support": "support@acme.com",
+		},
+	}
+	mux := NewMux().
+		Handle("threat", doc).
+		Handle("list", Func(func(name, key string) (interface{}, error) {
+			return doc.Resolve(name, key)
+		}))
+
+	tcases := []struct {
+		name     string
+		key      string
+		expected interface{}
+		notFound bool
+	}{
+		{
+			name:     "threat.feed",
+			key:      "over_21_skus",
+			expected: []interface{}{"sku1", "sku2"},
+		},
+		{
+			name:     "threat.feed",
+			expected: map[string]interface{}{"over_21_skus": []interface{}{"sku1", "sku2"}},
+		},
+		{
+			name:     "list",
+			key:      "name=customer_support",
+			expected: "support@acme.com",
+		},
+		{
+			name:     "threat.feed",
+			key:      "under_21_skus",
+			notFound: true,
+		},
+		{
+			name:     "threat.feed.over_21_skus.x",
+			notFound: true,
+		},
+		{
+			name:     "company.list",
+			key:      "name=endangered",
+			notFound: true,
+		},
+	}
+
+	for idx, tcase := range tcases {
+		actual, err := mux.Resolve(tcase.name, tcase.key)
+		if tcase.notFound {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("tcase #%d: expected ErrNotFound, got: %v", idx, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tcase #%d: unexpected error: %s", idx, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, tcase.expected) {
+			t.Errorf("tcase #%d: expected %#v, got %#v", idx, tcase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package sealtest defines the seal test files, listing requests and the decisions expected
+// from the policies, so that policy authors can test policies without writing rego tests.
+package sealtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/infobloxopen/seal/pkg/eval"
+)
+
+// Suite is a YAML or JSON seal test file, e.g.
+//
+//	swaggers: [petstore.all.swagger]
+//	policies: [petstore.all.seal]
+//	tests:
+//	- name: customers can buy available pets
+//	  request:
+//	    type: petstore.pet
+//	    verb: buy
+//	    ctx: [{status: available}]
+//	    subject: {groups: [customers]}
+//	  expect:
+//	    action: allow
+//
+// Swaggers, policies and data files are relative to the test file.
+type Suite struct {
+	File     string   `json:"-"`                  // path of the test file
+	Swaggers []string `json:"swaggers,omitempty"` // swagger files to read types
+	Policies []string `json:"policies,omitempty"` // seal files or directories of seal files
+	Data     string   `json:"data,omitempty"`     // YAML or JSON document resolving data references
+	Tests    []*Test  `json:"tests"`
+}
+
+// Test is a request and the decision expected for it
+type Test struct {
+	Name    string          `json:"name"`
+	Request json.RawMessage `json:"request"` // with the layout of the rego input document, see eval.ParseRequests
+	Expect  *Expectation    `json:"expect"`
+
+	request *eval.Request
+}
+
+// Expectation is the decision expected for a request
+type Expectation struct {
+	Action      string    `json:"action"`                // allow, deny, or the default action of the type
+	Obligations *[]string `json:"obligations,omitempty"` // in any order, not checked if omitted
+}
+
+// Result is the result of a test
+type Result struct {
+	Test     *Test
+	Decision *eval.Decision
+	Failures []string // differences between the expected and actual decisions
+}
+
+// Passed returns true if the decision is the expected one
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Parse parses the YAML or JSON test file, unknown fields are errors
+func Parse(file string, data []byte) (*Suite, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse seal tests: %s", err)
+	}
+
+	s := &Suite{}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("could not parse seal tests: %s", err)
+	}
+	s.File = file
+
+	for i, tst := range s.Tests {
+		if tst == nil || tst.Name == "" {
+			return nil, fmt.Errorf("test #%d: missing name", i)
+		}
+		if tst.Expect == nil || tst.Expect.Action == "" {
+			return nil, fmt.Errorf("test %q: missing expected action", tst.Name)
+		}
+		if len(tst.Request) == 0 {
+			return nil, fmt.Errorf("test %q: missing request", tst.Name)
+		}
+		reqs, err := eval.ParseRequests(tst.Request)
+		if err != nil {
+			return nil, fmt.Errorf("test %q: %s", tst.Name, err)
+		}
+		if len(reqs) != 1 {
+			return nil, fmt.Errorf("test %q: expected a single request, got %d", tst.Name, len(reqs))
+		}
+		tst.request = reqs[0]
+	}
+	return s, nil
+}
+
+// Path returns the path of a file referenced by the test file
+func (s *Suite) Path(name string) string {
+	if filepath.IsAbs(name) || s.File == "" {
+		return name
+	}
+	return filepath.Join(filepath.Dir(s.File), name)
+}
+
+// Run decides the request of every test with the evaluator
+func (s *Suite) Run(e *eval.Evaluator) ([]*Result, error) {
+	results := []*Result{}
+	for _, tst := range s.Tests {
+		dcsn, err := e.Eval(tst.request)
+		if err != nil {
+			return nil, fmt.Errorf("test %q: %s", tst.Name, err)
+		}
+
+		res := &Result{Test: tst, Decision: dcsn}
+		if dcsn.Action != tst.Expect.Action {
+			res.Failures = append(res.Failures, fmt.Sprintf("expected action %s, got %s", tst.Expect.Action, orUndefined(dcsn.Action)))
+		}
+		if tst.Expect.Obligations != nil {
+			expected, actual := sorted(*tst.Expect.Obligations), sorted(dcsn.Obligations)
+			if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+				res.Failures = append(res.Failures, fmt.Sprintf("expected obligations %q, got %q", expected, actual))
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func orUndefined(action string) string {
+	if action == "" {
+		return "undefined"
+	}
+	return action
+}
+
+func sorted(strs []string) []string {
+	out := append([]string{}, strs...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,145 @@
+package sealtest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/internal/fixture"
+)
+
+func petstoreTests() string {
+	return filepath.Join(fixture.PetstoreDir(), "petstore.all.sealtest.yaml")
+}
+
+func newEvaluator(t *testing.T, s *Suite) *eval.Evaluator {
+	t.Helper()
+	swaggers := []string{}
+	for _, sw := range s.Swaggers {
+		content, err := ioutil.ReadFile(s.Path(sw))
+		if err != nil {
+			t.Fatalf("could not read swagger: %s", err)
+		}
+		swaggers = append(swaggers, string(content))
+	}
+	content, err := ioutil.ReadFile(s.Path(s.Policies[0]))
+	if err != nil {
+		t.Fatalf("could not read policies: %s", err)
+	}
+	pols, swaggerTypes := fixture.Parse(t, s.Path(s.Policies[0]), string(content), swaggers...)
+	e, err := eval.New(pols, swaggerTypes)
+	if err != nil {
+		t.Fatalf("could not create evaluator: %s", err)
+	}
+	return e
+}
+
+func TestPetstore(t *testing.T) {
+	content, err := ioutil.ReadFile(petstoreTests())
+	if err != nil {
+		t.Fatalf("could not read seal tests: %s", err)
+	}
+	s, err := Parse(petstoreTests(), content)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	results, err := s.Run(newEvaluator(t, s))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, res := range results {
+		if !res.Passed() {
+			t.Errorf("%s: %v", res.Test.Name, res.Failures)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		test     string
+		failures []string
+	}{
+		"wrong action": {
+			test:     `request: {type: petstore.pet, verb: get, subject: {iss: petstore.swagger.io, groups: [customers]}}, expect: {action: deny}`,
+			failures: []string{"expected action deny, got allow"},
+		},
+		"wrong obligations": {
+			test:     `request: {type: petstore.order, verb: list, ctx: [{status: delivered}], subject: {iss: context.petstore.swagger.io, groups: [employees]}}, expect: {action: allow, obligations: []}`,
+			failures: []string{`expected obligations [], got ["type:petstore.order; (ctx.marketplace != \"amazon\")"]`},
+		},
+		"unknown type": {
+			test:     `request: {type: petstore.unknown, verb: get}, expect: {action: deny}`,
+			failures: []string{"expected action deny, got undefined"},
+		},
+		"passed": {
+			test: `request: {type: petstore.order, verb: list, ctx: [{status: delivered}], subject: {iss: context.petstore.swagger.io, groups: [employees]}}, expect: {action: allow}`,
+		},
+	}
+
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(petstoreTests(), []byte(`
+swaggers: [petstore.jwt.swagger, petstore.tags.swagger, petstore.all.swagger]
+policies: [petstore.all.seal]
+tests: [{name: test, `+tst.test+`}]
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			results, err := s.Run(newEvaluator(t, s))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(results[0].Failures, tst.failures) {
+				t.Errorf("expected failures %q, got %q", tst.failures, results[0].Failures)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "unknown field",
+			data: `tests: [{name: a, request: {type: t, verb: v}, expect: {action: allow, matches: 1}}]`,
+			err:  `could not parse seal tests: json: unknown field "matches"`,
+		},
+		{
+			name: "missing name",
+			data: `tests: [{request: {type: t, verb: v}, expect: {action: allow}}]`,
+			err:  `test #0: missing name`,
+		},
+		{
+			name: "missing action",
+			data: `tests: [{name: a, request: {type: t, verb: v}, expect: {}}]`,
+			err:  `test "a": missing expected action`,
+		},
+		{
+			name: "missing request",
+			data: `tests: [{name: a, expect: {action: allow}}]`,
+			err:  `test "a": missing request`,
+		},
+		{
+			name: "several requests",
+			data: `tests: [{name: a, request: [{type: t, verb: v}, {type: t, verb: w}], expect: {action: allow}}]`,
+			err:  `test "a": expected a single request, got 2`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			_, err := Parse("", []byte(tst.data))
+			if err == nil || err.Error() != tst.err {
+				t.Errorf("expected error %q, got %v", tst.err, err)
+			}
+		})
+	}
+}
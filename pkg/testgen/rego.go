@@ -0,0 +1,119 @@
+package testgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// header marks the rego tests generated by Rego
+const header = "# Code generated by seal compile --emit-tests. DO NOT EDIT."
+
+// generatedRe matches the header of generated files, as go generate does
+var generatedRe = regexp.MustCompile(`(?m)^# Code generated .* DO NOT EDIT\.$`)
+
+// IsGenerated returns whether the rego file content is generated, files without the header must not be overwritten
+func IsGenerated(content []byte) bool {
+	return generatedRe.Match(content)
+}
+
+// jwtHelper signs the subject claims of the test requests, the rego backend decodes them without verification
+const jwtHelper = `# sealtest_jwt_encode_sign returns HMAC signed jwt from claims for testing purposes
+sealtest_jwt_encode_sign(claims) = jwt {
+	jwt = io.jwt.encode_sign(
+		{
+			"typ": "JWT",
+			"alg": "HS256",
+		},
+		claims, {
+			"kty": "oct",
+			# k from https://tools.ietf.org/html/rfc7517#appendix-A.3
+			"k": "AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAowg",
+		},
+	)
+}
+`
+
+// Rego returns the rego unit tests of the cases for the package compiled by the rego backend,
+// formatted as opa fmt does
+func Rego(pkgname string, cases []*Case) string {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "package %s\n\n", pkgname)
+	fmt.Fprintf(&out, "%s\n", header)
+
+	stmt := ""
+	for _, c := range cases {
+		if c.Rule != stmt {
+			stmt = c.Rule
+			fmt.Fprintf(&out, "\n# %s\n", c.Rule)
+		}
+		if c.Comment != "" {
+			fmt.Fprintf(&out, "\n# %s\n", c.Comment)
+		} else {
+			out.WriteString("\n")
+		}
+
+		fmt.Fprintf(&out, "test_%s {\n", c.Name)
+		out.WriteString("\tin := {\n")
+		fmt.Fprintf(&out, "\t\t\"type\": %s,\n", regoValue(c.Request.Type))
+		fmt.Fprintf(&out, "\t\t\"verb\": %s,\n", regoValue(c.Request.Verb))
+		if len(c.Request.Ctx) > 0 {
+			ctx := []interface{}{}
+			for _, obj := range c.Request.Ctx {
+				ctx = append(ctx, obj)
+			}
+			fmt.Fprintf(&out, "\t\t\"ctx\": %s,\n", regoValue(ctx))
+		}
+		fmt.Fprintf(&out, "\t\t\"jwt\": sealtest_jwt_encode_sign(%s),\n", regoValue(c.Request.Subject))
+		out.WriteString("\t}\n\n")
+
+		not := ""
+		if !c.Expect {
+			not = "not "
+		}
+		fmt.Fprintf(&out, "\t%s%s with input as in\n}\n", not, c.Action)
+	}
+
+	fmt.Fprintf(&out, "\n%s", jwtHelper)
+	return out.String()
+}
+
+// regoValue returns the value as a rego term on a single line, with the keys of objects sorted
+func regoValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n")
+	case []interface{}:
+		items := []string{}
+		for _, it := range v {
+			items = append(items, regoValue(it))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := []string{}
+		for _, k := range keys {
+			items = append(items, regoValue(k)+": "+regoValue(v[k]))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return fmt.Sprintf("%v", value)
+}
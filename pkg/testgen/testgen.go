@@ -0,0 +1,560 @@
+// Package testgen generates test requests exercising every rule of the policies:
+// a request matching the rule, a request from a non-member of its subject, a request with a wrong verb,
+// and requests just outside each condition of its where clause.
+// The expected decisions are those of the evaluator, so that the generated tests
+// check that a backend enforces the policies the same way.
+package testgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/infobloxopen/seal/pkg/ast"
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/lexer"
+	"github.com/infobloxopen/seal/pkg/token"
+	"github.com/infobloxopen/seal/pkg/types"
+	"github.com/mb0/glob"
+)
+
+// Kinds of generated cases
+const (
+	KindPositive  = "positive"   // the request matches the rule
+	KindNonMember = "non_member" // the subject is not the group or user of the rule
+	KindWrongVerb = "wrong_verb" // the base verb is not one of the verb of the rule
+	KindOutside   = "outside"    // one condition of the where clause does not hold
+)
+
+// Case is a generated test request and the decision expected for it
+type Case struct {
+	Name    string // unique name, e.g. stmt3_outside1
+	Stmt    int    // index of the statement in the policies
+	Rule    string // the rule tested, context statements are linearized into one rule per action
+	Action  string // action of the rule, allow or deny
+	Kind    string
+	Comment string // what the request varies, e.g. `outside ctx.age <= 2`
+	Request *eval.Request
+	Expect  bool // whether the policies decide the action of the rule for the request, by this rule or another
+}
+
+// Generate returns the test cases of the allow and deny rules of the policies, in policy order.
+// Rules with custom actions, or whose type pattern matches no type with their verb, are not tested.
+func Generate(pols *ast.Policies, swaggerTypes []types.Type) ([]*Case, error) {
+	e, err := eval.New(pols, swaggerTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	swts := append([]types.Type{}, swaggerTypes...)
+	sort.Slice(swts, func(i, j int) bool { return swts[i].String() < swts[j].String() })
+
+	cases := []*Case{}
+	for idx, stmt := range pols.Statements {
+		var stmts []*ast.ActionStatement
+		switch s := stmt.(type) {
+		case *ast.ActionStatement:
+			stmts = []*ast.ActionStatement{s}
+		case *ast.ContextStatement:
+			stmts = ast.LinearizeContext(s)
+		}
+
+		for n, st := range stmts {
+			name := fmt.Sprintf("stmt%d", idx)
+			if len(stmts) > 1 {
+				name = fmt.Sprintf("%s_%d", name, n)
+			}
+			cases = append(cases, generateRule(swts, idx, name, st)...)
+		}
+	}
+
+	for _, c := range cases {
+		dcsn, err := e.Eval(c.Request)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range dcsn.Matches {
+			if m.Action == c.Action {
+				c.Expect = true
+				break
+			}
+		}
+	}
+	return cases, nil
+}
+
+// generateRule returns the cases of the rule, with requests on the first type matching its type pattern
+func generateRule(swts []types.Type, idx int, name string, st *ast.ActionStatement) []*Case {
+	action := st.Token.Literal
+	if (action != eval.ActionAllow && action != eval.ActionDeny) || st.Verb == nil || st.TypePattern == nil {
+		return nil
+	}
+
+	var swtype types.Type
+	for _, swt := range swts {
+		if m, err := glob.Match(st.TypePattern.Value, swt.String()); err == nil && m && types.IsValidVerb(swt, st.Verb.Value) {
+			swtype = swt
+			break
+		}
+	}
+	if swtype == nil {
+		return nil
+	}
+
+	verbs := baseVerbs(swtype, st.Verb.Value)
+	g := &generator{swtype: swtype, st: st, atoms: conjuncts(st.WhereClause)}
+	newCase := func(kind, suffix, comment string, req *eval.Request) *Case {
+		return &Case{
+			Name:    name + "_" + suffix,
+			Stmt:    idx,
+			Rule:    st.String(),
+			Action:  action,
+			Kind:    kind,
+			Comment: comment,
+			Request: req,
+		}
+	}
+
+	cases := []*Case{newCase(KindPositive, KindPositive, "", g.request(verbs[0], true, -1))}
+	if !types.IsNilInterface(st.Subject) {
+		cases = append(cases, newCase(KindNonMember, KindNonMember, "", g.request(verbs[0], false, -1)))
+	}
+	if wrong := wrongVerb(swtype, verbs); wrong != "" {
+		cases = append(cases, newCase(KindWrongVerb, KindWrongVerb, "", g.request(wrong, true, -1)))
+	}
+	for i, atom := range g.atoms {
+		comment := fmt.Sprintf("outside %s", atom)
+		cases = append(cases, newCase(KindOutside, fmt.Sprintf("%s%d", KindOutside, i+1), comment, g.request(verbs[0], true, i)))
+	}
+	return cases
+}
+
+// baseVerbs returns the base verbs of the verb of the type
+func baseVerbs(swtype types.Type, verb string) []string {
+	for _, vrb := range swtype.GetVerbs() {
+		if vrb.GetName() == verb {
+			return vrb.GetBaseVerbs()
+		}
+	}
+	return nil
+}
+
+// wrongVerb returns the first base verb of the type, in alphabetical order, which is not one of the base verbs
+func wrongVerb(swtype types.Type, verbs []string) string {
+	all := []string{}
+	for _, vrb := range swtype.GetVerbs() {
+		all = append(all, vrb.GetBaseVerbs()...)
+	}
+	sort.Strings(all)
+	for _, bv := range all {
+		if !containsString(verbs, bv) {
+			return bv
+		}
+	}
+	return ""
+}
+
+// conjuncts returns the operands of the top-level `and` of the first disjunct of the where clause
+func conjuncts(cnd ast.Condition) []ast.Condition {
+	wc, ok := cnd.(*ast.WhereClause)
+	if !ok || types.IsNilInterface(wc.Condition) {
+		return nil
+	}
+
+	var flatten func(c ast.Condition) []ast.Condition
+	flatten = func(c ast.Condition) []ast.Condition {
+		if s, ok := c.(*ast.InfixCondition); ok && s.Token.Type == token.AND {
+			return append(flatten(s.Left), flatten(s.Right)...)
+		}
+		return []ast.Condition{c}
+	}
+	return flatten(ast.DisjunctiveNormalForm(wc.Condition)[0])
+}
+
+// generator builds the requests of a rule
+type generator struct {
+	swtype types.Type
+	st     *ast.ActionStatement
+	atoms  []ast.Condition // conditions and-ed in the where clause
+}
+
+// request returns a request with the base verb, from a member of the subject of the rule or not,
+// satisfying every condition of the where clause but the outside one, if any
+func (g *generator) request(verb string, member bool, outside int) *eval.Request {
+	req := &eval.Request{
+		Type:    g.swtype.String(),
+		Verb:    verb,
+		Subject: map[string]interface{}{},
+	}
+	ctx := map[string]interface{}{}
+
+	switch s := g.st.Subject.(type) {
+	case *ast.SubjectGroup:
+		group := s.Group
+		if !member {
+			group = "not_" + group
+		}
+		req.Subject["groups"] = []interface{}{group}
+	case *ast.SubjectUser:
+		user := s.User
+		if !member {
+			user = "not_" + user
+		}
+		req.Subject["sub"] = user
+	}
+
+	env := &env{ctx: ctx, subject: req.Subject}
+	for i, atom := range g.atoms {
+		if i != outside {
+			g.assign(atom, true, env)
+		}
+	}
+	if outside >= 0 {
+		g.assign(g.atoms[outside], false, env)
+	}
+
+	if len(ctx) > 0 {
+		req.Ctx = []map[string]interface{}{ctx}
+	}
+	return req
+}
+
+// env holds the values of the ctx and subject properties of a request
+type env struct {
+	ctx     map[string]interface{}
+	subject map[string]interface{}
+}
+
+// path returns the object and the path of the property of the identifier, e.g. ["tags", "endangered"]
+func (e *env) path(id string) (map[string]interface{}, []string) {
+	idParts := lexer.SplitIdentifier(id)
+	path := []string{idParts.Field}
+	if idParts.Key != "" {
+		path = append(append(path, idParts.Key), idParts.Path...)
+	}
+	switch idParts.Table {
+	case "ctx":
+		return e.ctx, path
+	case types.SUBJECT:
+		return e.subject, path
+	}
+	return nil, nil
+}
+
+// get returns the value of the property of the identifier
+func (e *env) get(id string) (interface{}, bool) {
+	obj, path := e.path(id)
+	var cur interface{} = obj
+	for _, elem := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[elem]; !ok {
+			return nil, false
+		}
+	}
+	return cur, obj != nil
+}
+
+// set sets the value of the property of the identifier, creating the nested objects
+func (e *env) set(id string, value interface{}) {
+	obj, path := e.path(id)
+	if obj == nil {
+		return
+	}
+	for _, elem := range path[:len(path)-1] {
+		next, ok := obj[elem].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			obj[elem] = next
+		}
+		obj = next
+	}
+	obj[path[len(path)-1]] = value
+}
+
+// assign sets the values of the properties of the condition so that it holds, or does not.
+// Only the left operand of `and` does not hold when the `and` must not hold, so that requests stay just outside.
+func (g *generator) assign(cnd ast.Condition, want bool, e *env) {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type != token.LITERAL {
+			e.set(s.Value, want)
+		}
+
+	case *ast.PrefixCondition:
+		g.assign(s.Right, !want, e)
+
+	case *ast.InfixCondition:
+		switch s.Token.Type {
+		case token.AND:
+			g.assign(s.Right, true, e)
+			g.assign(s.Left, want, e)
+		case token.OR:
+			g.assign(s.Right, false, e)
+			g.assign(s.Left, want, e)
+		default:
+			g.compare(s, want, e)
+		}
+	}
+}
+
+// compare sets the value of the property of the comparison so that it holds, or does not
+func (g *generator) compare(s *ast.InfixCondition, want bool, e *env) {
+	op := s.Token.Type
+	lhs, lhsIsProperty := property(s.Left)
+	rhs, rhsIsProperty := property(s.Right)
+
+	switch {
+	case lhsIsProperty && rhsIsProperty:
+		// both properties are set to the same value, or to different values
+		if op == token.OP_EQUAL_TO || op == token.OP_NOT_EQUAL {
+			e.set(lhs, "seal_test")
+			if want == (op == token.OP_EQUAL_TO) {
+				e.set(rhs, "seal_test")
+			} else {
+				e.set(rhs, "not_seal_test")
+			}
+		}
+
+	case lhsIsProperty:
+		lit, ok := literal(s.Right)
+		if !ok {
+			return
+		}
+		if v, ok := g.value(lhs, op, lit, want); ok {
+			e.set(lhs, v)
+		}
+
+	case rhsIsProperty:
+		lit, ok := literal(s.Left)
+		if _, isList := lit.([]interface{}); !ok || isList {
+			return
+		}
+		if op != token.OP_IN {
+			if flipped, ok := flip(op); ok {
+				if v, ok := g.value(rhs, flipped, lit, want); ok {
+					e.set(rhs, v)
+				}
+			}
+			return
+		}
+
+		// e.g. "boss" in subject.groups
+		items := []interface{}{}
+		if cur, ok := e.get(rhs); ok {
+			if l, ok := cur.([]interface{}); ok {
+				items = l
+			}
+		}
+		list := []interface{}{}
+		for _, it := range items {
+			if it != lit {
+				list = append(list, it)
+			}
+		}
+		if want {
+			list = append(list, lit)
+		}
+		e.set(rhs, list)
+	}
+}
+
+// value returns a value of the property for which `property op lit` holds, or does not.
+// Numbers and strings are set just inside or just outside of the bounds,
+// and values of enumerated properties are preferred to made up ones.
+func (g *generator) value(id string, op token.TokenType, lit interface{}, want bool) (interface{}, bool) {
+	enum := g.enum(id)
+	switch l := lit.(type) {
+	case float64:
+		switch op {
+		case token.OP_EQUAL_TO:
+			return pick(want, l, l+1)
+		case token.OP_NOT_EQUAL:
+			return pick(want, l+1, l)
+		case token.OP_LESS_THAN:
+			return pick(want, l-1, l)
+		case token.OP_LESS_EQUAL:
+			return pick(want, l, l+1)
+		case token.OP_GREATER_THAN:
+			return pick(want, l+1, l)
+		case token.OP_GREATER_EQUAL:
+			return pick(want, l, l-1)
+		}
+
+	case string:
+		switch op {
+		case token.OP_EQUAL_TO:
+			return pick(want, l, other(enum, l))
+		case token.OP_NOT_EQUAL:
+			return pick(want, other(enum, l), l)
+		case token.OP_LESS_THAN:
+			if l == "" {
+				return nil, false
+			}
+			return pick(want, "", l)
+		case token.OP_LESS_EQUAL:
+			return pick(want, l, l+"~")
+		case token.OP_GREATER_THAN:
+			return pick(want, l+"~", l)
+		case token.OP_GREATER_EQUAL:
+			if l == "" {
+				return nil, false
+			}
+			return pick(want, l, "")
+		case token.OP_MATCH:
+			re, err := regexp.Compile(l)
+			if err != nil {
+				return nil, false
+			}
+			if want {
+				return sample(re)
+			}
+			for _, s := range []string{"", "seal_test"} {
+				if !re.MatchString(s) {
+					return s, true
+				}
+			}
+		}
+
+	case []interface{}:
+		if op != token.OP_IN || len(l) == 0 {
+			return nil, false
+		}
+		if want {
+			return l[0], true
+		}
+		for _, v := range enum {
+			if !containsValue(l, v) {
+				return v, true
+			}
+		}
+		switch first := l[0].(type) {
+		case string:
+			return "not_" + first, true
+		case float64:
+			max := first
+			for _, it := range l {
+				if f, ok := it.(float64); ok && f > max {
+					max = f
+				}
+			}
+			return max + 1, true
+		}
+	}
+	return nil, false
+}
+
+// enum returns the enumerated values of the ctx property of the identifier, if any
+func (g *generator) enum(id string) []interface{} {
+	idParts := lexer.SplitIdentifier(id)
+	if idParts.Table != "ctx" {
+		return nil
+	}
+	path := []string{idParts.Field}
+	if idParts.Key != "" {
+		path = append(append(path, idParts.Key), idParts.Path...)
+	}
+	prop, ok := types.LookupProperty(g.swtype.GetProperties(), strings.Join(path, "."))
+	if !ok {
+		return nil
+	}
+	return types.GetEnum(prop)
+}
+
+// property returns the identifier of the ctx or subject property of the operand, if any
+func property(cnd ast.Condition) (string, bool) {
+	id, ok := cnd.(*ast.Identifier)
+	if !ok || id.Token.Type == token.LITERAL {
+		return "", false
+	}
+	return id.Value, strings.HasPrefix(id.Value, "ctx.") || strings.HasPrefix(id.Value, types.SUBJECT+".")
+}
+
+// literal returns the value of the literal operand: a string, a float64 or a list of them
+func literal(cnd ast.Condition) (interface{}, bool) {
+	switch s := cnd.(type) {
+	case *ast.Identifier:
+		if s.Token.Type == token.LITERAL {
+			return s.Value, true
+		}
+	case *ast.IntegerLiteral:
+		return float64(s.Value), true
+	case *ast.ArrayLiteral:
+		items := []interface{}{}
+		for _, it := range s.Items {
+			item, ok := literal(it)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// flip returns the operator comparing the operands in reverse order
+func flip(op token.TokenType) (token.TokenType, bool) {
+	switch op {
+	case token.OP_EQUAL_TO, token.OP_NOT_EQUAL:
+		return op, true
+	case token.OP_LESS_THAN:
+		return token.OP_GREATER_THAN, true
+	case token.OP_LESS_EQUAL:
+		return token.OP_GREATER_EQUAL, true
+	case token.OP_GREATER_THAN:
+		return token.OP_LESS_THAN, true
+	case token.OP_GREATER_EQUAL:
+		return token.OP_LESS_EQUAL, true
+	}
+	return op, false
+}
+
+// sample returns a string matching the pattern, made of its literal characters
+func sample(re *regexp.Regexp) (interface{}, bool) {
+	s := strings.TrimSuffix(strings.TrimPrefix(re.String(), "^"), "$")
+	s = strings.NewReplacer(".*", "", ".+", "x").Replace(s)
+	s = regexp.MustCompile(`\\(.)`).ReplaceAllString(s, "$1")
+	if !re.MatchString(s) {
+		return nil, false
+	}
+	return s, true
+}
+
+// other returns the first enumerated value other than the string, or a made up one
+func other(enum []interface{}, s string) string {
+	for _, v := range enum {
+		if str, ok := v.(string); ok && str != s {
+			return str
+		}
+	}
+	return "not_" + s
+}
+
+func pick(want bool, inside, outside interface{}) (interface{}, bool) {
+	if want {
+		return inside, true
+	}
+	return outside, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, it := range list {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package testgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/infobloxopen/seal/pkg/eval"
+	"github.com/infobloxopen/seal/pkg/internal/fixture"
+)
+
+func TestGenerate(t *testing.T) {
+	type expectedCase struct {
+		name    string
+		request string
+		expect  bool
+	}
+	tests := map[string]struct {
+		policy   string
+		expected []expectedCase
+	}{
+		"conjunction": {
+			policy: `allow subject group customers to buy shop.pet where ctx.status == "available" and ctx.age > 2;`,
+			expected: []expectedCase{
+				{"stmt0_positive", `{"type":"shop.pet","verb":"buy","ctx":[{"age":3,"status":"available"}],"subject":{"groups":["customers"]}}`, true},
+				{"stmt0_non_member", `{"type":"shop.pet","verb":"buy","ctx":[{"age":3,"status":"available"}],"subject":{"groups":["not_customers"]}}`, false},
+				{"stmt0_wrong_verb", `{"type":"shop.pet","verb":"get","ctx":[{"age":3,"status":"available"}],"subject":{"groups":["customers"]}}`, false},
+				{"stmt0_outside1", `{"type":"shop.pet","verb":"buy","ctx":[{"age":3,"status":"sold"}],"subject":{"groups":["customers"]}}`, false},
+				{"stmt0_outside2", `{"type":"shop.pet","verb":"buy","ctx":[{"age":2,"status":"available"}],"subject":{"groups":["customers"]}}`, false},
+			},
+		},
+		"negation": {
+			policy: `deny to manage shop.pet where not ctx.neutered;`,
+			expected: []expectedCase{
+				{"stmt0_positive", `{"type":"shop.pet","verb":"get","ctx":[{"neutered":false}]}`, true},
+				{"stmt0_wrong_verb", `{"type":"shop.pet","verb":"buy","ctx":[{"neutered":false}]}`, false},
+				{"stmt0_outside1", `{"type":"shop.pet","verb":"get","ctx":[{"neutered":true}]}`, false},
+			},
+		},
+		"regexp and subject groups": {
+			policy: `allow subject user bob to buy shop.pet where ctx.email =~ "^.*@acme\.com$" and "vip" in subject.groups;`,
+			expected: []expectedCase{
+				{"stmt0_positive", `{"type":"shop.pet","verb":"buy","ctx":[{"email":"@acme.com"}],"subject":{"groups":["vip"],"sub":"bob"}}`, true},
+				{"stmt0_non_member", `{"type":"shop.pet","verb":"buy","ctx":[{"email":"@acme.com"}],"subject":{"groups":["vip"],"sub":"not_bob"}}`, false},
+				{"stmt0_wrong_verb", `{"type":"shop.pet","verb":"get","ctx":[{"email":"@acme.com"}],"subject":{"groups":["vip"],"sub":"bob"}}`, false},
+				{"stmt0_outside1", `{"type":"shop.pet","verb":"buy","ctx":[{"email":""}],"subject":{"groups":["vip"],"sub":"bob"}}`, false},
+				{"stmt0_outside2", `{"type":"shop.pet","verb":"buy","ctx":[{"email":"@acme.com"}],"subject":{"groups":[],"sub":"bob"}}`, false},
+			},
+		},
+		"disjunction": {
+			policy: `allow subject group customers to manage shop.pet where ctx.age <= 1 or ctx.status in ["sold"];`,
+			expected: []expectedCase{
+				{"stmt0_positive", `{"type":"shop.pet","verb":"get","ctx":[{"age":1}],"subject":{"groups":["customers"]}}`, true},
+				{"stmt0_non_member", `{"type":"shop.pet","verb":"get","ctx":[{"age":1}],"subject":{"groups":["not_customers"]}}`, false},
+				{"stmt0_wrong_verb", `{"type":"shop.pet","verb":"buy","ctx":[{"age":1}],"subject":{"groups":["customers"]}}`, false},
+				{"stmt0_outside1", `{"type":"shop.pet","verb":"get","ctx":[{"age":2}],"subject":{"groups":["customers"]}}`, false},
+			},
+		},
+	}
+
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			cases, err := Generate(fixture.Parse(t, "", tst.policy))
+			if err != nil {
+				t.Fatalf("could not generate tests: %s", err)
+			}
+
+			if len(cases) != len(tst.expected) {
+				names := []string{}
+				for _, c := range cases {
+					names = append(names, c.Name)
+				}
+				t.Fatalf("expected %d cases, got %d: %s", len(tst.expected), len(cases), strings.Join(names, ", "))
+			}
+			for idx, exp := range tst.expected {
+				c := cases[idx]
+				if c.Name != exp.name {
+					t.Errorf("case #%d: expected name %s, got %s", idx, exp.name, c.Name)
+				}
+				js, err := json.Marshal(c.Request)
+				if err != nil {
+					t.Fatalf("case %s: could not marshal request: %s", c.Name, err)
+				}
+				if string(js) != exp.request {
+					t.Errorf("case %s:\nexpected request %s\n     got request %s", c.Name, exp.request, js)
+				}
+				if c.Expect != exp.expect {
+					t.Errorf("case %s: expected %v, got %v", c.Name, exp.expect, c.Expect)
+				}
+			}
+		})
+	}
+}
+
+func TestRego(t *testing.T) {
+	cases := []*Case{
+		{
+			Name:    "stmt0_positive",
+			Rule:    `allow subject group customers to buy shop.pet where (ctx.status == "available");`,
+			Action:  eval.ActionAllow,
+			Request: &eval.Request{Type: "shop.pet", Verb: "buy", Ctx: []map[string]interface{}{{"status": "available", "age": float64(3)}}, Subject: map[string]interface{}{"groups": []interface{}{"customers"}}},
+			Expect:  true,
+		},
+		{
+			Name:    "stmt0_outside1",
+			Rule:    `allow subject group customers to buy shop.pet where (ctx.status == "available");`,
+			Action:  eval.ActionAllow,
+			Comment: `outside (ctx.status == "available")`,
+			Request: &eval.Request{Type: "shop.pet", Verb: "buy", Ctx: []map[string]interface{}{{"status": "<sold>"}}, Subject: map[string]interface{}{}},
+		},
+	}
+
+	expected := `package shop
+
+# Code generated by seal compile --emit-tests. DO NOT EDIT.
+
+# allow subject group customers to buy shop.pet where (ctx.status == "available");
+
+test_stmt0_positive {
+	in := {
+		"type": "shop.pet",
+		"verb": "buy",
+		"ctx": [{"age": 3, "status": "available"}],
+		"jwt": sealtest_jwt_encode_sign({"groups": ["customers"]}),
+	}
+
+	allow with input as in
+}
+
+# outside (ctx.status == "available")
+test_stmt0_outside1 {
+	in := {
+		"type": "shop.pet",
+		"verb": "buy",
+		"ctx": [{"status": "<sold>"}],
+		"jwt": sealtest_jwt_encode_sign({}),
+	}
+
+	not allow with input as in
+}
+
+` + jwtHelper
+
+	if actual := Rego("shop", cases); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		expected bool
+	}{
+		"generated": {
+			content:  Rego("shop", nil),
+			expected: true,
+		},
+		"hand written": {
+			content: "package shop\n\ntest_allow {\n\tallow\n}\n",
+		},
+		"header not at the start of a line": {
+			content: "package shop\n\nx := \"# Code generated by seal compile --emit-tests. DO NOT EDIT.\"\n",
+		},
+	}
+
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := IsGenerated([]byte(tst.content)); actual != tst.expected {
+				t.Errorf("expected %v, got %v", tst.expected, actual)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package token
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -9,6 +11,38 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+}
+
+// Position is a location in a seal source
+type Position struct {
+	File   string // file name, empty if unknown
+	Line   int    // line number, starting at 1
+	Column int    // column number (in bytes), starting at 1
+}
+
+// IsValid returns true if the position is known
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as file:line:col, or line:col if the file is unknown
+func (p Position) String() string {
+	if !p.IsValid() {
+		return ""
+	}
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Message prefixes msg with the position, if the position is known
+func (p Position) Message(msg string) string {
+	if !p.IsValid() {
+		return msg
+	}
+	return fmt.Sprintf("%s: %s", p, msg)
 }
 
 const (
@@ -20,6 +54,7 @@ const (
 	IDENT        = "IDENT"
 	INT          = "INT" // 1343456
 	TYPE_PATTERN = "TYPE_PATTERN"
+	DATA_REF     = "DATA_REF" // $threat.feed["over_21_skus"]
 	DELIMETER    = ";"
 	COMMA        = ","
 
@@ -32,6 +67,7 @@ const (
 	OP_GREATER_EQUAL = ">="
 	OP_MATCH         = "=~"
 	OP_IN            = "in"
+	ASSIGN           = "="
 	OPEN_PAREN       = "("
 	CLOSE_PAREN      = ")"
 	OPEN_BLOCK       = "{"
@@ -76,6 +112,12 @@ func LookupIdent(ident string) TokenType {
 	return IDENT
 }
 
+// IsKeyword returns true if ident is a reserved keyword
+func IsKeyword(ident string) bool {
+	_, ok := keywords[ident]
+	return ok
+}
+
 func LookupOperatorComparison(op string) TokenType {
 	logrus.WithField("op", op).Trace("LookupOperatorComparison")
 	switch op {
@@ -109,6 +151,14 @@ func LookupOperatorLogical(op string) TokenType {
 	return TokenType(op)
 }
 
+func LookupOperatorAssignment(op string) TokenType {
+	logrus.WithField("op", op).Trace("LookupOperatorAssignment")
+	if op == ASSIGN {
+		return ASSIGN
+	}
+	return ILLEGAL
+}
+
 func LookupOperator(op string) TokenType {
 	logrus.WithField("op", op).Trace("LookupOperator")
 	type funcLookupOperator func(string) TokenType
@@ -116,6 +166,7 @@ func LookupOperator(op string) TokenType {
 	for _, f := range []funcLookupOperator{
 		LookupOperatorComparison,
 		LookupOperatorLogical,
+		LookupOperatorAssignment,
 	} {
 		if typ := f(op); typ != ILLEGAL {
 			return typ
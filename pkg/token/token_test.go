@@ -73,6 +73,11 @@ func TestLookupOperator(t *testing.T) {
 			tok:      "in",
 			expected: OP_IN,
 		},
+		{
+			name:     "assign",
+			tok:      "=",
+			expected: ASSIGN,
+		},
 	}
 
 	for _, tst := range testcases {
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -69,12 +70,35 @@ func (s *swaggerAction) GetName() string {
 }
 
 func (s *swaggerAction) GetProperty(name string) (ActionProperty, bool) {
-	// FIXME, get real property
-	return nil, false
+	if s.schema == nil || s.schema.Value == nil {
+		return nil, false
+	}
+	prop, ok := s.schema.Value.Properties[name]
+	if !ok || prop == nil || prop.Value == nil {
+		return nil, false
+	}
+	return &actionProperty{
+		name:   name,
+		schema: prop,
+	}, true
+}
+
+func (s *swaggerAction) GetProperties() map[string]ActionProperty {
+	properties := make(map[string]ActionProperty)
+	if s.schema == nil || s.schema.Value == nil {
+		return properties
+	}
+	for k := range s.schema.Value.Properties {
+		if prop, ok := s.GetProperty(k); ok {
+			properties[k] = prop
+		}
+	}
+	return properties
 }
 
 type ActionProperty interface {
 	GetName() string
+	GetType() string
 	String() string
 }
 
@@ -87,6 +111,30 @@ func (aa *actionProperty) GetName() string {
 	return aa.name
 }
 
+// GetType returns the swagger type of the property (boolean, integer, number, string...)
+func (aa *actionProperty) GetType() string {
+	return aa.schema.Value.Type
+}
+
 func (aa *actionProperty) String() string {
 	return aa.name
 }
+
+// IsValidActionPropertyValue returns true if value can be assigned to the action property.
+// isString is true when the value was written as a quoted string literal.
+func IsValidActionPropertyValue(prop ActionProperty, value string, isString bool) bool {
+	switch prop.GetType() {
+	case "boolean":
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case "integer":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "number":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case "string":
+		return isString
+	}
+	return true
+}
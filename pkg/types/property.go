@@ -16,17 +16,7 @@ func getPropertyTypes(schema *openapi3.SchemaRef) (map[string]Property, error) {
 	}
 
 	for k, v := range schema.Value.Properties {
-		pr := &swaggerProperty{
-			name:                        k,
-			schema:                      v.Value,
-			additionalPropertiesAllowed: false,
-		}
-
-		if ap := v.Value.AdditionalProperties.Has; ap != nil && *ap {
-			pr.additionalPropertiesAllowed = true
-		}
-
-		properties[k] = pr
+		properties[k] = newSwaggerProperty(k, v.Value)
 	}
 
 	ap := schema.Value.AdditionalProperties.Has
@@ -40,6 +30,20 @@ func getPropertyTypes(schema *openapi3.SchemaRef) (map[string]Property, error) {
 	return properties, nil
 }
 
+func newSwaggerProperty(name string, schema *openapi3.Schema) *swaggerProperty {
+	pr := &swaggerProperty{
+		name:                        name,
+		schema:                      schema,
+		additionalPropertiesAllowed: false,
+	}
+
+	if ap := schema.AdditionalProperties.Has; ap != nil && *ap {
+		pr.additionalPropertiesAllowed = true
+	}
+
+	return pr
+}
+
 type SwaggerProperty interface {
 	GetName() string
 	String() string
@@ -59,9 +63,33 @@ func (s *swaggerProperty) GetName() string {
 	return s.name
 }
 
-func (s *swaggerProperty) GetProperty(name string) (SwaggerProperty, bool) {
-	// FIXME, get real property
-	return nil, false
+// GetType returns the swagger type of the property (boolean, integer, number, string, object...)
+func (s *swaggerProperty) GetType() string {
+	if s.schema == nil {
+		return ""
+	}
+	return s.schema.Type
+}
+
+// GetProperty returns the nested property of an object property,
+// $ref-ed schemas are already resolved by the swagger loader
+func (s *swaggerProperty) GetProperty(name string) (Property, bool) {
+	if s.schema == nil {
+		return nil, false
+	}
+	v, ok := s.schema.Properties[name]
+	if !ok || v == nil || v.Value == nil {
+		return nil, false
+	}
+	return newSwaggerProperty(name, v.Value), true
+}
+
+// GetEnum returns the enumerated values of the property, if any
+func (s *swaggerProperty) GetEnum() []interface{} {
+	if s.schema == nil {
+		return nil
+	}
+	return s.schema.Enum
 }
 
 func (s *swaggerProperty) HasAdditionalProperties() bool {
@@ -84,3 +112,11 @@ func (s *swaggerProperty) GetExtensionProp(name string) (string, bool, error) {
 	}
 	return string(marshaledBytes), true, nil
 }
+
+// GetEnum returns the enumerated values of the property, or nil if the property does not enumerate its values
+func GetEnum(p Property) []interface{} {
+	if e, ok := p.(interface{ GetEnum() []interface{} }); ok {
+		return e.GetEnum()
+	}
+	return nil
+}
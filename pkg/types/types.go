@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mb0/glob"
 	"github.com/sirupsen/logrus"
 )
 
@@ -225,12 +226,14 @@ type Action interface {
 	GetName() string
 	String() string
 	GetProperty(name string) (ActionProperty, bool)
+	GetProperties() map[string]ActionProperty
 }
 
 type Property interface {
 	GetName() string
+	GetType() string
 	String() string
-	GetProperty(name string) (SwaggerProperty, bool)
+	GetProperty(name string) (Property, bool)
 	HasAdditionalProperties() bool
 	GetExtensionProp(name string) (string, bool, error)
 }
@@ -253,17 +256,60 @@ func IsValidAction(t Type, action string) bool {
 	return false
 }
 
-func IsValidProperty(t Type, property string) bool {
-	for _, a := range t.GetProperties() {
-		if property == "ctx."+a.GetName() {
-			return true
+// GetActionProperty returns the property of the action of type t
+func GetActionProperty(t Type, action, property string) (ActionProperty, bool) {
+	act, ok := t.GetActions()[action]
+	if !ok || IsNilInterface(act) {
+		return nil, false
+	}
+	return act.GetProperty(property)
+}
+
+// MatchActionProperty returns the property of the action of the types matching the type pattern,
+// types are visited in name order as the parser does, the first type declaring the property wins
+func MatchActionProperty(ts []Type, typePattern, action, property string) (ActionProperty, bool) {
+	matched := []Type{}
+	for _, t := range ts {
+		if m, err := glob.Match(typePattern, t.String()); err == nil && m {
+			matched = append(matched, t)
 		}
 	}
-	return false
+	sort.Slice(matched, func(i, j int) bool { return matched[i].String() < matched[j].String() })
+
+	for _, t := range matched {
+		if prop, ok := GetActionProperty(t, action, property); ok {
+			return prop, true
+		}
+	}
+	return nil, false
+}
+
+// LookupProperty returns the property at the dotted path (e.g. buyer.email) of the properties,
+// walking into nested object properties
+func LookupProperty(properties map[string]Property, path string) (Property, bool) {
+	names := strings.Split(path, ".")
+	prop, ok := properties[names[0]]
+	if !ok || prop == nil {
+		return nil, false
+	}
+	for _, name := range names[1:] {
+		if prop, ok = prop.GetProperty(name); !ok || prop == nil {
+			return nil, false
+		}
+	}
+	return prop, true
+}
+
+func IsValidProperty(t Type, property string) bool {
+	if !strings.HasPrefix(property, "ctx.") {
+		return false
+	}
+	_, ok := LookupProperty(t.GetProperties(), strings.TrimPrefix(property, "ctx."))
+	return ok
 }
 
 func IsValidSubject(t map[string]Type, property string) bool {
-	if !strings.HasPrefix(property, SUBJECT) {
+	if !strings.HasPrefix(property, SUBJECT+".") {
 		return false
 	}
 
@@ -272,20 +318,16 @@ func IsValidSubject(t map[string]Type, property string) bool {
 		return false
 	}
 
-	for _, pName := range tp.GetProperties() {
-		if property == SUBJECT+"."+pName.GetName() {
-			return true
-		}
-	}
-	return false
+	_, ok = LookupProperty(tp.GetProperties(), strings.TrimPrefix(property, SUBJECT+"."))
+	return ok
 }
 
 func IsValidTag(t Type, property string) bool {
-	for _, a := range t.GetProperties() {
-		if strings.HasPrefix(property, "ctx."+a.GetName()+"[\"") && a.HasAdditionalProperties() {
-			return true
-		}
+	idx := strings.Index(property, "[\"")
+	if !strings.HasPrefix(property, "ctx.") || idx < 0 {
+		return false
 	}
 
-	return false
+	a, ok := LookupProperty(t.GetProperties(), property[len("ctx."):idx])
+	return ok && a.HasAdditionalProperties()
 }
@@ -18,15 +18,145 @@ func TestNewTypeFromOpenAPIv3(t *testing.T) {
 		for _, ac := range st.GetActions() {
 			t.Logf("got action: %#v", ac)
 			if prop, exists := ac.GetProperty(ac.GetName()); prop != nil && exists {
-				t.Logf("  got type schema for action: %#v", prop)
-			} else {
-				t.Logf("    TODO: get type schema for action")
+				t.Fatalf("unexpected property %s for action %s", prop, ac)
 			}
 		}
 
+		prop, exists := GetActionProperty(st, "allow", "log")
+		if !exists {
+			t.Fatalf("expected property log for action allow")
+		}
+		if expected, actual := "boolean", prop.GetType(); expected != actual {
+			t.Fatalf("expected %s, got %s", expected, actual)
+		}
+		if _, exists := GetActionProperty(st, "deny", "log"); exists {
+			t.Fatalf("did not expect property log for implicit action deny")
+		}
+
+	}
+}
+
+func TestIsValidActionPropertyValue(t *testing.T) {
+	types, err := NewTypeFromOpenAPIv3(exampleSwagger)
+	if err != nil {
+		t.Fatalf("could not load swagger yaml: %s", err)
+	}
+	prop, exists := GetActionProperty(types[0], "allow", "log")
+	if !exists {
+		t.Fatalf("expected property log for action allow")
+	}
+
+	tests := []struct {
+		value    string
+		isString bool
+		expected bool
+	}{
+		{value: "true", isString: true, expected: true},
+		{value: "false", isString: false, expected: true},
+		{value: "yes", isString: true, expected: false},
+		{value: "", isString: true, expected: false},
+	}
+
+	for idx, tst := range tests {
+		if actual := IsValidActionPropertyValue(prop, tst.value, tst.isString); actual != tst.expected {
+			t.Errorf("tst #%d: value=%q expected=%v actual=%v", idx, tst.value, tst.expected, actual)
+		}
+	}
+}
+
+func TestLookupProperty(t *testing.T) {
+	types, err := NewTypeFromOpenAPIv3(nestedSwagger)
+	if err != nil {
+		t.Fatalf("could not load swagger yaml: %s", err)
+	}
+	typs := map[string]Type{}
+	for _, typ := range types {
+		typs[typ.String()] = typ
+	}
+	order := typs["petstore.order"]
+
+	tests := []struct {
+		property string
+		expected bool
+	}{
+		{property: "ctx.id", expected: true},
+		{property: "ctx.buyer", expected: true},
+		{property: "ctx.buyer.email", expected: true},
+		{property: "ctx.buyer.address.city", expected: true},
+		{property: "ctx.buyer.phone", expected: false},
+		{property: "ctx.buyer.address.city.zip", expected: false},
+		{property: "subject.email", expected: false},
+	}
+	for idx, tst := range tests {
+		if actual := IsValidProperty(order, tst.property); actual != tst.expected {
+			t.Errorf("tst #%d: property=%s expected=%v actual=%v", idx, tst.property, tst.expected, actual)
+		}
+	}
+
+	subjects := []struct {
+		property string
+		expected bool
+	}{
+		{property: "subject.email", expected: true},
+		{property: "subject.address.city", expected: true},
+		{property: "subject.address.zip", expected: false},
+		{property: "subjects.email", expected: false},
+	}
+	for idx, tst := range subjects {
+		if actual := IsValidSubject(typs, tst.property); actual != tst.expected {
+			t.Errorf("tst #%d: property=%s expected=%v actual=%v", idx, tst.property, tst.expected, actual)
+		}
+	}
+
+	if !IsValidTag(order, `ctx.buyer.tags["vip"]`) {
+		t.Errorf("expected ctx.buyer.tags[\"vip\"] to be a valid tag")
+	}
+	if IsValidTag(order, `ctx.buyer.email["vip"]`) {
+		t.Errorf("expected ctx.buyer.email[\"vip\"] not to be a valid tag")
 	}
 }
 
+var nestedSwagger = []byte(`
+openapi: "3.0.0"
+components:
+  schemas:
+    subject:
+      type: object
+      properties:
+        email:
+          type: string
+        address:
+          $ref: '#/components/schemas/address'
+      x-seal-type: none
+    address:
+      type: object
+      properties:
+        city:
+          type: string
+      x-seal-type: none
+    petstore.order:
+      type: object
+      properties:
+        id:
+          type: string
+        buyer:
+          type: object
+          properties:
+            email:
+              type: string
+            address:
+              $ref: '#/components/schemas/address'
+            tags:
+              type: object
+              additionalProperties: true
+      x-seal-actions:
+      - allow
+      - deny
+      x-seal-verbs:
+        manage:
+      x-seal-default-action: deny
+`)
+
 var exampleSwagger = []byte(`
 openapi: "3.0.0"
 components: